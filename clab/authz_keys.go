@@ -25,7 +25,7 @@ func (c *CLab) CreateAuthzKeysFile() error {
 
 	b := new(bytes.Buffer)
 
-	p, err := resolvePath(pubKeysGlob)
+	p, err := resolvePath(pubKeysGlob, "")
 	if err != nil {
 		return fmt.Errorf("failed resolving path %s", pubKeysGlob)
 	}
@@ -0,0 +1,142 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+)
+
+// defaultDockerfile is the Dockerfile used when the build doesn't name one
+const defaultDockerfile = "Dockerfile"
+
+// invalidRepoChars are the characters not allowed in an image repository name
+var invalidRepoChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// resolveBuild returns a copy of the image build with the context resolved to an absolute path
+// and the Dockerfile defaulted, the build may be shared by the nodes of a kind.
+// An empty build disables the build inherited from the kind or the defaults.
+// A relative context is resolved against the base directory, if it is set
+func resolveBuild(b *types.BuildConfig, base string) (*types.BuildConfig, error) {
+	if b == nil || (b.Context == "" && b.Dockerfile == "" && len(b.Args) == 0) {
+		return nil, nil
+	}
+	if b.Context == "" {
+		return nil, fmt.Errorf("build context is not set")
+	}
+	r := *b
+	var err error
+	if r.Context, err = resolvePath(r.Context, base); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(r.Context); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("build context %q is not a directory", b.Context)
+	}
+	if r.Dockerfile == "" {
+		r.Dockerfile = defaultDockerfile
+	}
+	r.Dockerfile = filepath.Clean(r.Dockerfile)
+	if filepath.IsAbs(r.Dockerfile) || r.Dockerfile == ".." || strings.HasPrefix(r.Dockerfile, "../") {
+		return nil, fmt.Errorf("dockerfile %q must be a path within the build context", b.Dockerfile)
+	}
+	if _, err := os.Stat(filepath.Join(r.Context, r.Dockerfile)); err != nil {
+		return nil, fmt.Errorf("dockerfile %q is not found in the build context %q", r.Dockerfile, b.Context)
+	}
+	return &r, nil
+}
+
+// buildImageName returns the name of the lab image built from the build context,
+// the image is tagged with the build hash so that an unchanged build is not repeated
+func (c *CLab) buildImageName(b *types.BuildConfig, hash string) string {
+	name := invalidRepoChars.ReplaceAllString(strings.ToLower(filepath.Base(b.Context)), "-")
+	name = strings.Trim(name, "-_.")
+	if name == "" {
+		name = "build"
+	}
+	repo := strings.ToLower(fmt.Sprintf("clab-%s-%s", c.Config.Name, name))
+	return fmt.Sprintf("%s:%s", repo, hash[:12])
+}
+
+// buildHash returns the hash of the Dockerfile, the build args and the build context files
+// which are not excluded by the .dockerignore file
+func buildHash(b *types.BuildConfig) (string, error) {
+	excludes, err := utils.BuildContextExcludes(b.Context, b.Dockerfile)
+	if err != nil {
+		return "", err
+	}
+	pm, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "dockerfile %s\x00", filepath.ToSlash(b.Dockerfile))
+	args := make([]string, 0, len(b.Args))
+	for k, v := range b.Args {
+		args = append(args, k+"="+v)
+	}
+	sort.Strings(args)
+	for _, a := range args {
+		fmt.Fprintf(h, "arg %s\x00", a)
+	}
+
+	// filepath.Walk visits the files in lexical order, which makes the hash stable
+	err = filepath.Walk(b.Context, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(b.Context, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		excluded, err := pm.Matches(rel)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			// the files of an excluded directory may be included back by an exception
+			if fi.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fmt.Fprintf(h, "%s %s\x00", rel, fi.Mode())
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+		case fi.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(h, f); err != nil {
+				return err
+			}
+			h.Write([]byte{0})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read the build context %q: %v", b.Context, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestBuildHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Dockerfile", "FROM alpine:3\nCOPY . /app\n")
+	write(".dockerignore", "*.log\n")
+	write("app.sh", "echo 1\n")
+	b := &types.BuildConfig{Context: dir, Dockerfile: "Dockerfile"}
+	hash := func() string {
+		t.Helper()
+		h, err := buildHash(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	h := hash()
+	write("build.log", "ignored")
+	if got := hash(); got != h {
+		t.Error("the hash changed with a file excluded by .dockerignore")
+	}
+	write("app.sh", "echo 2\n")
+	if got := hash(); got == h {
+		t.Error("the hash didn't change with a modified context file")
+	}
+	h = hash()
+	b.Args = map[string]string{"VERSION": "2"}
+	if got := hash(); got == h {
+		t.Error("the hash didn't change with the build args")
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,8 +33,20 @@ type CLab struct {
 	Runtimes      map[string]runtime.ContainerRuntime
 	globalRuntime string
 	Dir           *Directory
-
-	timeout time.Duration
+	// Events publishes the lab lifecycle events
+	Events *EventBus
+
+	timeout      time.Duration
+	webhooksOnce sync.Once
+	// pullProgress receives the images pull progress
+	pullProgress io.Writer
+	// maxPulls limits the number of images pulled concurrently
+	maxPulls uint
+	// nodesDone channels are closed once the nodes deploy finishes, successfully or not
+	nodesDone map[string]chan struct{}
+	// baseDir is the directory the relative paths of the topology and the lab directory are resolved against,
+	// the working directory is used when it is empty
+	baseDir string
 }
 
 type Directory struct {
@@ -53,6 +68,22 @@ func WithTimeout(dur time.Duration) ClabOption {
 	}
 }
 
+// WithPullProgress sets the writer receiving the progress of the images pulled during the deployment
+func WithPullProgress(w io.Writer) ClabOption {
+	return func(c *CLab) error {
+		c.pullProgress = w
+		return nil
+	}
+}
+
+// WithMaxPulls limits the number of images pulled concurrently during the deployment
+func WithMaxPulls(n uint) ClabOption {
+	return func(c *CLab) error {
+		c.maxPulls = n
+		return nil
+	}
+}
+
 func WithRuntime(name string, rtconfig *runtime.RuntimeConfig) ClabOption {
 	return func(c *CLab) error {
 		// define runtime name.
@@ -94,6 +125,23 @@ func WithKeepMgmtNet() ClabOption {
 	}
 }
 
+// WithBaseDir sets the directory the relative paths of the topology are resolved against
+// and the lab directory is created in, instead of the working directory.
+// The option must precede WithTopoFile option
+func WithBaseDir(dir string) ClabOption {
+	return func(c *CLab) error {
+		if c.TopoFile.path != "" {
+			return errors.New("base directory must be set before the topology file")
+		}
+		d, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		c.baseDir = d
+		return nil
+	}
+}
+
 func WithTopoFile(file, varsFile string) ClabOption {
 	return func(c *CLab) error {
 		if file == "" {
@@ -119,6 +167,7 @@ func NewContainerLab(opts ...ClabOption) (*CLab, error) {
 		Nodes:    make(map[string]nodes.Node),
 		Links:    make(map[int]*types.Link),
 		Runtimes: make(map[string]runtime.ContainerRuntime),
+		Events:   new(EventBus),
 	}
 
 	for _, opt := range opts {
@@ -170,6 +219,10 @@ func (c *CLab) CreateNodes(ctx context.Context, maxWorkers uint,
 	serialNodes map[string]struct{}) (*sync.WaitGroup, *sync.WaitGroup) {
 	staticIPNodes := make(map[string]nodes.Node)
 	dynIPNodes := make(map[string]nodes.Node)
+	c.nodesDone = make(map[string]chan struct{}, len(c.Nodes))
+	for name := range c.Nodes {
+		c.nodesDone[name] = make(chan struct{})
+	}
 
 	for name, n := range c.Nodes {
 		if n.Config().MgmtIPv4Address != "" || n.Config().MgmtIPv6Address != "" {
@@ -206,32 +259,7 @@ func (c *CLab) scheduleNodes(ctx context.Context, maxWorkers int,
 					return
 				}
 				log.Debugf("Worker %d received node: %+v", i, node.Config())
-
-				// Apply any startup delay
-				delay := node.Config().StartupDelay
-				if delay > 0 {
-					log.Infof("node %q is being delayed for %d seconds", node.Config().ShortName, delay)
-					time.Sleep(time.Duration(delay) * time.Second)
-				}
-
-				// PreDeploy
-				err := node.PreDeploy(c.Config.Name, c.Dir.LabCA, c.Dir.LabCARoot)
-				if err != nil {
-					log.Errorf("failed pre-deploy phase for node %q: %v", node.Config().ShortName, err)
-					continue
-				}
-				// Deploy
-				err = node.Deploy(ctx)
-				if err != nil {
-					log.Errorf("failed deploy phase for node %q: %v", node.Config().ShortName, err)
-					continue
-				}
-
-				// set deployment status of a node to created to indicate that it finished creating
-				// this status is checked during link creation to only schedule link creation if both nodes are ready
-				c.m.Lock()
-				node.Config().DeploymentStatus = "created"
-				c.m.Unlock()
+				c.deployNode(ctx, node)
 			case <-ctx.Done():
 				return
 			}
@@ -258,8 +286,21 @@ func (c *CLab) scheduleNodes(ctx context.Context, maxWorkers int,
 		go workerFunc(maxWorkers, serialChan, wg)
 	}
 
-	// send nodes to workers
-	for _, n := range scheduledNodes {
+	// send nodes to workers, the nodes sharing a network namespace after the node owning it,
+	// so that a worker never waits for a node which is not being deployed yet
+	names := make([]string, 0, len(scheduledNodes))
+	for name := range scheduledNodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := c.netnsDepth(names[i]), c.netnsDepth(names[j])
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		n := scheduledNodes[name]
 		if _, ok := serialNodes[n.Config().LongName]; ok {
 			// delete the entry to avoid starting a serial worker in the
 			// case of dynamic IP nodes scheduling
@@ -277,6 +318,59 @@ func (c *CLab) scheduleNodes(ctx context.Context, maxWorkers int,
 	return wg
 }
 
+// deployNode runs the pre-deploy and deploy phases of the node.
+// A node sharing the network namespace of another node waits for that node to be deployed first
+func (c *CLab) deployNode(ctx context.Context, node nodes.Node) {
+	defer close(c.nodesDone[node.Config().ShortName])
+
+	if target := c.netnsNode(node.Config().ShortName); target != "" {
+		select {
+		case <-c.nodesDone[target]:
+		case <-ctx.Done():
+			return
+		}
+		c.m.RLock()
+		status := c.Nodes[target].Config().DeploymentStatus
+		c.m.RUnlock()
+		if status != "created" {
+			err := fmt.Errorf("node %q whose network namespace is shared failed to deploy", target)
+			log.Errorf("failed deploy phase for node %q: %v", node.Config().ShortName, err)
+			c.emitNodeFailed(node.Config().ShortName, PhaseDeploy, err)
+			return
+		}
+	}
+
+	// Apply any startup delay
+	delay := node.Config().StartupDelay
+	if delay > 0 {
+		log.Infof("node %q is being delayed for %d seconds", node.Config().ShortName, delay)
+		time.Sleep(time.Duration(delay) * time.Second)
+	}
+
+	// PreDeploy
+	c.emit(Event{Type: EventNodePreDeploy, Node: node.Config().ShortName})
+	err := node.PreDeploy(c.Config.Name, c.Dir.LabCA, c.Dir.LabCARoot)
+	if err != nil {
+		log.Errorf("failed pre-deploy phase for node %q: %v", node.Config().ShortName, err)
+		c.emitNodeFailed(node.Config().ShortName, PhasePreDeploy, err)
+		return
+	}
+	// Deploy
+	err = node.Deploy(ctx)
+	if err != nil {
+		log.Errorf("failed deploy phase for node %q: %v", node.Config().ShortName, err)
+		c.emitNodeFailed(node.Config().ShortName, PhaseDeploy, err)
+		return
+	}
+
+	// set deployment status of a node to created to indicate that it finished creating
+	// this status is checked during link creation to only schedule link creation if both nodes are ready
+	c.m.Lock()
+	node.Config().DeploymentStatus = "created"
+	c.m.Unlock()
+	c.emit(Event{Type: EventNodeCreated, Node: node.Config().ShortName})
+}
+
 // CreateLinks creates links using the specified number of workers
 func (c *CLab) CreateLinks(ctx context.Context, workers uint) {
 	wg := new(sync.WaitGroup)
@@ -395,11 +489,28 @@ func (c *CLab) ListContainers(ctx context.Context, labels []*types.GenericFilter
 		if err != nil {
 			return containers, fmt.Errorf("could not list containers: %v", err)
 		}
+		for i := range ctrs {
+			ctrs[i].Runtime = r.GetName()
+		}
 		containers = append(containers, ctrs...)
 	}
 	return containers, nil
 }
 
+// ContainerNodes returns the sorted names of the lab nodes backed by a container or a VM
+func (c *CLab) ContainerNodes() []string {
+	names := make([]string, 0, len(c.Nodes))
+	for name, n := range c.Nodes {
+		switch n.Config().Kind {
+		case nodes.NodeKindHOST, nodes.NodeKindBridge, nodes.NodeKindOVS:
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *CLab) GetNodeRuntime(query string) (runtime.ContainerRuntime, error) {
 	shortName, err := getShortName(c.Config.Name, query)
 	if err != nil {
@@ -0,0 +1,304 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"github.com/vishvananda/netlink"
+)
+
+func newFakeLab(t *testing.T, topo string) (*CLab, *fake.Runtime) {
+	t.Helper()
+	c, err := NewContainerLab(
+		WithTopoFile(topo, ""),
+		WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, c.GlobalRuntime().(*fake.Runtime)
+}
+
+// createNodes creates the lab nodes and waits for the workers to finish
+func createNodes(c *CLab) {
+	staticWg, dynWg := c.CreateNodes(context.Background(), uint(len(c.Nodes)), c.serialNodes())
+	if staticWg != nil {
+		staticWg.Wait()
+	}
+	if dynWg != nil {
+		dynWg.Wait()
+	}
+}
+
+func TestCreateNodes(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	r.FailOn(fake.MethodCreateContainer, "clab-fake-n3", errors.New("no space left on device"))
+
+	var m sync.Mutex
+	var failed []Event
+	c.Events.Subscribe(func(e Event) {
+		if e.Type == EventNodeFailed {
+			m.Lock()
+			failed = append(failed, e)
+			m.Unlock()
+		}
+	})
+
+	createNodes(c)
+
+	if d := cmp.Diff([]string{"clab-fake-n1", "clab-fake-n2"}, r.ContainerNames()); d != "" {
+		t.Errorf("unexpected containers (-want +got):\n%s", d)
+	}
+	for name, want := range map[string]string{"n1": "created", "n2": "created", "n3": ""} {
+		if got := c.Nodes[name].Config().DeploymentStatus; got != want {
+			t.Errorf("node %s: got deployment status %q, want %q", name, got, want)
+		}
+	}
+	wantFailed := []Event{{Type: EventNodeFailed, Lab: "fake", Node: "n3", Phase: PhaseDeploy, Error: "no space left on device"}}
+	if d := cmp.Diff(wantFailed, failed, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().String() == ".Time"
+	}, cmp.Ignore())); d != "" {
+		t.Errorf("unexpected node failed events (-want +got):\n%s", d)
+	}
+
+	labels := []*types.GenericFilter{{FilterType: "label", Match: "fake", Field: "containerlab", Operator: "="}}
+	containers, err := c.ListContainers(context.Background(), labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.enrichNodes(containers)
+	if got := c.Nodes["n2"].Config().MgmtIPv4Address; got != "172.20.20.100" {
+		t.Errorf("got n2 static address %q, want %q", got, "172.20.20.100")
+	}
+	if got := c.Nodes["n1"].Config().MgmtIPv4Address; got != "172.20.20.2" {
+		t.Errorf("got n1 dynamic address %q, want %q", got, "172.20.20.2")
+	}
+	if got := c.Nodes["n1"].Config().ContainerID; got == "" {
+		t.Error("n1 container ID is not set")
+	}
+}
+
+func TestDeleteNodes(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	createNodes(c)
+	r.FailOn(fake.MethodDeleteContainer, "clab-fake-n2", errors.New("device busy"))
+
+	c.DeleteNodes(context.Background(), 2, c.serialNodes())
+
+	want := []string{"clab-fake-n1", "clab-fake-n2", "clab-fake-n3"}
+	if d := cmp.Diff(want, r.CallArgs(fake.MethodDeleteContainer)); d != "" {
+		t.Errorf("unexpected deleted containers (-want +got):\n%s", d)
+	}
+	// the failed deletion doesn't stop the removal of the other nodes
+	if d := cmp.Diff([]string{"clab-fake-n2"}, r.ContainerNames()); d != "" {
+		t.Errorf("unexpected remaining containers (-want +got):\n%s", d)
+	}
+}
+
+func TestExecCmds(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	r.ExecFn = func(name string, cmd []string) ([]byte, []byte, error) {
+		if cmd[0] == "false" {
+			return nil, nil, errors.New("exit code 1")
+		}
+		return []byte(name + ": " + cmd[1]), nil, nil
+	}
+	createNodes(c)
+
+	labels := []*types.GenericFilter{{FilterType: "name", Match: "clab-fake-n1"}}
+	containers, err := c.ListContainers(context.Background(), labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("got %d containers, want 1", len(containers))
+	}
+
+	res, err := ExecCmds(context.Background(), containers[0], r, []string{"echo 'hello world'", "false", "echo skipped"})
+	if err == nil {
+		t.Error("expected an error of the failed command")
+	}
+	want := map[string]*ExecResult{"echo 'hello world'": {Stdout: []byte("clab-fake-n1: hello world")}}
+	if d := cmp.Diff(want, res); d != "" {
+		t.Errorf("unexpected exec results (-want +got):\n%s", d)
+	}
+}
+
+func TestNodeSecurity(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	createNodes(c)
+
+	privileged, unprivileged := true, false
+	want := map[string]*types.SecurityConfig{
+		// linux kind defaults
+		"clab-fake-n1": {Privileged: &unprivileged, CapAdd: []string{"NET_ADMIN", "NET_RAW"}},
+		// privileged mode set in the topology
+		"clab-fake-n2": {Privileged: &privileged, CapAdd: []string{"NET_ADMIN", "NET_RAW"}},
+	}
+	for name, w := range want {
+		got, ok := r.Security(name)
+		if !ok {
+			t.Fatalf("container %q is not created", name)
+		}
+		if d := cmp.Diff(w, got); d != "" {
+			t.Errorf("container %q: unexpected security config (-want +got):\n%s", name, d)
+		}
+	}
+}
+
+func TestCreateLinks(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	c, r := newFakeLab(t, "test_data/topo_fake_links.yml")
+	r.NetNS = true
+	t.Cleanup(func() { c.DeleteNodes(context.Background(), 2, nil) })
+
+	var m sync.Mutex
+	var links []*EventLink
+	c.Events.Subscribe(func(e Event) {
+		if e.Type == EventLinkCreated {
+			m.Lock()
+			links = append(links, e.Link)
+			m.Unlock()
+		}
+	})
+
+	createNodes(c)
+	if len(r.ContainerNames()) != 2 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	c.CreateLinks(context.Background(), 1)
+
+	if d := cmp.Diff([]*EventLink{{A: "n1:eth1", B: "n2:eth1"}}, links); d != "" {
+		t.Errorf("unexpected link created events (-want +got):\n%s", d)
+	}
+}
+
+func TestCreateNodesSharedNetns(t *testing.T) {
+	c, _ := newFakeLab(t, "test_data/topo_fake_netns.yml")
+	// a single worker deadlocks if a sidecar is scheduled before the node owning the netns
+	staticWg, dynWg := c.CreateNodes(context.Background(), 1, c.serialNodes())
+	if staticWg != nil {
+		staticWg.Wait()
+	}
+	dynWg.Wait()
+
+	if d := cmp.Diff("container:clab-fake-a-sidecar", c.Nodes["a-sidecar-of-sidecar"].Config().NetworkMode); d != "" {
+		t.Errorf("unexpected network mode (-want +got):\n%s", d)
+	}
+	want := c.Nodes["app"].Config().NSPath
+	for _, name := range []string{"a-sidecar", "a-sidecar-of-sidecar"} {
+		if got := c.Nodes[name].Config().NSPath; got != want {
+			t.Errorf("node %q: got netns path %q, want %q", name, got, want)
+		}
+	}
+
+	t.Run("failed_netns_owner", func(t *testing.T) {
+		c, r := newFakeLab(t, "test_data/topo_fake_netns.yml")
+		r.FailOn(fake.MethodCreateContainer, "clab-fake-app", errors.New("no space left on device"))
+
+		var m sync.Mutex
+		var failed []string
+		c.Events.Subscribe(func(e Event) {
+			if e.Type == EventNodeFailed {
+				m.Lock()
+				failed = append(failed, e.Node)
+				m.Unlock()
+			}
+		})
+		createNodes(c)
+
+		if len(r.ContainerNames()) != 0 {
+			t.Errorf("unexpected containers: %v", r.ContainerNames())
+		}
+		m.Lock()
+		defer m.Unlock()
+		sort.Strings(failed)
+		if d := cmp.Diff([]string{"a-sidecar", "a-sidecar-of-sidecar", "app"}, failed); d != "" {
+			t.Errorf("unexpected failed nodes (-want +got):\n%s", d)
+		}
+	})
+}
+
+func TestNetworks(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake_networks.yml")
+	if err := c.CreateNetworks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	createNodes(c)
+
+	if d := cmp.Diff([]string{"clab-fake-oob", "shared-ha"}, r.ExtraNets()); d != "" {
+		t.Errorf("unexpected networks (-want +got):\n%s", d)
+	}
+	type attachment struct{ Network, Interface, IPv4 string }
+	want := map[string][]attachment{
+		// attached by the kind
+		"clab-fake-n1": {{"clab-fake-oob", "oob", ""}},
+		"clab-fake-n2": {{"clab-fake-oob", "oob0", "192.168.100.10"}, {"shared-ha", "ha", ""}},
+	}
+	for name, w := range want {
+		nets, ok := r.Networks(name)
+		if !ok {
+			t.Fatalf("container %q is not created", name)
+		}
+		var got []attachment
+		for _, a := range nets {
+			got = append(got, attachment{a.Net.Network, a.Interface, a.IPv4})
+		}
+		if d := cmp.Diff(w, got); d != "" {
+			t.Errorf("container %q: unexpected networks (-want +got):\n%s", name, d)
+		}
+	}
+
+	// the networks in use are kept
+	c.DeleteNetworks(context.Background())
+	if d := cmp.Diff([]string{"clab-fake-oob", "shared-ha"}, r.ExtraNets()); d != "" {
+		t.Errorf("unexpected networks (-want +got):\n%s", d)
+	}
+	c.DeleteNodes(context.Background(), 2, nil)
+	c.DeleteNetworks(context.Background())
+	if len(r.ExtraNets()) != 0 {
+		t.Errorf("unexpected networks left: %v", r.ExtraNets())
+	}
+}
+
+func TestDestroyBridgesWithoutContainers(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating bridges requires root privileges")
+	}
+	c, r := newFakeLab(t, "test_data/topo_fake_bridge.yml")
+	br := c.Nodes["clabtestbr0"]
+	if err := br.Deploy(context.Background()); err != nil {
+		t.Skipf("failed to create a bridge: %v", err)
+	}
+	t.Cleanup(func() { _ = utils.DeleteLinkByName("clabtestbr0") })
+	// the deployment failed to create the node containers
+	if len(r.ContainerNames()) != 0 {
+		t.Fatalf("unexpected containers: %v", r.ContainerNames())
+	}
+
+	if err := c.Destroy(context.Background(), DestroyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := netlink.LinkByName("clabtestbr0"); err == nil {
+		t.Error("bridge clabtestbr0 is not deleted")
+	}
+	// destroying the lab again doesn't fail on the missing bridge
+	if err := c.Destroy(context.Background(), DestroyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -46,6 +48,8 @@ const (
 	NodeGroupLabel    = "clab-node-group"
 	NodeLabDirLabel   = "clab-node-lab-dir"
 	TopoFileLabel     = "clab-topo-file"
+	ImageDigestLabel  = "clab-node-image-digest"
+	BuildHashLabel    = "clab-image-build-hash"
 )
 
 // supported kinds
@@ -75,10 +79,16 @@ var kinds = []string{
 
 // Config defines lab configuration as it is provided in the YAML file
 type Config struct {
-	Name       string          `json:"name,omitempty"`
-	Prefix     *string         `json:"prefix,omitempty"`
-	Mgmt       *types.MgmtNet  `json:"mgmt,omitempty"`
-	Topology   *types.Topology `json:"topology,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Prefix   *string         `json:"prefix,omitempty"`
+	Mgmt     *types.MgmtNet  `json:"mgmt,omitempty"`
+	Topology *types.Topology `json:"topology,omitempty"`
+	// user-defined inventories rendered after the lab is deployed
+	Inventories []*types.InventoryConfig `json:"inventories,omitempty"`
+	// webhooks the lab lifecycle events are posted to
+	Webhooks []*types.WebhookConfig `json:"webhooks,omitempty"`
+	// registries credentials keyed by the registry host
+	Registries map[string]*types.RegistryAuth `json:"registries,omitempty"`
 	ConfigPath string
 }
 
@@ -87,7 +97,10 @@ func (c *CLab) parseTopology() error {
 	log.Infof("Parsing & checking topology file: %s", c.TopoFile.fullName)
 
 	if c.Config.ConfigPath == "" {
-		c.Config.ConfigPath, _ = filepath.Abs(os.Getenv("PWD"))
+		c.Config.ConfigPath = c.baseDir
+		if c.Config.ConfigPath == "" {
+			c.Config.ConfigPath, _ = filepath.Abs(os.Getenv("PWD"))
+		}
 	}
 
 	if c.Config.Prefix == nil {
@@ -104,6 +117,10 @@ func (c *CLab) parseTopology() error {
 	c.Dir.LabCARoot = filepath.Join(c.Dir.LabCA, "root")
 	c.Dir.LabGraph = filepath.Join(c.Dir.Lab, "graph")
 
+	if err := c.initNetworks(); err != nil {
+		return err
+	}
+
 	// initialize Nodes and Links variable
 	c.Nodes = make(map[string]nodes.Node)
 	c.Links = make(map[int]*types.Link)
@@ -166,8 +183,11 @@ func (c *CLab) parseTopology() error {
 	}
 	for i, l := range c.Config.Topology.Links {
 		// i represents the endpoint integer and l provide the link struct
-		c.Links[i] = c.NewLink(l)
+		if c.Links[i], err = c.NewLink(l); err != nil {
+			return err
+		}
 	}
+	c.genLinkMACs()
 
 	// set any containerlab defaults after we've parsed the input
 	c.setDefaults()
@@ -187,6 +207,12 @@ func (c *CLab) NewNode(nodeName, nodeRuntime string, nodeDef *types.NodeDefiniti
 	if !ok {
 		return fmt.Errorf("node %q refers to a kind %q which is not supported. Supported kinds are %q", nodeCfg.ShortName, nodeCfg.Kind, kinds)
 	}
+	if _, ok := c.Runtimes[nodeRuntime].(clabRuntimes.ExtraNetworker); !ok && len(nodeCfg.Networks) > 0 {
+		return fmt.Errorf("node %q attaches to additional networks which are not supported by the %q runtime", nodeCfg.ShortName, nodeRuntime)
+	}
+	if _, ok := c.Runtimes[nodeRuntime].(clabRuntimes.ImageBuilder); !ok && nodeCfg.Build != nil {
+		return fmt.Errorf("node %q builds its image which is not supported by the %q runtime", nodeCfg.ShortName, nodeRuntime)
+	}
 	n := nodeInitializer()
 	// Init
 
@@ -210,16 +236,70 @@ func (c *CLab) NewNode(nodeName, nodeRuntime string, nodeDef *types.NodeDefiniti
 	return nil
 }
 
-func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx int) (*types.NodeConfig, error) {
-	// default longName follows $prefix-$lab-$nodeName pattern
-	longName := fmt.Sprintf("%s-%s-%s", *c.Config.Prefix, c.Config.Name, nodeName)
-
+// containerName returns the name of the node container, which is the node long name
+func (c *CLab) containerName(nodeName string) string {
 	switch {
 	// when prefix is an empty string longName will match shortName/nodeName
 	case *c.Config.Prefix == "":
-		longName = nodeName
+		return nodeName
 	case *c.Config.Prefix == "__lab-name":
-		longName = fmt.Sprintf("%s-%s", c.Config.Name, nodeName)
+		return fmt.Sprintf("%s-%s", c.Config.Name, nodeName)
+	}
+	// default longName follows $prefix-$lab-$nodeName pattern
+	return fmt.Sprintf("%s-%s-%s", *c.Config.Prefix, c.Config.Name, nodeName)
+}
+
+// nodeNetworkMode returns the network mode of the node. The `container:<node>` mode
+// referring to a lab node is resolved to the name of the node container
+func (c *CLab) nodeNetworkMode(nodeName string) (string, error) {
+	mode := c.Config.Topology.GetNodeNetworkMode(nodeName)
+	target := c.netnsNode(nodeName)
+	if target == "" {
+		return strings.ToLower(mode), nil
+	}
+	if _, ok := c.Config.Topology.Nodes[target]; !ok {
+		return "", fmt.Errorf("network mode %q refers to node %q which is not defined in the topology", mode, target)
+	}
+	switch strings.ToLower(c.Config.Topology.GetNodeKind(target)) {
+	case nodes.NodeKindHOST, nodes.NodeKindBridge, nodes.NodeKindOVS:
+		return "", fmt.Errorf("network mode %q refers to node %q which is not a container", mode, target)
+	}
+	// the nodes sharing a netns are deployed after the node they refer to, so the references can't loop
+	seen := map[string]bool{nodeName: true}
+	for next := target; next != ""; next = c.netnsNode(next) {
+		if seen[next] {
+			return "", fmt.Errorf("network mode %q makes a loop of nodes sharing their network namespaces", mode)
+		}
+		seen[next] = true
+	}
+	return types.NetworkModeContainerPrefix + c.containerName(target), nil
+}
+
+// netnsNode returns the name of the node whose network namespace is shared by the given node
+// with the `container:<node>` network mode, and an empty string for the other network modes
+func (c *CLab) netnsNode(nodeName string) string {
+	mode := c.Config.Topology.GetNodeNetworkMode(nodeName)
+	if !strings.HasPrefix(strings.ToLower(mode), types.NetworkModeContainerPrefix) {
+		return ""
+	}
+	return mode[len(types.NetworkModeContainerPrefix):]
+}
+
+// netnsDepth returns the number of nodes the given node goes through to reach
+// the node owning the network namespace it shares, which is zero for the owning node
+func (c *CLab) netnsDepth(nodeName string) int {
+	depth := 0
+	for next := c.netnsNode(nodeName); next != ""; next = c.netnsNode(next) {
+		depth++
+	}
+	return depth
+}
+
+func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx int) (*types.NodeConfig, error) {
+	longName := c.containerName(nodeName)
+	networkMode, err := c.nodeNetworkMode(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("node %q: %v", nodeName, err)
 	}
 
 	nodeCfg := &types.NodeConfig{
@@ -233,12 +313,13 @@ func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx
 		NodeType:        c.Config.Topology.GetNodeType(nodeName),
 		Position:        c.Config.Topology.GetNodePosition(nodeName),
 		Image:           c.Config.Topology.GetNodeImage(nodeName),
+		ImagePullPolicy: c.Config.Topology.GetNodeImagePullPolicy(nodeName),
 		User:            c.Config.Topology.GetNodeUser(nodeName),
 		Entrypoint:      c.Config.Topology.GetNodeEntrypoint(nodeName),
 		Cmd:             c.Config.Topology.GetNodeCmd(nodeName),
 		Exec:            c.Config.Topology.GetNodeExec(nodeName),
 		Env:             c.Config.Topology.GetNodeEnv(nodeName),
-		NetworkMode:     strings.ToLower(c.Config.Topology.GetNodeNetworkMode(nodeName)),
+		NetworkMode:     networkMode,
 		MgmtIPv4Address: nodeDef.GetMgmtIPv4(),
 		MgmtIPv6Address: nodeDef.GetMgmtIPv6(),
 		Publish:         c.Config.Topology.GetNodePublish(nodeName),
@@ -251,15 +332,31 @@ func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx
 		CPUSet:          c.Config.Topology.GetNodeCPUSet(nodeName),
 		Memory:          c.Config.Topology.GetNodeMemory(nodeName),
 		StartupDelay:    c.Config.Topology.GetNodeStartupDelay(nodeName),
+		Security:        c.Config.Topology.GetNodeSecurity(nodeName),
 
 		// Extras
 		Extras: c.Config.Topology.GetNodeExtras(nodeName),
 	}
 
 	log.Debugf("node config: %+v", nodeCfg)
-	var err error
+	if _, err = clabRuntimes.ParsePullPolicy(nodeCfg.ImagePullPolicy); err != nil {
+		return nil, fmt.Errorf("node %q: %v", nodeName, err)
+	}
+	nodeCfg.Security = nodeCfg.Security.WithDefaults(nodes.DefaultSecurity[nodeCfg.Kind])
+	if err = resolveSecurity(nodeCfg.Security, c.baseDir); err != nil {
+		return nil, fmt.Errorf("node %q: %v", nodeName, err)
+	}
+	if nodeCfg.Networks, err = c.nodeNetworks(nodeName); err != nil {
+		return nil, fmt.Errorf("node %q: %v", nodeName, err)
+	}
+	if len(nodeCfg.Networks) > 0 && (nodeCfg.NetworkMode == "host" || nodeCfg.NetworkModeContainer() != "") {
+		return nil, fmt.Errorf("node %q with %q network mode can't attach to additional networks", nodeName, nodeCfg.NetworkMode)
+	}
+	if nodeCfg.Build, err = resolveBuild(c.Config.Topology.GetNodeBuild(nodeName), c.baseDir); err != nil {
+		return nil, fmt.Errorf("node %q: %v", nodeName, err)
+	}
 	// initialize config
-	nodeCfg.StartupConfig, err = c.Config.Topology.GetNodeStartupConfig(nodeCfg.ShortName)
+	nodeCfg.StartupConfig, err = c.Config.Topology.GetNodeStartupConfig(nodeCfg.ShortName, c.baseDir)
 	if err != nil {
 		return nil, err
 	}
@@ -267,13 +364,13 @@ func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx
 	nodeCfg.EnforceStartupConfig = c.Config.Topology.GetNodeEnforceStartupConfig(nodeCfg.ShortName)
 
 	// initialize license field
-	nodeCfg.License, err = c.Config.Topology.GetNodeLicense(nodeCfg.ShortName)
+	nodeCfg.License, err = c.Config.Topology.GetNodeLicense(nodeCfg.ShortName, c.baseDir)
 	if err != nil {
 		return nil, err
 	}
 	// initialize bind mounts
 	binds := c.Config.Topology.GetNodeBinds(nodeName)
-	err = resolveBindPaths(binds, nodeCfg.LabDir)
+	err = resolveBindPaths(binds, nodeCfg.LabDir, c.baseDir)
 	if err != nil {
 		return nil, err
 	}
@@ -282,6 +379,11 @@ func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx
 	if err != nil {
 		return nil, err
 	}
+	// the addresses and ports of a shared netns belong to the node owning it
+	if nodeCfg.NetworkModeContainer() != "" &&
+		(nodeCfg.MgmtIPv4Address != "" || nodeCfg.MgmtIPv6Address != "" || len(nodeCfg.PortBindings) > 0) {
+		return nil, fmt.Errorf("node %q shares the network namespace of another node, it can't have management addresses or ports", nodeName)
+	}
 	nodeCfg.Labels = c.Config.Topology.GetNodeLabels(nodeCfg.ShortName)
 
 	nodeCfg.Config = c.Config.Topology.GetNodeConfigDispatcher(nodeCfg.ShortName)
@@ -290,39 +392,62 @@ func (c *CLab) createNodeCfg(nodeName string, nodeDef *types.NodeDefinition, idx
 }
 
 // NewLink initializes a new link object
-func (c *CLab) NewLink(l *types.LinkConfig) *types.Link {
+func (c *CLab) NewLink(l *types.LinkConfig) (*types.Link, error) {
 	if len(l.Endpoints) != 2 {
-		log.Fatalf("endpoint %q has wrong syntax, unexpected number of items", l.Endpoints) // skipcq: RVV-A0003
+		return nil, fmt.Errorf("endpoint %q has wrong syntax, unexpected number of items", l.Endpoints)
+	}
+
+	macs := make([]string, 2)
+	copy(macs, l.MACs)
+
+	mtu := DefaultVethLinkMTU
+	if l.MTU != 0 {
+		mtu = l.MTU
+	}
+
+	a, err := c.NewEndpoint(l.Endpoints[0], macs[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.NewEndpoint(l.Endpoints[1], macs[1])
+	if err != nil {
+		return nil, err
 	}
 
 	return &types.Link{
-		A:      c.NewEndpoint(l.Endpoints[0]),
-		B:      c.NewEndpoint(l.Endpoints[1]),
-		MTU:    DefaultVethLinkMTU,
+		A:      a,
+		B:      b,
+		MTU:    mtu,
 		Labels: l.Labels,
 		Vars:   l.Vars,
-	}
+		Vlan:   l.Vlan,
+		Vlans:  l.Vlans,
+		PVID:   l.PVID,
+	}, nil
 }
 
 // NewEndpoint initializes a new endpoint object
-func (c *CLab) NewEndpoint(e string) *types.Endpoint {
+// mac is a user-defined MAC address of the endpoint, when empty the MAC address
+// is generated once all links are created, see genLinkMACs
+func (c *CLab) NewEndpoint(e, mac string) (*types.Endpoint, error) {
 	// initialize a new endpoint
 	endpoint := new(types.Endpoint)
 
 	// split the string to get node name and endpoint name
 	split := strings.Split(e, ":")
 	if len(split) != 2 {
-		log.Fatalf("endpoint %s has wrong syntax", e) // skipcq: GO-S0904, RVV-A0003
+		return nil, fmt.Errorf("endpoint %s has wrong syntax", e)
 	}
 	nName := split[0] // node name
 
 	// initialize the endpoint name based on the split function
 	endpoint.EndpointName = split[1] // endpoint name
 	if len(endpoint.EndpointName) > 15 {
-		log.Fatalf("interface '%s' name exceeds maximum length of 15 characters", endpoint.EndpointName) //skipcq: RVV-A0003
+		return nil, fmt.Errorf("interface '%s' name exceeds maximum length of 15 characters", endpoint.EndpointName)
 	}
-	// generate unique MAC
-	endpoint.MAC = utils.GenMac(ClabOUI)
+	// endpoints named <iface>.<vlan-id> denote vlan sub-interfaces
+	endpoint.VlanID = endpointVlanID(endpoint.EndpointName)
+	endpoint.MAC = mac
 
 	// search the node pointer for a node name referenced in endpoint section
 	switch nName {
@@ -355,10 +480,10 @@ func (c *CLab) NewEndpoint(e string) *types.Endpoint {
 	// stop the deployment if the matching node element was not found
 	// "host" node name is an exception, it may exist without a matching node
 	if endpoint.Node == nil {
-		log.Fatalf("not all nodes are specified in the 'topology.nodes' section or the names don't match in the 'links.endpoints' section: %s", nName) // skipcq: GO-S0904, RVV-A0003
+		return nil, fmt.Errorf("not all nodes are specified in the 'topology.nodes' section or the names don't match in the 'links.endpoints' section: %s", nName)
 	}
 
-	return endpoint
+	return endpoint, nil
 }
 
 // CheckTopologyDefinition runs topology checks and returns any errors found
@@ -370,6 +495,15 @@ func (c *CLab) CheckTopologyDefinition(ctx context.Context) error {
 	if err = c.verifyLinks(); err != nil {
 		return err
 	}
+	if err = c.verifyNetworkInterfaces(); err != nil {
+		return err
+	}
+	if err = c.verifyLinkVlans(); err != nil {
+		return err
+	}
+	if err = c.verifyLinkMACs(); err != nil {
+		return err
+	}
 	if err = c.verifyRootNetnsInterfaceUniqueness(); err != nil {
 		return err
 	}
@@ -382,13 +516,23 @@ func (c *CLab) CheckTopologyDefinition(ctx context.Context) error {
 	if err = c.verifyHostIfaces(); err != nil {
 		return err
 	}
+	if err = c.verifyInventories(); err != nil {
+		return err
+	}
 	return c.VerifyImages(ctx)
 }
 
 // VerifyBridgeExists verifies if every node of kind=bridge/ovs-bridge exists on the lab host
+// bridges that are created by containerlab (extras.create) are skipped
 func (c *CLab) verifyBridgesExist() error {
 	for name, node := range c.Nodes {
 		if node.Config().Kind == nodes.NodeKindBridge || node.Config().Kind == nodes.NodeKindOVS {
+			if node.Config().Extras != nil && node.Config().Extras.CreateBridge {
+				if _, err := netlink.LinkByName(name); err == nil {
+					return fmt.Errorf("bridge %s is set to be created by containerlab but it already exists in the default network namespace", name)
+				}
+				continue
+			}
 			if _, err := netlink.LinkByName(name); err != nil {
 				return fmt.Errorf("bridge %s is referenced in the endpoints section but was not found in the default network namespace", name)
 			}
@@ -402,6 +546,12 @@ func (c *CLab) verifyLinks() error {
 	// dups accumulates duplicate links
 	dups := []string{}
 	for _, lc := range c.Config.Topology.Links {
+		if lc.MTU != 0 && (lc.MTU < 68 || lc.MTU > 65535) {
+			return fmt.Errorf("link %q has mtu %d which is out of range 68-65535", lc.Endpoints, lc.MTU)
+		}
+		if len(lc.MACs) > len(lc.Endpoints) {
+			return fmt.Errorf("link %q has more MAC addresses than endpoints", lc.Endpoints)
+		}
 		for _, e := range lc.Endpoints {
 			if err := checkEndpoint(e); err != nil {
 				return err
@@ -418,27 +568,125 @@ func (c *CLab) verifyLinks() error {
 	return nil
 }
 
-// VerifyImages will check if image referred in the node config
-// either pullable or is available in the local image store
-func (c *CLab) VerifyImages(ctx context.Context) error {
-
-	images := make(map[string]string)
-
-	for _, node := range c.Nodes {
-
-		for _, imageName := range node.GetImages() {
-			if imageName == "" {
-				return fmt.Errorf("missing required image for node %q", node.Config().ShortName)
+// genLinkMACs generates the MAC addresses of the endpoints without a user-defined one.
+// A MAC address is derived from the lab, node and interface names and thus is stable across deployments,
+// the names are rehashed with a counter until the MAC address is not used by another endpoint
+func (c *CLab) genLinkMACs() {
+	links := c.sortedLinks()
+	used := map[string]struct{}{}
+	for _, l := range links {
+		for _, e := range []*types.Endpoint{l.A, l.B} {
+			if hw, err := net.ParseMAC(e.MAC); err == nil {
+				used[hw.String()] = struct{}{}
 			}
-			images[imageName] = node.GetRuntime().GetName()
 		}
+	}
+	for _, l := range links {
+		for _, e := range []*types.Endpoint{l.A, l.B} {
+			if e.MAC != "" {
+				continue
+			}
+			name := strings.Join([]string{c.Config.Name, e.Node.ShortName, e.EndpointName}, "/")
+			mac := utils.GenMacFromString(ClabOUI, name)
+			for i := 1; ; i++ {
+				if _, ok := used[mac]; !ok {
+					break
+				}
+				mac = utils.GenMacFromString(ClabOUI, fmt.Sprintf("%s/%d", name, i))
+			}
+			used[mac] = struct{}{}
+			e.MAC = mac
+			// the nodes hold copies of their endpoints
+			for i := range e.Node.Endpoints {
+				if e.Node.Endpoints[i].EndpointName == e.EndpointName {
+					e.Node.Endpoints[i].MAC = mac
+				}
+			}
+		}
+	}
+}
 
+// verifyLinkMACs ensures that MAC addresses of the link endpoints are valid unicast addresses
+// and are unique across the topology
+func (c *CLab) verifyLinkMACs() error {
+	macs := map[string]string{}
+	for _, l := range c.Links {
+		for _, e := range []*types.Endpoint{l.A, l.B} {
+			ep := e.Node.ShortName + ":" + e.EndpointName
+			hw, err := net.ParseMAC(e.MAC)
+			if err != nil {
+				return fmt.Errorf("endpoint %s has invalid MAC address %q: %v", ep, e.MAC, err)
+			}
+			if hw[0]&1 == 1 {
+				return fmt.Errorf("endpoint %s has multicast MAC address %q", ep, e.MAC)
+			}
+			if other, ok := macs[hw.String()]; ok {
+				return fmt.Errorf("endpoints %s and %s have the same MAC address %s. Set a unique MAC address with the link 'macs' attribute",
+					other, ep, hw)
+			}
+			macs[hw.String()] = ep
+		}
 	}
+	return nil
+}
 
-	for image, runtimeName := range images {
-		err := c.Runtimes[runtimeName].PullImageIfRequired(ctx, image)
-		if err != nil {
-			return err
+// verifyLinkVlans validates vlan sub-interface endpoints and bridge port vlan settings
+func (c *CLab) verifyLinkVlans() error {
+	// ifaces holds parent interface names per node to detect
+	// endpoints which share the same parent interface
+	ifaces := map[string]string{}
+	// plain holds the endpoints without a vlan to detect
+	// the interfaces used both as an endpoint and as a parent interface
+	plain := map[string]struct{}{}
+	for _, l := range c.sortedLinks() {
+		bridged := false
+		for _, e := range []*types.Endpoint{l.A, l.B} {
+			isBridge := e.Node.Kind == nodes.NodeKindBridge || e.Node.Kind == nodes.NodeKindOVS
+			if e.Node.Kind == nodes.NodeKindBridge {
+				bridged = true
+			}
+			key := e.Node.ShortName + ":" + e.IfaceName()
+			if e.VlanID == 0 {
+				if other, ok := ifaces[key]; ok {
+					return fmt.Errorf("endpoint %s:%s is also the parent interface of the endpoint %s",
+						e.Node.ShortName, e.EndpointName, other)
+				}
+				plain[key] = struct{}{}
+				continue
+			}
+			if isBridge {
+				return fmt.Errorf("endpoint %s:%s: vlan sub-interfaces are not supported for %s nodes, use link vlan settings instead",
+					e.Node.ShortName, e.EndpointName, e.Node.Kind)
+			}
+			if _, ok := plain[key]; ok {
+				return fmt.Errorf("endpoint %s:%s uses the parent interface %s which is also a link endpoint",
+					e.Node.ShortName, e.EndpointName, e.IfaceName())
+			}
+			if other, ok := ifaces[key]; ok {
+				return fmt.Errorf("endpoints %s:%s and %s share the same parent interface %s, only one sub-interface per interface is supported",
+					e.Node.ShortName, e.EndpointName, other, e.IfaceName())
+			}
+			ifaces[key] = e.Node.ShortName + ":" + e.EndpointName
+		}
+
+		if l.Vlan == 0 && len(l.Vlans) == 0 && l.PVID == 0 {
+			continue
+		}
+		if !bridged {
+			return fmt.Errorf("%s: vlan settings can only be used on links connected to a linux bridge", l)
+		}
+		if l.Vlan != 0 && (len(l.Vlans) != 0 || l.PVID != 0) {
+			return fmt.Errorf("%s: access vlan can't be combined with vlans and pvid settings", l)
+		}
+		for _, vid := range append([]int{l.Vlan, l.PVID}, l.Vlans...) {
+			if vid < 0 || vid > 4094 {
+				return fmt.Errorf("%s: vlan id %d is out of range 1-4094", l, vid)
+			}
+		}
+		for _, vid := range l.Vlans {
+			if vid == 0 {
+				return fmt.Errorf("%s: vlan id 0 is not allowed in the vlans list", l)
+			}
 		}
 	}
 	return nil
@@ -497,6 +745,10 @@ func (c *CLab) verifyHostIfaces() error {
 			return fmt.Errorf("node '%s' is defined with host network mode, it can't have any links. Remove '%s' node links from the topology definition",
 				l.A.Node.ShortName, l.A.Node.ShortName)
 		}
+		if l.A.Node.NetworkModeContainer() != "" {
+			return fmt.Errorf("node '%s' shares the network namespace of another node, it can't have any links. Remove '%s' node links from the topology definition",
+				l.A.Node.ShortName, l.A.Node.ShortName)
+		}
 		if l.B.Node.ShortName == "host" {
 			if nl, _ := netlink.LinkByName(l.B.EndpointName); nl != nil {
 				return fmt.Errorf("host interface %s referenced in topology already exists", l.B.EndpointName)
@@ -506,6 +758,10 @@ func (c *CLab) verifyHostIfaces() error {
 			return fmt.Errorf("node '%s' is defined with host network mode, it can't have any links. Remove '%s' node links from the topology definition",
 				l.B.Node.ShortName, l.B.Node.ShortName)
 		}
+		if l.B.Node.NetworkModeContainer() != "" {
+			return fmt.Errorf("node '%s' shares the network namespace of another node, it can't have any links. Remove '%s' node links from the topology definition",
+				l.B.Node.ShortName, l.B.Node.ShortName)
+		}
 	}
 	return nil
 }
@@ -574,8 +830,24 @@ func checkEndpoint(e string) error {
 	return nil
 }
 
-//resolvePath resolves a string path by expanding `~` to home dir or getting Abs path for the given path
-func resolvePath(p string) (string, error) {
+// endpointVlanID returns the vlan id for endpoint names following the <iface>.<vlan-id> pattern
+// and 0 for all other endpoint names
+func endpointVlanID(e string) int {
+	i := strings.LastIndex(e, ".")
+	if i <= 0 {
+		return 0
+	}
+	vid, err := strconv.Atoi(e[i+1:])
+	// only canonical vlan ids are considered, so that the sub-interface name matches the endpoint name
+	if err != nil || vid < 1 || vid > 4094 || strconv.Itoa(vid) != e[i+1:] {
+		return 0
+	}
+	return vid
+}
+
+//resolvePath resolves a string path by expanding `~` to home dir or getting Abs path for the given path,
+// relative paths are resolved against base directory, if it is set
+func resolvePath(p, base string) (string, error) {
 	if p == "" {
 		return "", nil
 	}
@@ -588,6 +860,9 @@ func resolvePath(p string) (string, error) {
 			return "", err
 		}
 	default:
+		if base != "" && !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
 		p, err = filepath.Abs(p)
 		if err != nil {
 			return "", err
@@ -596,10 +871,36 @@ func resolvePath(p string) (string, error) {
 	return p, nil
 }
 
+// resolveSecurity validates the device mappings of a node security config
+// and resolves the path to its seccomp profile
+func resolveSecurity(s *types.SecurityConfig, base string) error {
+	if s == nil {
+		return nil
+	}
+	for _, d := range s.Devices {
+		if _, err := types.ParseDevice(d); err != nil {
+			return err
+		}
+	}
+	if s.SeccompProfile == "" || s.SeccompProfile == "unconfined" {
+		return nil
+	}
+	p, err := resolvePath(s.SeccompProfile, base)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(p); err != nil {
+		return fmt.Errorf("failed to verify seccomp profile: %v", err)
+	}
+	s.SeccompProfile = p
+	return nil
+}
+
 // resolveBindPaths resolves the host paths in a bind string, such as /hostpath:/remotepath(:options) string
 // it allows host path to have `~` and returns absolute path for a relative path
-// if the host path doesn't exist, the error will be returned
-func resolveBindPaths(binds []string, nodedir string) error {
+// if the host path doesn't exist, the error will be returned.
+// Relative host paths are resolved against the base directory, if it is set
+func resolveBindPaths(binds []string, nodedir, base string) error {
 	for i := range binds {
 		// host path is a first element in a /hostpath:/remotepath(:options) string
 		elems := strings.Split(binds[i], ":")
@@ -607,7 +908,7 @@ func resolveBindPaths(binds []string, nodedir string) error {
 		r := strings.NewReplacer("$nodeDir", nodedir)
 		hp := r.Replace(elems[0])
 
-		hp, err := resolvePath(hp)
+		hp, err := resolvePath(hp, base)
 		if err != nil {
 			return err
 		}
@@ -633,18 +934,23 @@ func resolveBindPaths(binds []string, nodedir string) error {
 
 // CheckResources runs container host resources check
 func (*CLab) CheckResources() error {
-	vcpu := runtime.NumCPU()
+	vcpu, _, freeMem := hostResources()
 	log.Debugf("Number of vcpu: %d", vcpu)
 	if vcpu < 2 {
 		log.Warn("Only 1 vcpu detected on this container host. Most containerlab nodes require at least 2 vcpu")
 	}
-	freeMemG := sysMemory("free") / 1024 / 1024 / 1024
+	freeMemG := freeMem / 1024 / 1024 / 1024
 	if freeMemG < 1 {
 		log.Warnf("it appears that container host has low memory available: ~%dGi. This might lead to runtime errors. Consider freeing up more memory.", freeMemG)
 	}
 	return nil
 }
 
+// hostResources returns the number of vcpu and the total and free memory (in bytes) of the container host
+func hostResources() (vcpu int, totalMem, freeMem uint64) {
+	return runtime.NumCPU(), sysMemory("total"), sysMemory("free")
+}
+
 // sets defaults after the topology has been parsed
 func (c *CLab) setDefaults() {
 	for _, n := range c.Nodes {
@@ -13,7 +13,10 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
 	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
 )
 
 func TestLicenseInit(t *testing.T) {
@@ -103,7 +106,7 @@ func TestBindsInit(t *testing.T) {
 			// binds := c.bindsInit(nodeCfg)
 			binds := c.Config.Topology.GetNodeBinds("node1")
 			// resolve wanted paths as the binds paths are resolved as part of the c.ParseTopology
-			err = resolveBindPaths(tc.want, node.LabDir)
+			err = resolveBindPaths(tc.want, node.LabDir, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -139,7 +142,7 @@ func TestBindsInitNodeDir(t *testing.T) {
 			_ = os.MkdirAll(bind_part[0], os.ModePerm)
 
 			binds := []string{tc.bind}
-			err := resolveBindPaths(binds, tc.nodeDir)
+			err := resolveBindPaths(binds, tc.nodeDir, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -436,8 +439,8 @@ func TestLabelsInit(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			tc.want[NodeLabDirLabel], _ = resolvePath(tc.want[NodeLabDirLabel])
-			tc.want[TopoFileLabel], _ = resolvePath(tc.want[TopoFileLabel])
+			tc.want[NodeLabDirLabel], _ = resolvePath(tc.want[NodeLabDirLabel], "")
+			tc.want[TopoFileLabel], _ = resolvePath(tc.want[TopoFileLabel], "")
 
 			labels := c.Nodes[tc.node].Config().Labels
 
@@ -465,3 +468,428 @@ func TestVerifyRootNetnsInterfaceUniqueness(t *testing.T) {
 	t.Logf("error: %v", err)
 
 }
+
+func TestEndpointVlanID(t *testing.T) {
+	tests := map[string]int{
+		"e1-1":      0,
+		"e1-1.100":  100,
+		"eth1.4094": 4094,
+		"eth1.4095": 0,
+		"eth1.0100": 0,
+		"eth1.abc":  0,
+		".100":      0,
+	}
+
+	for e, want := range tests {
+		t.Run(e, func(t *testing.T) {
+			if got := endpointVlanID(e); got != want {
+				t.Fatalf("wanted %d got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyLinkVlans(t *testing.T) {
+	opts := []ClabOption{
+		WithTopoFile("test_data/topo10-vlans.yml", ""),
+	}
+	c, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.verifyLinkVlans(); err != nil {
+		t.Fatal(err)
+	}
+
+	l := c.Links[0]
+	if l.A.VlanID != 100 || l.A.IfaceName() != "eth1" {
+		t.Fatalf("unexpected vlan endpoint %+v", l.A)
+	}
+
+	// access vlan can't be combined with tagged vlans
+	c.Links[1].Vlans = []int{300}
+	if err := c.verifyLinkVlans(); err == nil {
+		t.Fatal("expected access and trunk vlan settings error")
+	}
+	c.Links[1].Vlans = nil
+
+	// vlan settings on a link without a bridge
+	c.Links[0].Vlan = 100
+	if err := c.verifyLinkVlans(); err == nil {
+		t.Fatal("expected non-bridged link vlan error")
+	}
+	c.Links[0].Vlan = 0
+
+	// the parent interface of a sub-interface used as a plain endpoint, in both links orders
+	plain, err := c.NewLink(&types.LinkConfig{Endpoints: []string{"lin1:eth1", "lin2:eth3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"endpoint lin1:eth1.100 uses the parent interface eth1 which is also a link endpoint",
+		"endpoint lin1:eth1 is also the parent interface of the endpoint lin1:eth1.100",
+	}
+	for i, links := range [][]*types.Link{{plain, c.Links[0]}, {c.Links[0], plain}} {
+		c.Links = map[int]*types.Link{0: links[0], 1: links[1]}
+		if err := c.verifyLinkVlans(); err == nil || err.Error() != want[i] {
+			t.Errorf("wanted %q got %v", want[i], err)
+		}
+	}
+}
+
+func TestLinkMTUAndMACs(t *testing.T) {
+	opts := []ClabOption{
+		WithTopoFile("test_data/topo11-link-attrs.yml", ""),
+	}
+	c, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Links[0].MTU != 1500 {
+		t.Fatalf("wanted mtu 1500 got %d", c.Links[0].MTU)
+	}
+	if c.Links[1].MTU != DefaultVethLinkMTU {
+		t.Fatalf("wanted mtu %d got %d", DefaultVethLinkMTU, c.Links[1].MTU)
+	}
+	if c.Links[0].A.MAC != "02:00:00:00:01:01" {
+		t.Fatalf("wanted user-defined MAC got %s", c.Links[0].A.MAC)
+	}
+	if err := c.verifyLinkMACs(); err != nil {
+		t.Fatal(err)
+	}
+
+	// generated MACs must be stable across topology parsing
+	c2, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Links[0].B.MAC != c2.Links[0].B.MAC || c.Links[1].A.MAC != c2.Links[1].A.MAC {
+		t.Fatal("generated MAC addresses differ between topology parsing runs")
+	}
+
+	if got := c.Nodes["lin2"].Config().Endpoints[1].MAC; got != c.Links[1].B.MAC {
+		t.Fatalf("got node endpoint MAC %s, want the link endpoint MAC %s", got, c.Links[1].B.MAC)
+	}
+
+	// a generated MAC colliding with another endpoint MAC is rehashed
+	taken := c.Links[1].A.MAC
+	c.Links[0].A.MAC = taken
+	c.Links[1].A.MAC = ""
+	c.genLinkMACs()
+	if c.Links[1].A.MAC == taken || !strings.HasPrefix(c.Links[1].A.MAC, ClabOUI) {
+		t.Fatalf("got MAC %s colliding with the user-defined MAC %s", c.Links[1].A.MAC, taken)
+	}
+	if err := c.verifyLinkMACs(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Links[1].A.MAC = c.Links[0].A.MAC
+	if err := c.verifyLinkMACs(); err == nil {
+		t.Fatal("expected duplicate MAC error")
+	}
+}
+
+func TestSharedNetnsErrors(t *testing.T) {
+	tests := map[string]struct {
+		nodes string
+		links string
+		want  string
+	}{
+		"unknown_node": {
+			nodes: `
+    n1:
+      network-mode: container:n2`,
+			want: `node "n1": network mode "container:n2" refers to node "n2" which is not defined in the topology`,
+		},
+		"not_a_container": {
+			nodes: `
+    br:
+      kind: bridge
+    n1:
+      network-mode: container:br`,
+			want: `node "n1": network mode "container:br" refers to node "br" which is not a container`,
+		},
+		"loop": {
+			nodes: `
+    n1:
+      network-mode: container:n2
+    n2:
+      network-mode: container:n1`,
+			want: `node "n1": network mode "container:n2" makes a loop of nodes sharing their network namespaces`,
+		},
+		"mgmt_address": {
+			nodes: `
+    n1:
+    n2:
+      network-mode: container:n1
+      mgmt_ipv4: 172.20.20.100`,
+			want: `node "n2" shares the network namespace of another node, it can't have management addresses or ports`,
+		},
+		"links": {
+			nodes: `
+    n1:
+    n2:
+      network-mode: container:n1`,
+			links: `
+  links:
+    - endpoints: ["n1:eth1", "n2:eth1"]`,
+			want: `node 'n2' shares the network namespace of another node, it can't have any links. Remove 'n2' node links from the topology definition`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			topo := filepath.Join(t.TempDir(), "topo.clab.yml")
+			data := "name: netns\ntopology:\n  kinds:\n    linux:\n      image: alpine:3\n  defaults:\n    kind: linux\n  nodes:" +
+				tc.nodes + tc.links + "\n"
+			if err := os.WriteFile(topo, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := NewContainerLab(
+				WithTopoFile(topo, ""),
+				WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+			)
+			if err == nil {
+				err = c.verifyHostIfaces()
+			}
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("wanted %q got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestNetworksErrors(t *testing.T) {
+	tests := map[string]struct {
+		topo string
+		want string
+	}{
+		"undefined_network": {
+			topo: `
+  nodes:
+    n1:
+      networks: [{name: oob}]`,
+			want: `node "n1": network "oob" is not defined in the topology networks`,
+		},
+		"dns": {
+			topo: `
+  networks:
+    oob: {dns: true}`,
+			want: `network "oob": dns is only served on the management network`,
+		},
+		"same_runtime_network": {
+			topo: `
+  networks:
+    a: {network: oob}
+    b: {network: oob}`,
+			want: `networks "a" and "b" use the same runtime network "oob"`,
+		},
+		"attached_twice": {
+			topo: `
+  networks:
+    oob:
+  nodes:
+    n1:
+      networks: [{name: oob}, {name: oob, interface: oob1}]`,
+			want: `node "n1": network "oob" is attached more than once`,
+		},
+		"address_out_of_subnet": {
+			topo: `
+  networks:
+    oob: {ipv4_subnet: 192.168.100.0/24}
+  nodes:
+    n1:
+      networks: [{name: oob, ipv4: 192.168.101.10}]`,
+			want: `node "n1": network "oob": ipv4 address 192.168.101.10 is not in the network subnet 192.168.100.0/24`,
+		},
+		"long_interface_name": {
+			topo: `
+  networks:
+    out-of-band-network:
+  nodes:
+    n1:
+      networks: [{name: out-of-band-network}]`,
+			want: `node "n1": network "out-of-band-network": interface name "out-of-band-network" must be 1 to 15 characters long`,
+		},
+		"host_network_mode": {
+			topo: `
+  networks:
+    oob:
+  nodes:
+    n1:
+      network-mode: host
+      networks: [{name: oob}]`,
+			want: `node "n1" with "host" network mode can't attach to additional networks`,
+		},
+		"link_interface": {
+			topo: `
+  networks:
+    oob:
+  nodes:
+    n1:
+      networks: [{name: oob, interface: eth1}]
+    n2:
+  links:
+    - endpoints: ["n1:eth1", "n2:eth1"]`,
+			want: `endpoint n1:eth1 uses the interface attached to network "oob"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			topo := filepath.Join(t.TempDir(), "topo.clab.yml")
+			data := "name: nets\ntopology:\n  defaults:\n    kind: linux\n    image: alpine:3" + tc.topo + "\n"
+			if err := os.WriteFile(topo, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := NewContainerLab(
+				WithTopoFile(topo, ""),
+				WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+			)
+			if err == nil {
+				err = c.verifyNetworkInterfaces()
+			}
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("wanted %q got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestLinkEndpointErrors(t *testing.T) {
+	tests := map[string]struct {
+		endpoints string
+		want      string
+	}{
+		"single_endpoint": {
+			endpoints: `["n1:eth1"]`,
+			want:      `endpoint ["n1:eth1"] has wrong syntax, unexpected number of items`,
+		},
+		"no_interface": {
+			endpoints: `["n1", "n2:eth1"]`,
+			want:      `endpoint n1 has wrong syntax`,
+		},
+		"long_interface_name": {
+			endpoints: `["n1:ethernet-1-1-1-1", "n2:eth1"]`,
+			want:      `interface 'ethernet-1-1-1-1' name exceeds maximum length of 15 characters`,
+		},
+		"unknown_node": {
+			endpoints: `["n1:eth1", "n3:eth1"]`,
+			want:      `not all nodes are specified in the 'topology.nodes' section or the names don't match in the 'links.endpoints' section: n3`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			topo := filepath.Join(dir, "topo.clab.yml")
+			data := "name: links\ntopology:\n  defaults:\n    kind: linux\n    image: alpine:3\n" +
+				"  nodes:\n    n1:\n    n2:\n  links:\n    - endpoints: " + tc.endpoints + "\n"
+			if err := os.WriteFile(topo, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewContainerLab(
+				WithBaseDir(dir),
+				WithTopoFile(topo, ""),
+				WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+			)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("wanted %q got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestBaseDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "configs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "configs", "n1.cfg"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	topo := filepath.Join(dir, "topo.clab.yml")
+	data := `name: base
+topology:
+  nodes:
+    n1:
+      kind: linux
+      image: alpine:3
+      startup-config: configs/n1.cfg
+      binds:
+        - configs:/configs
+`
+	if err := os.WriteFile(topo, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewContainerLab(
+		WithBaseDir(dir),
+		WithTopoFile(topo, ""),
+		WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// relative paths are resolved against the base directory rather than the working directory
+	cfg := c.Nodes["n1"].Config()
+	if want := filepath.Join(dir, "configs", "n1.cfg"); cfg.StartupConfig != want {
+		t.Errorf("wanted startup config %q got %q", want, cfg.StartupConfig)
+	}
+	if want := []string{filepath.Join(dir, "configs") + ":/configs"}; !cmp.Equal(cfg.Binds, want) {
+		t.Errorf("wanted binds %q got %q", want, cfg.Binds)
+	}
+	if want := filepath.Join(dir, "clab-base"); c.Dir.Lab != want {
+		t.Errorf("wanted lab directory %q got %q", want, c.Dir.Lab)
+	}
+	if !utils.FileExists(filepath.Join(dir, ".topo.clab.yaml")) {
+		t.Error("rendered topology file is not created in the base directory")
+	}
+
+	if _, err := NewContainerLab(WithTopoFile(topo, ""), WithBaseDir(dir)); err == nil {
+		t.Error("expected an error setting the base directory after the topology file")
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := map[string]struct {
+		build string
+		want  string
+	}{
+		"no_context": {
+			build: `{dockerfile: Dockerfile}`,
+			want:  `node "n1": build context is not set`,
+		},
+		"missing_context": {
+			build: `{context: test_data/build/missing}`,
+			want:  `node "n1": build context "test_data/build/missing" is not a directory`,
+		},
+		"dockerfile_outside_context": {
+			build: `{context: test_data/build/client, dockerfile: ../Dockerfile}`,
+			want:  `node "n1": dockerfile "../Dockerfile" must be a path within the build context`,
+		},
+		"missing_dockerfile": {
+			build: `{context: test_data/build/client, dockerfile: Containerfile}`,
+			want:  `node "n1": dockerfile "Containerfile" is not found in the build context "test_data/build/client"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			topo := filepath.Join(t.TempDir(), "topo.clab.yml")
+			data := "name: build\ntopology:\n  nodes:\n    n1:\n      kind: linux\n      build: " + tc.build + "\n"
+			if err := os.WriteFile(topo, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewContainerLab(
+				WithTopoFile(topo, ""),
+				WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+			)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("wanted %q got %v", tc.want, err)
+			}
+		})
+	}
+}
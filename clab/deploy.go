@@ -0,0 +1,272 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/cert"
+	"github.com/srl-labs/containerlab/nodes"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+)
+
+// DeployOptions are the options of a lab deployment
+type DeployOptions struct {
+	// Reconfigure removes the lab and its directory before deploying it
+	Reconfigure bool
+	// MaxWorkers limits the number of workers creating nodes and links, 0 means no limit
+	MaxWorkers uint
+	// Graph generates the topology graph of the lab
+	Graph bool
+	// Executable is the path to the containerlab binary running the lab DNS server,
+	// defaults to the current executable
+	Executable string
+}
+
+// DeployResult is the result of a lab deployment
+type DeployResult struct {
+	// Containers are the lab containers
+	Containers []types.GenericContainer
+	// Exec holds the results of the nodes exec commands keyed by the container name and the command
+	Exec map[string]map[string]*ExecResult
+	// Errors are the errors which didn't fail the deployment,
+	// such as failed post-deploy tasks and exec commands of the nodes
+	Errors []error
+}
+
+// NodeError is an error of a lab node operation
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %s: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Deploy deploys the lab.
+// The returned error fails the deployment, while the errors of the individual nodes tasks
+// are collected in the result
+func (c *CLab) Deploy(ctx context.Context, o DeployOptions) (*DeployResult, error) {
+	var err error
+
+	if err = c.subscribeWebhooks(); err != nil {
+		return nil, err
+	}
+
+	if o.Reconfigure {
+		_ = c.Destroy(ctx, DestroyOptions{MaxWorkers: o.MaxWorkers})
+		log.Infof("Removing %s directory...", c.Dir.Lab)
+		if err := os.RemoveAll(c.Dir.Lab); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = c.CheckTopologyDefinition(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = c.CheckResources(); err != nil {
+		return nil, err
+	}
+
+	log.Info("Creating lab directory: ", c.Dir.Lab)
+	utils.CreateDirectory(c.Dir.Lab, 0755)
+
+	// create an empty ansible inventory file that will get populated later
+	// we create it here first, so that bind mounts of ansible-inventory.yml file could work
+	ansibleInvFPath := filepath.Join(c.Dir.Lab, "ansible-inventory.yml")
+	_, err = os.Create(ansibleInvFPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cert.CreateRootCA(c.Config.Name, c.Dir.LabCARoot, c.Nodes); err != nil {
+		return nil, err
+	}
+
+	c.CreateAuthzKeysFile()
+
+	// create docker network or use existing one
+	if err = c.GlobalRuntime().CreateNet(ctx); err != nil {
+		return nil, err
+	}
+	if err = c.CreateNetworks(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.Config.Mgmt.DNS {
+		if _, err = c.StartDNS(o.Executable); err != nil {
+			return nil, err
+		}
+	}
+
+	nodeWorkers := uint(len(c.Nodes))
+	linkWorkers := uint(len(c.Links))
+
+	if o.MaxWorkers > 0 && o.MaxWorkers < nodeWorkers {
+		nodeWorkers = o.MaxWorkers
+	}
+
+	if o.MaxWorkers > 0 && o.MaxWorkers < linkWorkers {
+		linkWorkers = o.MaxWorkers
+	}
+
+	// extraHosts holds host entries for nodes with static IPv4/6 addresses
+	// these entries will be used by container runtime to populate /etc/hosts file
+	extraHosts := make([]string, 0, len(c.Nodes))
+
+	for _, n := range c.Nodes {
+		if n.Config().MgmtIPv4Address != "" {
+			log.Debugf("Adding static ipv4 /etc/hosts entry for %s:%s", n.Config().ShortName, n.Config().MgmtIPv4Address)
+			extraHosts = append(extraHosts, n.Config().ShortName+":"+n.Config().MgmtIPv4Address)
+		}
+
+		if n.Config().MgmtIPv6Address != "" {
+			log.Debugf("Adding static ipv6 /etc/hosts entry for %s:%s", n.Config().ShortName, n.Config().MgmtIPv6Address)
+			extraHosts = append(extraHosts, n.Config().ShortName+":"+n.Config().MgmtIPv6Address)
+		}
+	}
+
+	for _, n := range c.Nodes {
+		n.Config().ExtraHosts = extraHosts
+	}
+
+	nodesStaticWg, nodesDynWg := c.CreateNodes(ctx, nodeWorkers, c.serialNodes())
+	c.CreateLinks(ctx, linkWorkers)
+	if nodesStaticWg != nil {
+		nodesStaticWg.Wait()
+	}
+	if nodesDynWg != nil {
+		nodesDynWg.Wait()
+	}
+
+	log.Debug("containers created, retrieving state and IP addresses...")
+
+	// Building list of generic containers
+	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
+	containers, err := c.ListContainers(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("enriching nodes with IP information...")
+	c.enrichNodes(containers)
+
+	if err := c.GenerateInventories(); err != nil {
+		return nil, err
+	}
+
+	res := &DeployResult{
+		Exec: map[string]map[string]*ExecResult{},
+	}
+	var m sync.Mutex
+	wg := &sync.WaitGroup{}
+	wg.Add(len(c.Nodes))
+
+	for _, node := range c.Nodes {
+		go func(node nodes.Node, wg *sync.WaitGroup) {
+			defer wg.Done()
+			err := node.PostDeploy(ctx, c.Nodes)
+			if err != nil {
+				log.Errorf("failed to run postdeploy task for node %s: %v", node.Config().ShortName, err)
+				c.emitNodeFailed(node.Config().ShortName, PhasePostDeploy, err)
+				m.Lock()
+				res.Errors = append(res.Errors, &NodeError{Node: node.Config().ShortName, Err: err})
+				m.Unlock()
+				return
+			}
+			c.emit(Event{Type: EventNodePostDeployed, Node: node.Config().ShortName})
+		}(node, wg)
+	}
+	wg.Wait()
+
+	// Update containers after postDeploy action
+	containers, err = c.ListContainers(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+	res.Containers = containers
+
+	// generate graph of the lab topology
+	if o.Graph {
+		if err = c.GenerateGraph(""); err != nil {
+			log.Error(err)
+			res.Errors = append(res.Errors, err)
+		}
+	}
+
+	// lab node names are resolved by the lab DNS server when it is enabled
+	if !c.Config.Mgmt.DNS {
+		log.Info("Adding containerlab host entries to /etc/hosts file")
+		err = AppendHostsFileEntries(containers, c.Config.Name)
+		if err != nil {
+			log.Errorf("failed to create hosts file: %v", err)
+			res.Errors = append(res.Errors, fmt.Errorf("failed to create hosts file: %v", err))
+		}
+	}
+
+	// exec commands specified for containers with `exec` parameter
+	for _, cont := range containers {
+		name := cont.Labels[NodeNameLabel]
+		node, ok := c.Nodes[name]
+		if !ok || len(node.Config().Exec) == 0 {
+			continue
+		}
+		contName := strings.TrimLeft(cont.Names[0], "/")
+		execRes, err := ExecCmds(ctx, cont, node.GetRuntime(), node.Config().Exec)
+		res.Exec[contName] = execRes
+		if err != nil {
+			log.Errorf("Failed to exec commands for node %s: %v", name, err)
+			res.Errors = append(res.Errors, &NodeError{Node: name, Err: err})
+		}
+	}
+
+	c.emit(Event{Type: EventLabReady})
+	return res, nil
+}
+
+// serialNodes returns the set of nodes long names which runtimes do not support concurrent operations
+func (c *CLab) serialNodes() map[string]struct{} {
+	serialNodes := make(map[string]struct{})
+	for _, n := range c.Nodes {
+		if n.GetRuntime().GetName() == runtime.IgniteRuntime {
+			serialNodes[n.Config().LongName] = struct{}{}
+		}
+	}
+	return serialNodes
+}
+
+// enrichNodes sets the management addresses and the container IDs of the lab nodes
+func (c *CLab) enrichNodes(containers []types.GenericContainer) {
+	for _, cont := range containers {
+		if node, ok := c.Nodes[cont.Labels[NodeNameLabel]]; ok {
+			// add network information
+			// skipping host networking nodes as they don't have separate addresses
+			if strings.ToLower(node.Config().NetworkMode) == "host" {
+				continue
+			}
+			if cont.NetworkSettings != (types.GenericMgmtIPs{}) {
+				node.Config().MgmtIPv4Address = cont.NetworkSettings.IPv4addr
+				node.Config().MgmtIPv4PrefixLength = cont.NetworkSettings.IPv4pLen
+				node.Config().MgmtIPv6Address = cont.NetworkSettings.IPv6addr
+				node.Config().MgmtIPv6PrefixLength = cont.NetworkSettings.IPv6pLen
+			}
+			node.Config().ContainerID = cont.ID
+		}
+	}
+}
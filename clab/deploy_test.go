@@ -0,0 +1,57 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestEnrichNodes(t *testing.T) {
+	c, err := NewContainerLab(WithTopoFile("test_data/topo1.yml", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.enrichNodes([]types.GenericContainer{
+		{
+			ID:     "abcdef",
+			Labels: map[string]string{NodeNameLabel: "node2"},
+			NetworkSettings: types.GenericMgmtIPs{
+				IPv4addr: "172.100.100.12",
+				IPv4pLen: 24,
+				IPv6addr: "2001:172:100:100::12",
+				IPv6pLen: 64,
+			},
+		},
+		{
+			ID:     "unknown",
+			Labels: map[string]string{NodeNameLabel: "node3"},
+		},
+	})
+
+	cfg := c.Nodes["node2"].Config()
+	if cfg.ContainerID != "abcdef" || cfg.MgmtIPv4PrefixLength != 24 ||
+		cfg.MgmtIPv6Address != "2001:172:100:100::12" || cfg.MgmtIPv6PrefixLength != 64 {
+		t.Errorf("node2 was not enriched: %+v", cfg)
+	}
+	if cfg := c.Nodes["node1"].Config(); cfg.ContainerID != "" {
+		t.Errorf("node1 without a container got container ID %q", cfg.ContainerID)
+	}
+}
+
+func TestNodeError(t *testing.T) {
+	inner := errors.New("timeout")
+	var err error = &NodeError{Node: "node1", Err: inner}
+
+	if err.Error() != "node node1: timeout" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+	if !errors.Is(err, inner) {
+		t.Error("node error does not wrap the cause")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/nodes"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/vishvananda/netlink"
+)
+
+// DestroyOptions are the options of a lab removal
+type DestroyOptions struct {
+	// Cleanup removes the lab directory
+	Cleanup bool
+	// MaxWorkers limits the number of workers deleting nodes, 0 means no limit
+	MaxWorkers uint
+	// KeepMgmtNet keeps the management network of the lab
+	KeepMgmtNet bool
+}
+
+// Destroy removes the lab nodes along with the lab DNS server, hosts entries, management and additional networks.
+// Only the bridges created by containerlab are removed from the labs without deployed containers
+func (c *CLab) Destroy(ctx context.Context, o DestroyOptions) error {
+	if err := c.subscribeWebhooks(); err != nil {
+		log.Warnf("failed to subscribe webhooks: %v", err)
+	}
+
+	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
+	containers, err := c.ListContainers(ctx, labels)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		// the bridges created by a failed deployment are removed even if no container is left
+		c.deleteBridgeNodes(ctx)
+		return nil
+	}
+
+	var labDir string
+	if o.Cleanup {
+		labDir = filepath.Dir(containers[0].Labels[NodeLabDirLabel])
+	}
+
+	maxWorkers := o.MaxWorkers
+	if maxWorkers == 0 {
+		maxWorkers = uint(len(c.Nodes))
+	}
+
+	// a set of workers that do not support concurrency
+	serialNodes := c.serialNodes()
+	// decreasing the num of maxWorkers as they are used for concurrent nodes
+	maxWorkers -= uint(len(serialNodes))
+
+	// Serializing ignite workers due to busy device error
+	if _, ok := c.Runtimes[runtime.IgniteRuntime]; ok {
+		maxWorkers = 1
+	}
+
+	log.Infof("Destroying lab: %s", c.Config.Name)
+	c.DeleteNodes(ctx, maxWorkers, serialNodes)
+
+	if err := c.StopDNS(); err != nil {
+		log.Errorf("failed to stop lab DNS server: %v", err)
+	}
+
+	// remove the lab directories
+	if o.Cleanup {
+		err = os.RemoveAll(labDir)
+		if err != nil {
+			log.Errorf("error deleting lab directory: %v", err)
+		}
+	}
+
+	log.Info("Removing containerlab host entries from /etc/hosts file")
+	err = DeleteEntriesFromHostsFile(c.Config.Name)
+	if err != nil {
+		return fmt.Errorf("error while trying to clean up the hosts file: %w", err)
+	}
+
+	// delete lab management network
+	if c.Config.Mgmt.Network != "bridge" && !o.KeepMgmtNet {
+		log.Debugf("Calling DeleteNet method. *CLab.Config.Mgmt value is: %+v", c.Config.Mgmt)
+		if err = c.GlobalRuntime().DeleteNet(ctx); err != nil {
+			// do not log error message if deletion error simply says that such network doesn't exist
+			if err.Error() != fmt.Sprintf("Error: No such network: %s", c.Config.Mgmt.Network) {
+				log.Error(err)
+			}
+		}
+	}
+	// delete the additional networks
+	c.DeleteNetworks(ctx)
+	// delete container network namespaces symlinks
+	err = c.DeleteNetnsSymlinks()
+	if err != nil {
+		return fmt.Errorf("error while deleting netns symlinks: %w", err)
+	}
+
+	c.emit(Event{Type: EventLabDestroyed})
+	return nil
+}
+
+// deleteBridgeNodes deletes the existing bridges which lifecycle is managed by containerlab
+func (c *CLab) deleteBridgeNodes(ctx context.Context) {
+	for _, n := range c.Nodes {
+		switch n.Config().Kind {
+		case nodes.NodeKindBridge:
+			if _, err := netlink.LinkByName(n.Config().ShortName); err != nil {
+				continue
+			}
+		case nodes.NodeKindOVS:
+		default:
+			continue
+		}
+		if err := n.Delete(ctx); err != nil {
+			log.Errorf("could not remove bridge %q: %v", n.Config().ShortName, err)
+		}
+	}
+}
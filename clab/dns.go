@@ -0,0 +1,394 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+)
+
+const (
+	// DNSPidFile is the name of the file in the lab directory holding the pid of the lab DNS server
+	DNSPidFile = "dns.pid"
+	// DNSLogFile is the name of the file in the lab directory the lab DNS server logs to
+	DNSLogFile = "dns.log"
+
+	dnsTTL             = 30
+	dnsForwardTimeout  = 2 * time.Second
+	dnsRefreshInterval = 5 * time.Second
+	dnsStartTimeout    = 5 * time.Second
+	hostResolvConf     = "/etc/resolv.conf"
+)
+
+// LabDomain returns the DNS domain of the lab nodes, which is the domain of the nodes fqdn
+func LabDomain(labName string) string {
+	return labName + ".io"
+}
+
+// dnsRecord holds the addresses of a DNS name
+type dnsRecord struct {
+	v4 []net.IP
+	v6 []net.IP
+}
+
+// DNSServer answers queries for the lab node names and forwards other queries to the upstream resolvers
+type DNSServer struct {
+	domain   string
+	upstream []string
+
+	m       sync.RWMutex
+	records map[string]*dnsRecord
+}
+
+// NewDNSServer returns a DNS server authoritative for the lab domain
+// that forwards other queries to the upstream resolvers given as host:port
+func NewDNSServer(domain string, upstream []string) *DNSServer {
+	return &DNSServer{
+		domain:   dns.Fqdn(strings.ToLower(domain)),
+		upstream: upstream,
+		records:  map[string]*dnsRecord{},
+	}
+}
+
+// SetRecords replaces the DNS records with the records built from the lab containers.
+// Each container is resolvable by its fqdn (<node>.<lab>.io) and its long name
+func (s *DNSServer) SetRecords(containers []types.GenericContainer) {
+	records := map[string]*dnsRecord{}
+	for _, cont := range containers {
+		r := &dnsRecord{}
+		if ip := net.ParseIP(cont.NetworkSettings.IPv4addr); ip != nil {
+			r.v4 = append(r.v4, ip)
+		}
+		if ip := net.ParseIP(cont.NetworkSettings.IPv6addr); ip != nil {
+			r.v6 = append(r.v6, ip)
+		}
+		if len(r.v4) == 0 && len(r.v6) == 0 {
+			continue
+		}
+		if name := cont.Labels[NodeNameLabel]; name != "" {
+			records[dns.Fqdn(strings.ToLower(name))+s.domain] = r
+		}
+		if len(cont.Names) > 0 {
+			records[dns.Fqdn(strings.ToLower(strings.TrimLeft(cont.Names[0], "/")))] = r
+		}
+	}
+
+	s.m.Lock()
+	s.records = records
+	s.m.Unlock()
+}
+
+// ServeDNS implements dns.Handler
+func (s *DNSServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) != 1 {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeFormatError)
+		_ = w.WriteMsg(m)
+		return
+	}
+	q := req.Question[0]
+	name := strings.ToLower(q.Name)
+
+	s.m.RLock()
+	r, ok := s.records[name]
+	s.m.RUnlock()
+
+	if !ok && !dns.IsSubDomain(s.domain, name) {
+		s.forward(w, req)
+		return
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+	if !ok {
+		m.Rcode = dns.RcodeNameError
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	hdr := func(t uint16) dns.RR_Header {
+		return dns.RR_Header{Name: q.Name, Rrtype: t, Class: dns.ClassINET, Ttl: dnsTTL}
+	}
+	if q.Qtype == dns.TypeA || q.Qtype == dns.TypeANY {
+		for _, ip := range r.v4 {
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr(dns.TypeA), A: ip})
+		}
+	}
+	if q.Qtype == dns.TypeAAAA || q.Qtype == dns.TypeANY {
+		for _, ip := range r.v6 {
+			m.Answer = append(m.Answer, &dns.AAAA{Hdr: hdr(dns.TypeAAAA), AAAA: ip})
+		}
+	}
+	_ = w.WriteMsg(m)
+}
+
+// forward sends the query to the upstream resolvers and relays the first received answer
+func (s *DNSServer) forward(w dns.ResponseWriter, req *dns.Msg) {
+	c := &dns.Client{Timeout: dnsForwardTimeout}
+	if _, ok := w.LocalAddr().(*net.TCPAddr); ok {
+		c.Net = "tcp"
+	}
+	for _, up := range s.upstream {
+		resp, _, err := c.Exchange(req, up)
+		if err != nil {
+			log.Debugf("failed to forward query for %s to %s: %v", req.Question[0].Name, up, err)
+			continue
+		}
+		_ = w.WriteMsg(resp)
+		return
+	}
+	m := new(dns.Msg)
+	m.SetRcode(req, dns.RcodeServerFailure)
+	_ = w.WriteMsg(m)
+}
+
+// ListenAndServe serves DNS over UDP and TCP on addr until the context is canceled
+func (s *DNSServer) ListenAndServe(ctx context.Context, addr string) error {
+	servers := []*dns.Server{
+		{Addr: addr, Net: "udp", Handler: s},
+		{Addr: addr, Net: "tcp", Handler: s},
+	}
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *dns.Server) {
+			errCh <- srv.ListenAndServe()
+		}(srv)
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+	}
+	for _, srv := range servers {
+		_ = srv.Shutdown()
+	}
+	return err
+}
+
+// ServeDNS runs the lab DNS server on addr. DNS records are built from the lab containers
+// and refreshed periodically, so that restarted and redeployed nodes are picked up
+func (c *CLab) ServeDNS(ctx context.Context, addr string) error {
+	upstream := hostResolvers(addr)
+	log.Infof("Serving DNS for the %s domain on %s, upstream resolvers: %v", LabDomain(c.Config.Name), addr, upstream)
+	s := NewDNSServer(LabDomain(c.Config.Name), upstream)
+
+	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
+	refresh := func() {
+		containers, err := c.ListContainers(ctx, labels)
+		if err != nil {
+			log.Errorf("failed to refresh DNS records: %v", err)
+			return
+		}
+		s.SetRecords(containers)
+	}
+	refresh()
+
+	go func() {
+		ticker := time.NewTicker(dnsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+
+	return s.ListenAndServe(ctx, addr)
+}
+
+// hostResolvers returns the resolvers configured on the host in host:port format,
+// the address of the lab DNS server itself is skipped to avoid forwarding loops
+func hostResolvers(self string) []string {
+	cfg, err := dns.ClientConfigFromFile(hostResolvConf)
+	if err != nil {
+		log.Warnf("failed to read host resolvers, queries outside of the lab domain will fail: %v", err)
+		return nil
+	}
+	servers := make([]string, 0, len(cfg.Servers))
+	for _, srv := range cfg.Servers {
+		addr := net.JoinHostPort(srv, cfg.Port)
+		if addr == self {
+			continue
+		}
+		servers = append(servers, addr)
+	}
+	return servers
+}
+
+// StartDNS starts the lab DNS server in the background on the address of the management bridge
+// and sets it as the resolver of the lab nodes. The server runs the `tools dns serve` command
+// of the containerlab executable, which defaults to the current executable when empty.
+// The address of the DNS server is returned
+func (c *CLab) StartDNS(executable string) (string, error) {
+	if err := c.StopDNS(); err != nil {
+		return "", err
+	}
+
+	v4, _, err := utils.FirstLinkIPs(c.Config.Mgmt.Bridge)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the address of the management bridge %q: %v", c.Config.Mgmt.Bridge, err)
+	}
+	if v4 == "" {
+		return "", fmt.Errorf("management bridge %q has no IPv4 address to serve DNS on", c.Config.Mgmt.Bridge)
+	}
+	addr := net.JoinHostPort(v4, "53")
+	// the labs sharing a management network can't run their DNS servers on the same address
+	if err := checkDNSAddrFree(addr); err != nil {
+		return "", fmt.Errorf("failed to start the lab DNS server, another lab on the management network %q might be serving DNS: %v",
+			c.Config.Mgmt.Network, err)
+	}
+
+	exe := executable
+	if exe == "" {
+		if exe, err = os.Executable(); err != nil {
+			return "", err
+		}
+	}
+	logPath := filepath.Join(c.Dir.Lab, DNSLogFile)
+	logf, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer logf.Close()
+
+	srv := exec.Command(exe, "tools", "dns", "serve", // skipcq: GSC-G204
+		"--name", c.Config.Name,
+		"--listen", addr,
+		"--runtime", c.GlobalRuntime().GetName(),
+	)
+	srv.Stdout = logf
+	srv.Stderr = logf
+	// detach the server from the deploy process session, so that it outlives it
+	srv.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := srv.Start(); err != nil {
+		return "", fmt.Errorf("failed to start the lab DNS server: %v", err)
+	}
+	pid := srv.Process.Pid
+	// reap the server if it exits while the deploy is running
+	exited := make(chan error, 1)
+	go func() { exited <- srv.Wait() }()
+	if err := waitDNSListening(addr, exited, dnsStartTimeout); err != nil {
+		_ = srv.Process.Kill()
+		return "", fmt.Errorf("lab DNS server failed to start, see %s: %v", logPath, err)
+	}
+	if err := utils.CreateFile(filepath.Join(c.Dir.Lab, DNSPidFile), strconv.Itoa(pid)); err != nil {
+		return "", err
+	}
+	log.Infof("Started lab DNS server on %s (pid %d)", addr, pid)
+
+	for _, n := range c.Nodes {
+		cfg := n.Config()
+		if cfg.NetworkMode != "" || len(cfg.DNSServers) > 0 {
+			continue
+		}
+		cfg.DNSServers = []string{v4}
+	}
+
+	log.Infof("To resolve the lab node names on a host with systemd-resolved run: resolvectl dns %s %s && resolvectl domain %s '~%s'",
+		c.Config.Mgmt.Bridge, v4, c.Config.Mgmt.Bridge, LabDomain(c.Config.Name))
+	return addr, nil
+}
+
+// checkDNSAddrFree checks that the UDP and TCP ports of the address are not bound
+func checkDNSAddrFree(addr string) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	pc.Close()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+// waitDNSListening waits for the DNS server to accept TCP connections on the address,
+// an error is returned if the server exits or doesn't listen within the timeout
+func waitDNSListening(addr string, exited <-chan error, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				return fmt.Errorf("server exited")
+			}
+			return fmt.Errorf("server exited: %v", err)
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("server is not listening on %s: %v", addr, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// StopDNS stops the lab DNS server started by StartDNS, if it is running
+func (c *CLab) StopDNS() error {
+	pidFile := filepath.Join(c.Dir.Lab, DNSPidFile)
+	b, err := ioutil.ReadFile(pidFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pidFile)
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("invalid pid file %s: %v", pidFile, err)
+	}
+	// the pid might have been reused after a reboot, so make sure it belongs to the lab DNS server
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || !isLabDNSCmdline(string(cmdline), c.Config.Name) {
+		return nil
+	}
+	log.Infof("Stopping lab DNS server (pid %d)", pid)
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to stop the lab DNS server: %v", err)
+	}
+	return nil
+}
+
+// isLabDNSCmdline checks if a NUL separated process command line is of the lab DNS server
+func isLabDNSCmdline(cmdline, lab string) bool {
+	args := strings.Split(strings.TrimRight(cmdline, "\x00"), "\x00")
+	var serve bool
+	for i, a := range args {
+		if a == "serve" && i >= 2 && args[i-1] == "dns" && args[i-2] == "tools" {
+			serve = true
+		}
+		if serve && a == "--name" && i+1 < len(args) && args[i+1] == lab {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/srl-labs/containerlab/types"
+)
+
+// dnsServeEnv makes the test binary serve DNS on the --listen address
+// when it is started by StartDNS in place of the containerlab executable
+const dnsServeEnv = "CLAB_TEST_DNS_SERVE"
+
+func init() {
+	if os.Getenv(dnsServeEnv) == "" {
+		return
+	}
+	var addr string
+	for i, a := range os.Args {
+		if a == "--listen" && i+1 < len(os.Args) {
+			addr = os.Args[i+1]
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer cancel()
+	if err := NewDNSServer(LabDomain("test"), nil).ListenAndServe(ctx, addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// dnsRecorder is a dns.ResponseWriter that records the written message
+type dnsRecorder struct {
+	msg *dns.Msg
+}
+
+var dnsRecorderAddr = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+
+func (*dnsRecorder) LocalAddr() net.Addr         { return dnsRecorderAddr }
+func (*dnsRecorder) RemoteAddr() net.Addr        { return dnsRecorderAddr }
+func (r *dnsRecorder) WriteMsg(m *dns.Msg) error { r.msg = m; return nil }
+func (*dnsRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (*dnsRecorder) Close() error                { return nil }
+func (*dnsRecorder) TsigStatus() error           { return nil }
+func (*dnsRecorder) TsigTimersOnly(bool)         {}
+func (*dnsRecorder) Hijack()                     {}
+
+func TestDNSServer(t *testing.T) {
+	s := NewDNSServer(LabDomain("demo"), nil)
+	s.SetRecords([]types.GenericContainer{
+		{
+			Names:  []string{"/clab-demo-l1"},
+			Labels: map[string]string{NodeNameLabel: "l1"},
+			NetworkSettings: types.GenericMgmtIPs{
+				IPv4addr: "172.20.20.2",
+				IPv6addr: "2001:172:20:20::2",
+			},
+		},
+		{
+			Names:           []string{"/clab-demo-l2"},
+			Labels:          map[string]string{NodeNameLabel: "l2"},
+			NetworkSettings: types.GenericMgmtIPs{},
+		},
+	})
+
+	tests := map[string]struct {
+		name   string
+		qtype  uint16
+		rcode  int
+		answer []string
+	}{
+		"fqdn_a": {
+			name:   "l1.demo.io.",
+			qtype:  dns.TypeA,
+			rcode:  dns.RcodeSuccess,
+			answer: []string{"172.20.20.2"},
+		},
+		"fqdn_aaaa_case_insensitive": {
+			name:   "L1.Demo.IO.",
+			qtype:  dns.TypeAAAA,
+			rcode:  dns.RcodeSuccess,
+			answer: []string{"2001:172:20:20::2"},
+		},
+		"long_name_any": {
+			name:   "clab-demo-l1.",
+			qtype:  dns.TypeANY,
+			rcode:  dns.RcodeSuccess,
+			answer: []string{"172.20.20.2", "2001:172:20:20::2"},
+		},
+		"node_without_addresses": {
+			name:  "l2.demo.io.",
+			qtype: dns.TypeA,
+			rcode: dns.RcodeNameError,
+		},
+		"unknown_in_lab_domain": {
+			name:  "l3.demo.io.",
+			qtype: dns.TypeA,
+			rcode: dns.RcodeNameError,
+		},
+		"forwarded_without_upstream": {
+			name:  "example.com.",
+			qtype: dns.TypeA,
+			rcode: dns.RcodeServerFailure,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := new(dns.Msg)
+			req.SetQuestion(tc.name, tc.qtype)
+			w := &dnsRecorder{}
+			s.ServeDNS(w, req)
+
+			if w.msg == nil {
+				t.Fatal("no response written")
+			}
+			if w.msg.Rcode != tc.rcode {
+				t.Errorf("rcode: got %s, want %s", dns.RcodeToString[w.msg.Rcode], dns.RcodeToString[tc.rcode])
+			}
+			var answer []string
+			for _, rr := range w.msg.Answer {
+				switch rr := rr.(type) {
+				case *dns.A:
+					answer = append(answer, rr.A.String())
+				case *dns.AAAA:
+					answer = append(answer, rr.AAAA.String())
+				}
+			}
+			if d := cmp.Diff(tc.answer, answer); d != "" {
+				t.Errorf("answer mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestIsLabDNSCmdline(t *testing.T) {
+	tests := map[string]struct {
+		cmdline string
+		want    bool
+	}{
+		"lab_dns_server": {
+			cmdline: "/usr/bin/containerlab\x00tools\x00dns\x00serve\x00--name\x00demo\x00--listen\x00172.20.20.1:53\x00",
+			want:    true,
+		},
+		"other_lab": {
+			cmdline: "/usr/bin/containerlab\x00tools\x00dns\x00serve\x00--name\x00demo2\x00--listen\x00172.20.20.1:53\x00",
+			want:    false,
+		},
+		"other_command": {
+			cmdline: "/usr/bin/containerlab\x00deploy\x00--name\x00demo\x00",
+			want:    false,
+		},
+		"reused_pid": {
+			cmdline: "/usr/sbin/sshd\x00-D\x00",
+			want:    false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isLabDNSCmdline(tc.cmdline, "demo"); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStartDNSSharedNetwork(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("binding port 53 requires root privileges")
+	}
+	// the loopback interface stands for the management bridge shared by the labs
+	if err := checkDNSAddrFree("127.0.0.1:53"); err != nil {
+		t.Skipf("DNS port of the loopback address is in use: %v", err)
+	}
+	t.Setenv(dnsServeEnv, "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var labs []*CLab
+	for _, topo := range []string{"test_data/topo_fake.yml", "test_data/topo_fake_links.yml"} {
+		c, _ := newFakeLab(t, topo)
+		c.Config.Mgmt.Bridge = "lo"
+		c.Dir.Lab = t.TempDir()
+		labs = append(labs, c)
+	}
+	t.Cleanup(func() {
+		for _, c := range labs {
+			c.StopDNS()
+		}
+	})
+
+	addr, err := labs[0].StartDNS(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := labs[1].StartDNS(exe); err == nil {
+		t.Fatal("expected an error starting the DNS server of the second lab on the same network")
+	}
+	// the DNS server of the first lab keeps running
+	msg := new(dns.Msg)
+	msg.SetQuestion("n1."+LabDomain("test")+".", dns.TypeA)
+	if _, _, err := (&dns.Client{Net: "tcp"}).Exchange(msg, addr); err != nil {
+		t.Fatalf("DNS server of the first lab doesn't answer: %v", err)
+	}
+
+	// a server that exits right after the start fails the deploy
+	if err := labs[0].StopDNS(); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitDNSAddrFree(addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := labs[1].StartDNS("/bin/false"); err == nil {
+		t.Fatal("expected an error starting a DNS server that exits")
+	}
+}
+
+// waitDNSAddrFree waits for a stopped DNS server to release the address
+func waitDNSAddrFree(addr string) error {
+	var err error
+	for i := 0; i < 50; i++ {
+		if err = checkDNSAddrFree(addr); err == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return err
+}
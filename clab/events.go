@@ -0,0 +1,256 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/types"
+)
+
+// EventType is a type of the lab lifecycle event
+type EventType string
+
+const (
+	// EventNodePreDeploy is emitted before the pre-deploy phase of a node
+	EventNodePreDeploy EventType = "node-pre-deploy"
+	// EventNodeCreated is emitted when a node is created
+	EventNodeCreated EventType = "node-created"
+	// EventNodePostDeployed is emitted when the post-deploy phase of a node is finished
+	EventNodePostDeployed EventType = "node-post-deployed"
+	// EventNodeFailed is emitted when a deployment phase of a node fails
+	EventNodeFailed EventType = "node-failed"
+	// EventLinkCreated is emitted when a link is created
+	EventLinkCreated EventType = "link-created"
+	// EventLabReady is emitted when the lab deployment is finished
+	EventLabReady EventType = "lab-ready"
+	// EventLabDestroyed is emitted when the lab is destroyed
+	EventLabDestroyed EventType = "lab-destroyed"
+	// EventLabResumed is emitted when the lab is resumed
+	EventLabResumed EventType = "lab-resumed"
+
+	// node deployment phases reported in the node failed events
+	PhasePreDeploy  = "pre-deploy"
+	PhaseDeploy     = "deploy"
+	PhasePostDeploy = "post-deploy"
+
+	webhookTimeout   = 5 * time.Second
+	webhookQueueSize = 256
+)
+
+// EventTypes is the list of the lab lifecycle event types
+var EventTypes = []EventType{
+	EventNodePreDeploy,
+	EventNodeCreated,
+	EventNodePostDeployed,
+	EventNodeFailed,
+	EventLinkCreated,
+	EventLabReady,
+	EventLabDestroyed,
+	EventLabResumed,
+}
+
+// Event is a lab lifecycle event
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	Lab  string    `json:"lab"`
+	// Node is the name of the node for the node events
+	Node string `json:"node,omitempty"`
+	// Phase is the failed deployment phase for the node failed events
+	Phase string `json:"phase,omitempty"`
+	// Link holds the link endpoints for the link events
+	Link *EventLink `json:"link,omitempty"`
+	// Error is the error message for the failed events
+	Error string `json:"error,omitempty"`
+}
+
+// EventLink describes the link of the link events
+type EventLink struct {
+	A string `json:"a"` // node:endpoint
+	B string `json:"b"` // node:endpoint
+}
+
+// EventHandler handles the published events. Handlers are called synchronously
+// from the deployment workers, so they must not block
+type EventHandler func(Event)
+
+// EventBus dispatches the lab lifecycle events to the subscribed handlers
+type EventBus struct {
+	m        sync.RWMutex
+	handlers []EventHandler
+	closers  []func()
+}
+
+// Subscribe adds the handler to the bus
+func (b *EventBus) Subscribe(h EventHandler) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish dispatches the event to the subscribed handlers
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.m.RLock()
+	defer b.m.RUnlock()
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
+
+// Close waits for the asynchronous handlers, such as webhooks, to deliver the published events.
+// Events published after Close are not delivered to the asynchronous handlers
+func (b *EventBus) Close() {
+	b.m.Lock()
+	closers := b.closers
+	b.closers = nil
+	b.m.Unlock()
+	for _, c := range closers {
+		c()
+	}
+}
+
+func (b *EventBus) onClose(f func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.closers = append(b.closers, f)
+}
+
+// emit publishes the event of the lab
+func (c *CLab) emit(e Event) {
+	e.Lab = c.Config.Name
+	e.Time = time.Now()
+	c.Events.Publish(e)
+}
+
+// emitNodeFailed publishes the node failed event
+func (c *CLab) emitNodeFailed(node, phase string, err error) {
+	c.emit(Event{Type: EventNodeFailed, Node: node, Phase: phase, Error: err.Error()})
+}
+
+// NewJSONLinesHandler returns an event handler writing the events as JSON lines
+func NewJSONLinesHandler(w io.Writer) EventHandler {
+	var m sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		m.Lock()
+		defer m.Unlock()
+		if err := enc.Encode(e); err != nil {
+			log.Warnf("failed to write %s event: %v", e.Type, err)
+		}
+	}
+}
+
+// subscribeWebhooks subscribes the webhooks defined in the topology to the lab events, once
+func (c *CLab) subscribeWebhooks() error {
+	var err error
+	c.webhooksOnce.Do(func() {
+		for _, wh := range c.Config.Webhooks {
+			var h EventHandler
+			h, err = newWebhookHandler(c.Events, wh)
+			if err != nil {
+				return
+			}
+			c.Events.Subscribe(h)
+		}
+	})
+	return err
+}
+
+// newWebhookHandler returns an event handler posting the events to the webhook.
+// Events are delivered in order by a background worker, so that the deployment is not slowed down
+func newWebhookHandler(b *EventBus, wh *types.WebhookConfig) (EventHandler, error) {
+	if wh.URL == "" {
+		return nil, fmt.Errorf("webhook url is not set")
+	}
+	filter := map[EventType]struct{}{}
+	for _, t := range wh.Events {
+		known := false
+		for _, et := range EventTypes {
+			if EventType(t) == et {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("webhook %s: unknown event type %q, use one of %q", wh.URL, t, EventTypes)
+		}
+		filter[EventType(t)] = struct{}{}
+	}
+
+	queue := make(chan Event, webhookQueueSize)
+	done := make(chan struct{})
+	client := &http.Client{Timeout: webhookTimeout}
+	go func() {
+		defer close(done)
+		for e := range queue {
+			if err := postWebhook(client, wh, e); err != nil {
+				log.Warnf("failed to deliver %s event to webhook %s: %v", e.Type, wh.URL, err)
+			}
+		}
+	}()
+
+	var m sync.Mutex
+	closed := false
+	b.onClose(func() {
+		m.Lock()
+		closed = true
+		close(queue)
+		m.Unlock()
+		<-done
+	})
+
+	return func(e Event) {
+		if _, ok := filter[e.Type]; len(filter) > 0 && !ok {
+			return
+		}
+		m.Lock()
+		defer m.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case queue <- e:
+		default:
+			log.Warnf("webhook %s queue is full, dropping %s event", wh.URL, e.Type)
+		}
+	}, nil
+}
+
+func postWebhook(client *http.Client, wh *types.WebhookConfig, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestJSONLinesHandler(t *testing.T) {
+	c := &CLab{Config: &Config{Name: "lab1"}, Events: new(EventBus)}
+	var buf bytes.Buffer
+	c.Events.Subscribe(NewJSONLinesHandler(&buf))
+
+	c.emit(Event{Type: EventNodeCreated, Node: "node1"})
+	c.emitNodeFailed("node2", PhasePostDeploy, errors.New("boom"))
+	c.emit(Event{Type: EventLinkCreated, Link: &EventLink{A: "node1:eth1", B: "node2:eth1"}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %s", len(lines), buf.String())
+	}
+	var got []Event
+	for _, l := range lines {
+		var e Event
+		if err := json.Unmarshal([]byte(l), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("event time is not set: %s", l)
+		}
+		e.Time = time.Time{}
+		got = append(got, e)
+	}
+	want := []Event{
+		{Type: EventNodeCreated, Lab: "lab1", Node: "node1"},
+		{Type: EventNodeFailed, Lab: "lab1", Node: "node2", Phase: PhasePostDeploy, Error: "boom"},
+		{Type: EventLinkCreated, Lab: "lab1", Link: &EventLink{A: "node1:eth1", B: "node2:eth1"}},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", d)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var m sync.Mutex
+	var got []EventType
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.Lock()
+		got = append(got, e.Type)
+		m.Unlock()
+	}))
+	defer srv.Close()
+
+	c := &CLab{
+		Config: &Config{
+			Name: "lab1",
+			Webhooks: []*types.WebhookConfig{{
+				URL:     srv.URL,
+				Events:  []string{string(EventNodeFailed), string(EventLabReady)},
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+			}},
+		},
+		Events: new(EventBus),
+	}
+	if err := c.subscribeWebhooks(); err != nil {
+		t.Fatal(err)
+	}
+	// subsequent calls don't subscribe the webhooks again
+	if err := c.subscribeWebhooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.emit(Event{Type: EventNodeCreated, Node: "node1"})
+	c.emitNodeFailed("node1", PhaseDeploy, errors.New("boom"))
+	c.emit(Event{Type: EventLabReady})
+	c.Events.Close()
+	// events published after Close are not delivered
+	c.emit(Event{Type: EventLabReady})
+
+	want := []EventType{EventNodeFailed, EventLabReady}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected delivered events (-want +got):\n%s", d)
+	}
+}
+
+func TestWebhookConfigErrors(t *testing.T) {
+	tests := map[string]*types.WebhookConfig{
+		"no_url":        {Events: []string{string(EventLabReady)}},
+		"unknown_event": {URL: "http://127.0.0.1", Events: []string{"lab-deployed"}},
+	}
+	for name, wh := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &CLab{Config: &Config{Name: "lab1", Webhooks: []*types.WebhookConfig{wh}}, Events: new(EventBus)}
+			if err := c.subscribeWebhooks(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
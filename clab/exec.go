@@ -0,0 +1,40 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/shlex"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+)
+
+// ExecResult is the output of a command executed in a lab container
+type ExecResult struct {
+	Stdout []byte
+	Stderr []byte
+}
+
+// ExecCmds executes the commands in the container one by one
+// and returns their results keyed by the command
+func ExecCmds(ctx context.Context, cont types.GenericContainer, r runtime.ContainerRuntime,
+	cmds []string) (map[string]*ExecResult, error) {
+	results := make(map[string]*ExecResult, len(cmds))
+	for _, cmd := range cmds {
+		c, err := shlex.Split(cmd)
+		if err != nil {
+			return results, err
+		}
+
+		stdout, stderr, err := r.Exec(ctx, cont.ID, c)
+		if err != nil {
+			return results, fmt.Errorf("%s: failed to execute cmd %q: %v", cont.Names, cmd, err)
+		}
+		results[cmd] = &ExecResult{Stdout: stdout, Stderr: stderr}
+	}
+	return results, nil
+}
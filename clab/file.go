@@ -59,7 +59,7 @@ func (c *CLab) GetTopology(topo, varsFile string) error {
 	}
 	if !strings.HasPrefix(fileBase, ".") {
 		// create a hidden file that will contain the rendered topology
-		err = utils.CreateFile(fmt.Sprintf(".%s.yaml", fileBase[:len(fileBase)-len(filepath.Ext(topo))]), buf.String())
+		err = utils.CreateFile(filepath.Join(c.baseDir, fmt.Sprintf(".%s.yaml", fileBase[:len(fileBase)-len(filepath.Ext(topo))])), buf.String())
 		if err != nil {
 			return err
 		}
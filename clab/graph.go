@@ -16,6 +16,12 @@ import (
 
 var g *gographviz.Graph
 
+const (
+	// distance in inches between the columns and rows of the graph layout
+	dotColSpacing = 2
+	dotRowSpacing = 1.5
+)
+
 // GenerateGraph generates a graph of the lab topology
 func (c *CLab) GenerateGraph(_ string) error {
 	log.Info("Generating lab graph...")
@@ -27,6 +33,16 @@ func (c *CLab) GenerateGraph(_ string) error {
 		return err
 	}
 
+	// pin the nodes to their layout positions, the layout engine is set to neato
+	// since dot doesn't take node positions into account
+	var layout map[string]GraphCoordinates
+	if c.hasGraphLayout() {
+		if err := g.AddAttr(c.TopoFile.name, "layout", "neato"); err != nil {
+			return err
+		}
+		layout = c.GraphLayout()
+	}
+
 	var attr map[string]string
 
 	// Process the Nodes
@@ -50,6 +66,10 @@ func (c *CLab) GenerateGraph(_ string) error {
 				attr["fontcolor"] = "black"
 			}
 		}
+		if p, ok := layout[nodeName]; ok {
+			// graphviz y axis grows upwards, positions are set in inches
+			attr["pos"] = fmt.Sprintf("\"%g,%g!\"", p.X*dotColSpacing, -p.Y*dotRowSpacing)
+		}
 		if err := g.AddNode(c.TopoFile.name, node.Config().ShortName, attr); err != nil {
 			return err
 		}
@@ -64,6 +84,14 @@ func (c *CLab) GenerateGraph(_ string) error {
 		if (strings.Contains(link.A.Node.ShortName, "client")) || (strings.Contains(link.B.Node.ShortName, "client")) {
 			attr["color"] = "blue"
 		}
+		// host and mgmt-net link ends are not lab nodes
+		for _, n := range []string{link.A.Node.ShortName, link.B.Node.ShortName} {
+			if !g.IsNode(n) {
+				if err := g.AddNode(c.TopoFile.name, n, map[string]string{"label": n}); err != nil {
+					return err
+				}
+			}
+		}
 		if err := g.AddEdge(link.A.Node.ShortName, link.B.Node.ShortName, false, attr); err != nil {
 			return err
 		}
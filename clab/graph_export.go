@@ -0,0 +1,389 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+)
+
+const (
+	GraphFormatMermaid = "mermaid"
+	GraphFormatDrawio  = "drawio"
+	GraphFormatD2      = "d2"
+	GraphFormatJSON    = "json"
+
+	// drawio node dimensions and spacing
+	drawioNodeWidth  = 100
+	drawioNodeHeight = 50
+	drawioColSpacing = 180
+	drawioRowSpacing = 150
+)
+
+// GraphFormats is a list of diagram formats supported by ExportGraph
+var GraphFormats = []string{GraphFormatMermaid, GraphFormatDrawio, GraphFormatD2, GraphFormatJSON}
+
+// graphFileExtensions maps graph formats to the extensions of the generated files
+var graphFileExtensions = map[string]string{
+	GraphFormatMermaid: "mmd",
+	GraphFormatDrawio:  "drawio",
+	GraphFormatD2:      "d2",
+	GraphFormatJSON:    "json",
+}
+
+// GraphNode is a format neutral representation of a lab node in a diagram
+type GraphNode struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Image string `json:"image,omitempty"`
+	Group string `json:"group,omitempty"`
+	// coordinates of the node in the layout grid
+	Column float64 `json:"column"`
+	Row    float64 `json:"row"`
+}
+
+// GraphLink is a format neutral representation of a lab link in a diagram
+type GraphLink struct {
+	Source         string `json:"source"`
+	SourceEndpoint string `json:"source_endpoint"`
+	Target         string `json:"target"`
+	TargetEndpoint string `json:"target_endpoint"`
+}
+
+// GraphData holds the lab nodes and links used to render diagrams
+type GraphData struct {
+	Name  string       `json:"name"`
+	Nodes []*GraphNode `json:"nodes"`
+	Links []*GraphLink `json:"links"`
+}
+
+// ExportGraph renders the lab topology in a given diagram format
+// and writes it to the lab graph directory. The path to the generated file is returned
+func (c *CLab) ExportGraph(format string) (string, error) {
+	var render func(*GraphData) (string, error)
+	switch format {
+	case GraphFormatMermaid:
+		render = renderMermaid
+	case GraphFormatDrawio:
+		render = renderDrawio
+	case GraphFormatD2:
+		render = renderD2
+	case GraphFormatJSON:
+		render = renderGraphJSON
+	default:
+		return "", fmt.Errorf("graph format %q is not supported, use one of %q", format, GraphFormats)
+	}
+
+	out, err := render(c.GraphData())
+	if err != nil {
+		return "", err
+	}
+
+	utils.CreateDirectory(c.Dir.Lab, 0755)
+	utils.CreateDirectory(c.Dir.LabGraph, 0755)
+
+	fname := filepath.Join(c.Dir.LabGraph, c.TopoFile.name+"."+graphFileExtensions[format])
+	if err := utils.CreateFile(fname, out); err != nil {
+		return "", err
+	}
+	log.Infof("Created %s", fname)
+	return fname, nil
+}
+
+// GraphData builds the format neutral graph of the lab topology
+// with nodes placed on a layout grid
+func (c *CLab) GraphData() *GraphData {
+	g := &GraphData{
+		Name:  c.Config.Name,
+		Nodes: make([]*GraphNode, 0, len(c.Nodes)),
+		Links: make([]*GraphLink, 0, len(c.Links)),
+	}
+
+	for _, n := range c.Nodes {
+		g.Nodes = append(g.Nodes, &GraphNode{
+			Name:  n.Config().ShortName,
+			Kind:  n.Config().Kind,
+			Image: n.Config().Image,
+			Group: n.Config().Group,
+		})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Name < g.Nodes[j].Name
+	})
+
+	idxs := make([]int, 0, len(c.Links))
+	for i := range c.Links {
+		idxs = append(idxs, i)
+	}
+	sort.Ints(idxs)
+	for _, i := range idxs {
+		l := c.Links[i]
+		g.Links = append(g.Links, &GraphLink{
+			Source:         l.A.Node.ShortName,
+			SourceEndpoint: l.A.EndpointName,
+			Target:         l.B.Node.ShortName,
+			TargetEndpoint: l.B.EndpointName,
+		})
+	}
+
+	layout := c.GraphLayout()
+	nodes := map[string]bool{}
+	var bottom float64
+	for _, n := range g.Nodes {
+		n.Column, n.Row = layout[n.Name].X, layout[n.Name].Y
+		nodes[n.Name] = true
+		if n.Row+1 > bottom {
+			bottom = n.Row + 1
+		}
+	}
+
+	// host and mgmt-net link ends are not lab nodes,
+	// they are added to the graph in a row below the lab nodes
+	var col float64
+	for _, i := range idxs {
+		for _, e := range []*types.Endpoint{c.Links[i].A, c.Links[i].B} {
+			if nodes[e.Node.ShortName] {
+				continue
+			}
+			nodes[e.Node.ShortName] = true
+			g.Nodes = append(g.Nodes, &GraphNode{
+				Name:   e.Node.ShortName,
+				Kind:   e.Node.Kind,
+				Column: col,
+				Row:    bottom,
+			})
+			col++
+		}
+	}
+	return g
+}
+
+var graphIDRe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// graphID returns an identifier safe to use in mermaid diagrams
+func graphID(name string) string {
+	return graphIDRe.ReplaceAllString(name, "_")
+}
+
+// groupedNodes returns graph nodes grouped by their group name, group names are sorted by their tier
+// with ungrouped nodes returned last under an empty group name
+func groupedNodes(g *GraphData) ([]string, map[string][]*GraphNode) {
+	groups := map[string][]*GraphNode{}
+	for _, n := range g.Nodes {
+		groups[n.Group] = append(groups[n.Group], n)
+	}
+	names := make([]string, 0, len(groups))
+	for grp := range groups {
+		names = append(names, grp)
+	}
+	sort.Slice(names, func(i, j int) bool { return groupTierLess(names[i], names[j]) })
+	return names, groups
+}
+
+func renderMermaid(g *GraphData) (string, error) {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "---\ntitle: %s\n---\n", g.Name)
+	b.WriteString("graph TB\n")
+
+	names, groups := groupedNodes(g)
+	for _, grp := range names {
+		indent := "  "
+		if grp != "" {
+			fmt.Fprintf(b, "  subgraph %s[\"%s\"]\n", graphID("group_"+grp), grp)
+			indent = "    "
+		}
+		for _, n := range groups[grp] {
+			fmt.Fprintf(b, "%s%s[\"%s<br/><small>%s</small>\"]\n", indent, graphID(n.Name), n.Name, n.Kind)
+		}
+		if grp != "" {
+			b.WriteString("  end\n")
+		}
+	}
+
+	for _, l := range g.Links {
+		fmt.Fprintf(b, "  %s ---|\"%s - %s\"| %s\n",
+			graphID(l.Source), l.SourceEndpoint, l.TargetEndpoint, graphID(l.Target))
+	}
+	return b.String(), nil
+}
+
+func renderD2(g *GraphData) (string, error) {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "# %s\n", g.Name)
+	b.WriteString("direction: down\n\n")
+
+	// path of every node in the diagram, nodes of a group are nested in a container
+	paths := map[string]string{}
+	names, groups := groupedNodes(g)
+	for _, grp := range names {
+		indent := ""
+		if grp != "" {
+			fmt.Fprintf(b, "%q: {\n", grp)
+			indent = "  "
+		}
+		for _, n := range groups[grp] {
+			fmt.Fprintf(b, "%s%q: %q\n", indent, n.Name, n.Name+"\n"+n.Kind)
+			paths[n.Name] = strconv.Quote(n.Name)
+			if grp != "" {
+				paths[n.Name] = strconv.Quote(grp) + "." + strconv.Quote(n.Name)
+			}
+		}
+		if grp != "" {
+			b.WriteString("}\n")
+		}
+	}
+	b.WriteString("\n")
+
+	for _, l := range g.Links {
+		src, ok := paths[l.Source]
+		if !ok {
+			src = strconv.Quote(l.Source)
+		}
+		dst, ok := paths[l.Target]
+		if !ok {
+			dst = strconv.Quote(l.Target)
+		}
+		fmt.Fprintf(b, "%s -- %s: {\n  source-arrowhead.label: %q\n  target-arrowhead.label: %q\n}\n",
+			src, dst, l.SourceEndpoint, l.TargetEndpoint)
+	}
+	return b.String(), nil
+}
+
+func renderGraphJSON(g *GraphData) (string, error) {
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// drawio (mxGraph) file structure
+type drawioFile struct {
+	XMLName xml.Name      `xml:"mxfile"`
+	Host    string        `xml:"host,attr"`
+	Diagram drawioDiagram `xml:"diagram"`
+}
+
+type drawioDiagram struct {
+	Name  string           `xml:"name,attr"`
+	ID    string           `xml:"id,attr"`
+	Model drawioGraphModel `xml:"mxGraphModel"`
+}
+
+type drawioGraphModel struct {
+	Grid  int           `xml:"grid,attr"`
+	Cells []*drawioCell `xml:"root>mxCell"`
+}
+
+type drawioCell struct {
+	ID          string          `xml:"id,attr"`
+	Value       string          `xml:"value,attr,omitempty"`
+	Style       string          `xml:"style,attr,omitempty"`
+	Vertex      string          `xml:"vertex,attr,omitempty"`
+	Edge        string          `xml:"edge,attr,omitempty"`
+	Connectable string          `xml:"connectable,attr,omitempty"`
+	Parent      string          `xml:"parent,attr,omitempty"`
+	Source      string          `xml:"source,attr,omitempty"`
+	Target      string          `xml:"target,attr,omitempty"`
+	Geometry    *drawioGeometry `xml:"mxGeometry,omitempty"`
+}
+
+type drawioGeometry struct {
+	X        float64 `xml:"x,attr,omitempty"`
+	Y        float64 `xml:"y,attr,omitempty"`
+	Width    int     `xml:"width,attr,omitempty"`
+	Height   int     `xml:"height,attr,omitempty"`
+	Relative string  `xml:"relative,attr,omitempty"`
+	As       string  `xml:"as,attr"`
+}
+
+func renderDrawio(g *GraphData) (string, error) {
+	cells := []*drawioCell{
+		{ID: "0"},
+		{ID: "1", Parent: "0"},
+	}
+
+	for _, n := range g.Nodes {
+		cells = append(cells, &drawioCell{
+			ID:     "node-" + n.Name,
+			Value:  n.Name,
+			Style:  "rounded=1;whiteSpace=wrap;html=1;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &drawioGeometry{
+				X:      n.Column * drawioColSpacing,
+				Y:      n.Row * drawioRowSpacing,
+				Width:  drawioNodeWidth,
+				Height: drawioNodeHeight,
+				As:     "geometry",
+			},
+		})
+	}
+
+	labelStyle := "edgeLabel;html=1;align=center;verticalAlign=middle;resizable=0;points=[];"
+	for i, l := range g.Links {
+		id := fmt.Sprintf("link-%d", i)
+		cells = append(cells,
+			&drawioCell{
+				ID:     id,
+				Style:  "endArrow=none;html=1;",
+				Edge:   "1",
+				Parent: "1",
+				Source: "node-" + l.Source,
+				Target: "node-" + l.Target,
+				Geometry: &drawioGeometry{
+					Relative: "1",
+					As:       "geometry",
+				},
+			},
+			// endpoint labels are placed close to the respective nodes
+			&drawioCell{
+				ID:          id + "-source",
+				Value:       l.SourceEndpoint,
+				Style:       labelStyle,
+				Vertex:      "1",
+				Connectable: "0",
+				Parent:      id,
+				Geometry:    &drawioGeometry{X: -0.7, Relative: "1", As: "geometry"},
+			},
+			&drawioCell{
+				ID:          id + "-target",
+				Value:       l.TargetEndpoint,
+				Style:       labelStyle,
+				Vertex:      "1",
+				Connectable: "0",
+				Parent:      id,
+				Geometry:    &drawioGeometry{X: 0.7, Relative: "1", As: "geometry"},
+			},
+		)
+	}
+
+	f := drawioFile{
+		Host: "containerlab",
+		Diagram: drawioDiagram{
+			Name: g.Name,
+			ID:   g.Name,
+			Model: drawioGraphModel{
+				Grid:  1,
+				Cells: cells,
+			},
+		},
+	}
+	b, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(b) + "\n", nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GraphCoordinates are the coordinates of a node in the layout grid,
+// X is a column and Y is a row (tier) counted from the top of the diagram
+type GraphCoordinates struct {
+	X float64
+	Y float64
+}
+
+// nodePosition is a parsed node position, columns and rows are optional
+type nodePosition struct {
+	col, row       float64
+	hasCol, hasRow bool
+}
+
+// clos roles recognized in group names, ordered from the top tier down
+var closRoles = []struct {
+	match func(string) bool
+	rank  int
+}{
+	{func(g string) bool { return strings.Contains(g, "super") && strings.Contains(g, "spine") }, 0},
+	{func(g string) bool { return strings.Contains(g, "spine") }, 1},
+	{func(g string) bool { return strings.Contains(g, "border") }, 2},
+	{func(g string) bool { return strings.Contains(g, "leaf") }, 3},
+	{func(g string) bool {
+		return strings.Contains(g, "client") || strings.Contains(g, "server") || strings.Contains(g, "host")
+	}, 4},
+}
+
+var groupTierRe = regexp.MustCompile(`(\d+)$`)
+
+// GraphLayout computes the coordinates of the lab nodes for the graph outputs.
+//
+// Nodes with a position are placed according to it, see parseNodePosition for the supported formats.
+// The remaining nodes are placed in tiers derived from their groups: Clos roles found in the group names
+// (super-spine, spine, border, leaf, client/server/host) are placed top-down, followed by
+// groups with a numeric suffix (e.g. tier-1, tier-2) in the ascending order, other groups in the
+// alphabetical order and ungrouped nodes last. Each tier is centered relative to the widest one.
+func (c *CLab) GraphLayout() map[string]GraphCoordinates {
+	names := make([]string, 0, len(c.Nodes))
+	for name := range c.Nodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	layout := make(map[string]GraphCoordinates, len(names))
+	// nodes with a row but without a column, keyed by row
+	rows := map[float64][]string{}
+	// occupied columns per row
+	occupied := map[float64]map[float64]struct{}{}
+	// rows that have nodes with user defined columns are not centered
+	fixedRows := map[float64]struct{}{}
+	groups := map[string][]string{}
+	maxRow := -1.0
+
+	for _, name := range names {
+		cfg := c.Nodes[name].Config()
+		pos, err := parseNodePosition(cfg.Position)
+		if err != nil {
+			log.Warnf("node %q: ignoring position: %v", name, err)
+		}
+		switch {
+		case pos.hasCol && pos.hasRow:
+			layout[name] = GraphCoordinates{X: pos.col, Y: pos.row}
+			if occupied[pos.row] == nil {
+				occupied[pos.row] = map[float64]struct{}{}
+			}
+			occupied[pos.row][pos.col] = struct{}{}
+			fixedRows[pos.row] = struct{}{}
+		case pos.hasRow:
+			rows[pos.row] = append(rows[pos.row], name)
+		default:
+			groups[cfg.Group] = append(groups[cfg.Group], name)
+			continue
+		}
+		if pos.row > maxRow {
+			maxRow = pos.row
+		}
+	}
+
+	for i, grp := range sortGroupsByTier(groups) {
+		rows[maxRow+1+float64(i)] = groups[grp]
+	}
+
+	// place the nodes without a column in the free columns of their rows
+	widths := map[float64]float64{}
+	for row, rowNodes := range rows {
+		col := 0.0
+		for _, name := range rowNodes {
+			for {
+				if _, ok := occupied[row][col]; !ok {
+					break
+				}
+				col++
+			}
+			layout[name] = GraphCoordinates{X: col, Y: row}
+			col++
+		}
+	}
+	maxWidth := 0.0
+	for _, p := range layout {
+		if p.X+1 > widths[p.Y] {
+			widths[p.Y] = p.X + 1
+		}
+		if widths[p.Y] > maxWidth {
+			maxWidth = widths[p.Y]
+		}
+	}
+
+	// center the automatically placed rows
+	for name, p := range layout {
+		if _, ok := fixedRows[p.Y]; ok {
+			continue
+		}
+		p.X += (maxWidth - widths[p.Y]) / 2
+		layout[name] = p
+	}
+	return layout
+}
+
+// hasGraphLayout reports whether the lab nodes define positions or their groups form Clos or numbered tiers,
+// otherwise the nodes placement is left to the graph layout engine
+func (c *CLab) hasGraphLayout() bool {
+	for _, n := range c.Nodes {
+		if strings.TrimSpace(n.Config().Position) != "" {
+			return true
+		}
+		if grp := n.Config().Group; grp != "" {
+			if class, _ := groupTierKey(grp); class < 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseNodePosition parses a node position.
+// Supported formats are:
+//   - "x,y" - a column and a row of the layout grid, e.g. "2,1"
+//   - comma separated key=value pairs, where x/col/column keys set a column
+//     and y/row/tier keys set a row, e.g. "tier=2" or "tier=2,col=3"
+//
+// Empty position results in a position without a column and a row.
+func parseNodePosition(p string) (nodePosition, error) {
+	pos := nodePosition{}
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return pos, nil
+	}
+
+	parts := strings.Split(p, ",")
+	if !strings.Contains(p, "=") {
+		if len(parts) != 2 {
+			return pos, fmt.Errorf("position %q is expected in x,y format", p)
+		}
+		parts = []string{"x=" + parts[0], "y=" + parts[1]}
+	}
+
+	for _, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nodePosition{}, fmt.Errorf("position %q: %q is not a key=value pair", p, part)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || v < 0 {
+			return nodePosition{}, fmt.Errorf("position %q: %q is not a non-negative number", p, kv[1])
+		}
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "x", "col", "column":
+			pos.col, pos.hasCol = v, true
+		case "y", "row", "tier":
+			pos.row, pos.hasRow = v, true
+		default:
+			return nodePosition{}, fmt.Errorf("position %q: unknown key %q", p, kv[0])
+		}
+	}
+	if pos.hasCol && !pos.hasRow {
+		return nodePosition{}, fmt.Errorf("position %q sets a column without a row", p)
+	}
+	return pos, nil
+}
+
+// sortGroupsByTier returns the group names in the order of the tiers they are placed in
+func sortGroupsByTier(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for grp := range groups {
+		names = append(names, grp)
+	}
+	sort.Slice(names, func(i, j int) bool { return groupTierLess(names[i], names[j]) })
+	return names
+}
+
+// groupTierLess reports whether group a is placed in a tier above group b
+func groupTierLess(a, b string) bool {
+	// ungrouped nodes go last
+	if a == "" || b == "" {
+		return b == "" && a != ""
+	}
+	ka, na := groupTierKey(a)
+	kb, nb := groupTierKey(b)
+	if ka != kb {
+		return ka < kb
+	}
+	if na != nb {
+		return na < nb
+	}
+	return naturalLess(a, b)
+}
+
+// groupTierKey returns the class of a group (clos role, numbered tier or other)
+// and its rank within the class
+func groupTierKey(g string) (class, rank int) {
+	lg := strings.ToLower(g)
+	for _, r := range closRoles {
+		if r.match(lg) {
+			return 0, r.rank
+		}
+	}
+	if m := groupTierRe.FindStringSubmatch(lg); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return 1, n
+	}
+	return 2, 0
+}
+
+// naturalLess compares strings treating the sequences of digits as numbers,
+// so that leaf2 sorts before leaf10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, _ := strconv.Atoi(da)
+			nb, _ := strconv.Atoi(db)
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
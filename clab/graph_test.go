@@ -0,0 +1,157 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraphData(t *testing.T) {
+	opts := []ClabOption{
+		WithTopoFile("test_data/topo12-graph.yml", ""),
+	}
+	c, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := c.GraphData()
+	pos := map[string][2]float64{}
+	for _, n := range g.Nodes {
+		pos[n.Name] = [2]float64{n.Column, n.Row}
+	}
+	want := map[string][2]float64{
+		// positioned node widens the diagram to 4 columns
+		"client1": {3, 0},
+		// spine tier is placed above the leaf tier, tiers are centered
+		"spine1": {1.5, 1},
+		"leaf1":  {1, 2},
+		"leaf2":  {2, 2},
+		// host link end is placed below the lab nodes
+		"host": {0, 3},
+	}
+	if d := cmp.Diff(want, pos); d != "" {
+		t.Fatalf("node layout mismatch (-want +got):\n%s", d)
+	}
+
+	out, err := renderMermaid(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `spine1 ---|"e1-1 - e1-49"| leaf1`) {
+		t.Fatalf("mermaid diagram is missing a labeled link:\n%s", out)
+	}
+	if !strings.Contains(out, `subgraph group_leaf["leaf"]`) {
+		t.Fatalf("mermaid diagram is missing a group subgraph:\n%s", out)
+	}
+
+	out, err = renderDrawio(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f drawioFile
+	if err := xml.Unmarshal([]byte(out), &f); err != nil {
+		t.Fatalf("failed to parse drawio diagram: %v", err)
+	}
+	// every edge must connect existing cells
+	cells := map[string]bool{}
+	for _, cell := range f.Diagram.Model.Cells {
+		cells[cell.ID] = true
+	}
+	for _, cell := range f.Diagram.Model.Cells {
+		if cell.Edge == "1" && (!cells[cell.Source] || !cells[cell.Target]) {
+			t.Errorf("drawio edge %s connects missing cells %q and %q", cell.ID, cell.Source, cell.Target)
+		}
+	}
+	if !cells["node-host"] {
+		t.Errorf("drawio diagram is missing the host cell:\n%s", out)
+	}
+}
+
+func TestParseNodePosition(t *testing.T) {
+	tests := map[string]struct {
+		pos  string
+		want nodePosition
+		err  bool
+	}{
+		"empty":     {pos: "", want: nodePosition{}},
+		"xy":        {pos: "2, 1", want: nodePosition{col: 2, row: 1, hasCol: true, hasRow: true}},
+		"tier_only": {pos: "tier=3", want: nodePosition{row: 3, hasRow: true}},
+		"tier_col":  {pos: "tier=1,col=0.5", want: nodePosition{col: 0.5, row: 1, hasCol: true, hasRow: true}},
+		"col_only":  {pos: "col=1", err: true},
+		"free_text": {pos: "pos1", err: true},
+		"negative":  {pos: "-1,2", err: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseNodePosition(tc.pos)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error for position %q", tc.pos)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("wanted %+v got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGroupTierOrder(t *testing.T) {
+	groups := map[string][]string{}
+	for _, g := range []string{"", "servers", "leaf", "tier-10", "spine", "tier-2", "misc", "super-spine"} {
+		groups[g] = nil
+	}
+	want := []string{"super-spine", "spine", "leaf", "servers", "tier-2", "tier-10", "misc", ""}
+	if d := cmp.Diff(want, sortGroupsByTier(groups)); d != "" {
+		t.Fatalf("group order mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestGenerateGraphLayout(t *testing.T) {
+	tests := map[string]struct {
+		topo string
+		// whether the dot graph pins the nodes to their positions
+		pinned bool
+	}{
+		"positions_and_tiers": {topo: "test_data/topo12-graph.yml", pinned: true},
+		"no_layout":           {topo: "test_data/topo_fake_links.yml"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewContainerLab(WithTopoFile(tc.topo, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Dir.Lab = t.TempDir()
+			c.Dir.LabGraph = filepath.Join(c.Dir.Lab, "graph")
+			if err := c.GenerateGraph(""); err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadFile(filepath.Join(c.Dir.LabGraph, c.TopoFile.name+".dot"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dot := string(b)
+			if got := strings.Contains(dot, "neato") && strings.Contains(dot, "pos="); got != tc.pinned {
+				t.Fatalf("wanted pinned nodes %v got:\n%s", tc.pinned, dot)
+			}
+			if !tc.pinned && strings.Contains(dot, "pos=") {
+				t.Fatalf("unexpected node positions:\n%s", dot)
+			}
+		})
+	}
+}
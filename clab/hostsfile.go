@@ -1,57 +1,59 @@
 package clab
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
-	"io"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/srl-labs/containerlab/types"
-	"github.com/srl-labs/containerlab/utils"
 )
 
 const (
 	clabHostEntryPrefix  = "###### CLAB-%s-START ######"
 	clabHostEntryPostfix = "###### CLAB-%s-END ######"
 	clabHostsFilename    = "/etc/hosts"
+
+	// hostsLockTimeout is the time to wait for other containerlab processes to release the hosts file lock
+	hostsLockTimeout = 30 * time.Second
+	hostsLockPoll    = 100 * time.Millisecond
 )
 
+// AppendHostsFileEntries adds the hosts entries of the lab containers to the /etc/hosts file,
+// replacing the entries of the lab left from a previous deployment
 func AppendHostsFileEntries(containers []types.GenericContainer, labname string) error {
-	filename := clabHostsFilename
 	if labname == "" {
 		return fmt.Errorf("missing lab name")
 	}
-	if !utils.FileExists(filename) {
-		err := utils.CreateFile(filename, "127.0.0.1\tlocalhost")
-		if err != nil {
-			return err
-		}
-	}
-	// lets make sure to remove the entries of a non-properly destroyed lab in the hosts file
-	err := DeleteEntriesFromHostsFile(labname)
-	if err != nil {
-		return err
-	}
-	data := generateHostsEntries(containers, labname)
-	if len(data) == 0 {
-		return nil
-	}
-	var f *os.File
+	return appendHostsFileEntries(clabHostsFilename, generateHostsEntries(containers, labname), labname)
+}
 
-	f, err = os.OpenFile(clabHostsFilename, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
-	if err != nil {
-		return err
+// DeleteEntriesFromHostsFile removes the hosts entries of the lab from the /etc/hosts file
+func DeleteEntriesFromHostsFile(labname string) error {
+	if labname == "" {
+		return errors.New("missing containerlab name")
 	}
+	return updateHostsFile(clabHostsFilename, func(content []byte) []byte {
+		return removeHostsEntries(content, labname)
+	})
+}
 
-	defer f.Close()
-	_, err = f.Write(data)
-	if err != nil {
-		return err
-	}
-	return nil
+func appendHostsFileEntries(filename string, data []byte, labname string) error {
+	return updateHostsFile(filename, func(content []byte) []byte {
+		// lets make sure to remove the entries of a non-properly destroyed lab in the hosts file
+		content = removeHostsEntries(content, labname)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			content = append(content, '\n')
+		}
+		return append(content, data...)
+	})
 }
 
 // generateHostsEntries builds an /etc/hosts compliant text blob (as []byte]) for containers ipv4/6 address<->name pairs
@@ -81,50 +83,175 @@ func generateHostsEntries(containers []types.GenericContainer, labname string) [
 	return entries.Bytes()
 }
 
-func DeleteEntriesFromHostsFile(labname string) error {
-	if labname == "" {
-		return errors.New("missing containerlab name")
+// removeHostsEntries returns the hosts file content without the entries blocks of the lab.
+//
+// Blocks left half-written by an interrupted containerlab run are recovered:
+// a start marker without a matching end marker is removed along with the host entries following it,
+// up to the first line that is not a host entry (a comment, an empty line or another marker),
+// and a dangling end marker is removed.
+func removeHostsEntries(content []byte, labname string) []byte {
+	prefix := fmt.Sprintf(clabHostEntryPrefix, labname)
+	postfix := fmt.Sprintf(clabHostEntryPostfix, labname)
+
+	output := bytes.Buffer{}
+	inBlock := false
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		l := strings.TrimSpace(line)
+		switch {
+		case l == prefix:
+			if inBlock {
+				log.Warnf("recovering unterminated hosts entries block of the lab %s", labname)
+			}
+			inBlock = true
+		case l == postfix:
+			if !inBlock {
+				log.Warnf("removing dangling hosts entries end marker of the lab %s", labname)
+			}
+			inBlock = false
+		case inBlock && isHostsEntry(l):
+			continue
+		case inBlock:
+			// the block was not terminated, the entries written so far are dropped
+			// and the rest of the file is kept
+			log.Warnf("recovering unterminated hosts entries block of the lab %s", labname)
+			inBlock = false
+			output.WriteString(line)
+		default:
+			output.WriteString(line)
+		}
+	}
+	if inBlock {
+		log.Warnf("recovering unterminated hosts entries block of the lab %s", labname)
 	}
-	f, err := os.OpenFile(clabHostsFilename, os.O_RDWR, 0644) // skipcq: GSC-G302
+	return output.Bytes()
+}
+
+// isHostsEntry checks if the line is an address to name mapping
+func isHostsEntry(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) >= 2 && net.ParseIP(fields[0]) != nil
+}
+
+// updateHostsFile applies the update function to the content of the hosts file.
+// The update is done under an advisory lock shared by containerlab processes
+// and the result is written to a temporary file that replaces the hosts file,
+// so that concurrent updates are not lost and readers never see a partially written file.
+func updateHostsFile(filename string, update func([]byte) []byte) error {
+	unlock, err := lockHostsFile(filename)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	reader := bufio.NewReader(f)
-	skiplines := false
-	output := bytes.Buffer{}
-	prefix := fmt.Sprintf(clabHostEntryPrefix, labname)
-	postfix := fmt.Sprintf(clabHostEntryPostfix, labname)
+	defer unlock()
+
+	content, err := ioutil.ReadFile(filename)
+	exists := err == nil
+	if errors.Is(err, os.ErrNotExist) {
+		content = []byte("127.0.0.1\tlocalhost\n")
+	} else if err != nil {
+		return err
+	}
+
+	updated := update(content)
+	if exists && bytes.Equal(updated, content) {
+		return nil
+	}
+	return replaceFile(filename, updated)
+}
+
+// lockHostsFile takes an exclusive advisory lock for the hosts file updates.
+// The lock is taken on a separate file, since the hosts file itself is replaced on updates.
+func lockHostsFile(filename string) (func(), error) {
+	lockName := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".clab.lock")
+	f, err := os.OpenFile(lockName, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the lock file %s: %v", lockName, err)
+	}
+
+	deadline := time.Now().Add(hostsLockTimeout)
 	for {
-		line, err := reader.ReadString(byte('\n'))
-		if err == io.EOF {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
 			break
 		}
-		if err != nil {
-			return err
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %v", lockName, err)
 		}
-		if strings.TrimSpace(line) == postfix {
-			skiplines = false
-			continue
-		} else if strings.TrimSpace(line) == prefix || skiplines {
-			skiplines = true
-			continue
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for the lock on %s held by another containerlab process", lockName)
 		}
-		output.WriteString(line)
+		time.Sleep(hostsLockPoll)
 	}
-	if skiplines {
-		// if skiplines is not false, we did not find the end
-		// so we should not mess with /etc/hosts
-		return fmt.Errorf("issue cleaning up %s file. Please do so manually", clabHostsFilename)
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// replaceFile atomically replaces the file with the data by renaming a temporary file over it.
+// The mode and the owner of the original file are preserved.
+// Files that can't be renamed over (e.g. /etc/hosts bind mounted into a container) are rewritten in place.
+func replaceFile(filename string, data []byte) error {
+	// replace the target of a symlinked file, not the link itself
+	if p, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = p
 	}
-	err = f.Truncate(0)
+	mode := os.FileMode(0644)
+	fi, statErr := os.Stat(filename)
+	if statErr == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".clab-")
 	if err != nil {
+		return writeFileInPlace(filename, data, mode)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
 		return err
 	}
-	_, err = f.Seek(0, 0)
-	if err != nil {
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
 		return err
 	}
-	_, err = f.Write(output.Bytes())
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			if err := os.Chown(tmpName, int(st.Uid), int(st.Gid)); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = os.Rename(tmpName, filename)
+	if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+		log.Debugf("can't replace %s, rewriting it in place: %v", filename, err)
+		return writeFileInPlace(filename, data, mode)
+	}
 	return err
 }
+
+func writeFileInPlace(filename string, data []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode) // skipcq: GSC-G302
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
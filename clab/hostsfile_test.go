@@ -0,0 +1,157 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestRemoveHostsEntries(t *testing.T) {
+	tests := map[string]struct {
+		got  string
+		want string
+	}{
+		"complete_block": {
+			got: `127.0.0.1	localhost
+###### CLAB-lab1-START ######
+172.20.20.2	clab-lab1-n1
+###### CLAB-lab1-END ######
+###### CLAB-lab2-START ######
+172.20.20.3	clab-lab2-n1
+###### CLAB-lab2-END ######
+`,
+			want: `127.0.0.1	localhost
+###### CLAB-lab2-START ######
+172.20.20.3	clab-lab2-n1
+###### CLAB-lab2-END ######
+`,
+		},
+		"unterminated_block_at_eof": {
+			got: `127.0.0.1	localhost
+###### CLAB-lab1-START ######
+172.20.20.2	clab-lab1-n1
+172.20.20.3	clab-lab1-n2`,
+			want: `127.0.0.1	localhost
+`,
+		},
+		"unterminated_block_before_other_lab": {
+			got: `127.0.0.1	localhost
+###### CLAB-lab1-START ######
+172.20.20.2	clab-lab1-n1
+###### CLAB-lab2-START ######
+172.20.20.3	clab-lab2-n1
+###### CLAB-lab2-END ######
+# user comment
+`,
+			want: `127.0.0.1	localhost
+###### CLAB-lab2-START ######
+172.20.20.3	clab-lab2-n1
+###### CLAB-lab2-END ######
+# user comment
+`,
+		},
+		"dangling_end_marker": {
+			got: `127.0.0.1	localhost
+###### CLAB-lab1-END ######
+::1	localhost
+`,
+			want: `127.0.0.1	localhost
+::1	localhost
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := string(removeHostsEntries([]byte(tc.got), "lab1"))
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("hosts content mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestAppendHostsFileEntries(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	err := ioutil.WriteFile(hosts, []byte("127.0.0.1\tlocalhost\n###### CLAB-lab1-START ######\n172.20.20.9\tclab-lab1-old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers := []types.GenericContainer{
+		{
+			Names:           []string{"/clab-lab1-n1"},
+			NetworkSettings: types.GenericMgmtIPs{IPv4addr: "172.20.20.2", IPv6addr: "2001:172:20:20::2"},
+		},
+	}
+	if err := appendHostsFileEntries(hosts, generateHostsEntries(containers, "lab1"), "lab1"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `127.0.0.1	localhost
+###### CLAB-lab1-START ######
+172.20.20.2	clab-lab1-n1
+2001:172:20:20::2	clab-lab1-n1
+###### CLAB-lab1-END ######
+`
+	if d := cmp.Diff(want, string(got)); d != "" {
+		t.Errorf("hosts content mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestConcurrentHostsFileUpdates(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := ioutil.WriteFile(hosts, []byte("127.0.0.1\tlocalhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const labs = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, labs)
+	for i := 0; i < labs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lab := fmt.Sprintf("lab%d", i)
+			containers := []types.GenericContainer{
+				{
+					Names:           []string{"/clab-" + lab + "-n1"},
+					NetworkSettings: types.GenericMgmtIPs{IPv4addr: fmt.Sprintf("172.20.20.%d", i+2)},
+				},
+			}
+			errs <- appendHostsFileEntries(hosts, generateHostsEntries(containers, lab), lab)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ioutil.ReadFile(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < labs; i++ {
+		entry := fmt.Sprintf("172.20.20.%d\tclab-lab%d-n1\n", i+2, i)
+		if !strings.Contains(string(got), entry) {
+			t.Errorf("entry %q is missing from the hosts file:\n%s", entry, got)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/nodes"
+	clabRuntimes "github.com/srl-labs/containerlab/runtime"
+)
+
+// defaultMaxPulls is the default number of images pulled concurrently
+const defaultMaxPulls = 4
+
+// pullPolicyRank orders the pull policies from the least to the most demanding one
+var pullPolicyRank = map[clabRuntimes.PullPolicy]int{
+	clabRuntimes.PullPolicyNever:        0,
+	clabRuntimes.PullPolicyIfNotPresent: 1,
+	clabRuntimes.PullPolicyAlways:       2,
+}
+
+// ImageError is an error of a missing or unpullable image
+type ImageError struct {
+	// Image is empty for the nodes without an image
+	Image string
+	// Nodes are the names of the nodes using the image
+	Nodes []string
+	Err   error
+}
+
+func (e *ImageError) Error() string {
+	if e.Image == "" {
+		return fmt.Sprintf("nodes %q: %v", e.Nodes, e.Err)
+	}
+	return fmt.Sprintf("image %s used by nodes %q: %v", e.Image, e.Nodes, e.Err)
+}
+
+func (e *ImageError) Unwrap() error {
+	return e.Err
+}
+
+// ImagesError lists the errors of all the missing or unpullable images
+type ImagesError struct {
+	Errors []*ImageError
+}
+
+func (e *ImagesError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d image(s) are missing or failed to be pulled:\n\t%s", len(e.Errors), strings.Join(msgs, "\n\t"))
+}
+
+type imageKey struct {
+	image   string
+	runtime string
+}
+
+// imagePull is a pull of the image used by the nodes
+type imagePull struct {
+	imageKey
+	opts  clabRuntimes.PullOptions
+	nodes []nodes.Node
+}
+
+// imageBuild is a build of the image used by the nodes
+type imageBuild struct {
+	imageKey
+	opts   clabRuntimes.BuildOptions
+	always bool
+	nodes  []nodes.Node
+}
+
+// VerifyImages will check if image referred in the node config
+// either pullable or is available in the local image store.
+// Images are pulled concurrently according to the nodes pull policies and their resolved digests
+// are recorded in the nodes labels. The images of the nodes with a build are built unless
+// an image of the same build is present. The returned *ImagesError lists all the missing, unpullable
+// or unbuildable images
+func (c *CLab) VerifyImages(ctx context.Context) error {
+	pulls := make(map[imageKey]*imagePull)
+	builds := make(map[imageKey]*imageBuild)
+	// the build hashes keyed by the build, a build shared by the nodes is hashed once
+	hashes := make(map[string]string)
+	var noImage []string
+	var errs []*ImageError
+
+	for _, node := range c.Nodes {
+		policy, err := clabRuntimes.ParsePullPolicy(node.Config().ImagePullPolicy)
+		if err != nil {
+			return fmt.Errorf("node %q: %v", node.Config().ShortName, err)
+		}
+
+		if node.Config().Build != nil {
+			if err := c.addBuild(builds, hashes, node, policy); err != nil {
+				errs = append(errs, &ImageError{Nodes: []string{node.Config().ShortName}, Err: err})
+				continue
+			}
+		}
+
+		for _, imageName := range node.GetImages() {
+			if imageName == "" {
+				noImage = append(noImage, node.Config().ShortName)
+				continue
+			}
+			k := imageKey{image: imageName, runtime: node.GetRuntime().GetName()}
+			if _, ok := builds[k]; ok {
+				continue
+			}
+			p, ok := pulls[k]
+			if !ok {
+				p = &imagePull{imageKey: k, opts: clabRuntimes.PullOptions{Policy: policy}}
+				pulls[k] = p
+			}
+			// the image shared by the nodes is pulled with the most demanding policy
+			if pullPolicyRank[policy] > pullPolicyRank[p.opts.Policy] {
+				p.opts.Policy = policy
+			}
+			p.nodes = append(p.nodes, node)
+		}
+	}
+
+	if len(noImage) > 0 {
+		sort.Strings(noImage)
+		errs = append(errs, &ImageError{Nodes: noImage, Err: fmt.Errorf("missing required image")})
+	}
+
+	maxPulls := c.maxPulls
+	if maxPulls == 0 {
+		maxPulls = defaultMaxPulls
+	}
+	progress := newPullProgress(c.pullProgress, len(pulls)+len(builds) > 1 && maxPulls > 1)
+
+	auths := &registryAuths{topo: c.Config.Registries}
+	sem := make(chan struct{}, maxPulls)
+	var m sync.Mutex
+	wg := new(sync.WaitGroup)
+	for _, p := range pulls {
+		p.opts.Auth = auths.get(p.image)
+		p.opts.Progress = progress.writer(p.image)
+
+		wg.Add(1)
+		go func(p *imagePull) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := c.pullImage(ctx, p); err != nil {
+				names := make([]string, 0, len(p.nodes))
+				for _, n := range p.nodes {
+					names = append(names, n.Config().ShortName)
+				}
+				sort.Strings(names)
+				m.Lock()
+				errs = append(errs, &ImageError{Image: p.image, Nodes: names, Err: err})
+				m.Unlock()
+			}
+		}(p)
+	}
+	for _, b := range builds {
+		b.opts.Progress = progress.writer(b.image)
+
+		wg.Add(1)
+		go func(b *imageBuild) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := c.buildImage(ctx, b); err != nil {
+				names := make([]string, 0, len(b.nodes))
+				for _, n := range b.nodes {
+					names = append(names, n.Config().ShortName)
+				}
+				sort.Strings(names)
+				m.Lock()
+				errs = append(errs, &ImageError{Image: b.image, Nodes: names, Err: err})
+				m.Unlock()
+			}
+		}(b)
+	}
+	wg.Wait()
+	progress.flush()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Image < errs[j].Image })
+	return &ImagesError{Errors: errs}
+}
+
+// pullImage pulls the image and records its digest in the nodes using it
+func (c *CLab) pullImage(ctx context.Context, p *imagePull) error {
+	digest, err := c.Runtimes[p.runtime].PullImage(ctx, p.image, p.opts)
+	if err != nil {
+		return err
+	}
+	if digest == "" {
+		return nil
+	}
+	log.Debugf("image %s resolved to %s", p.image, digest)
+	for _, n := range p.nodes {
+		// the nodes may use the image as a kernel or a sandbox image
+		if n.Config().Image != p.image {
+			continue
+		}
+		n.Config().ImageDigest = digest
+		n.Config().Labels[ImageDigestLabel] = digest
+	}
+	return nil
+}
+
+// addBuild sets the node image to the image of its build and adds the node to the build,
+// the nodes with the same build share the image
+func (c *CLab) addBuild(builds map[imageKey]*imageBuild, hashes map[string]string, node nodes.Node, policy clabRuntimes.PullPolicy) error {
+	cfg := node.Config()
+	// fmt prints the maps sorted by key
+	key := fmt.Sprintf("%s %s %v", cfg.Build.Context, cfg.Build.Dockerfile, cfg.Build.Args)
+	hash, ok := hashes[key]
+	if !ok {
+		var err error
+		if hash, err = buildHash(cfg.Build); err != nil {
+			return err
+		}
+		hashes[key] = hash
+	}
+	cfg.Image = c.buildImageName(cfg.Build, hash)
+	k := imageKey{image: cfg.Image, runtime: node.GetRuntime().GetName()}
+	b, ok := builds[k]
+	if !ok {
+		b = &imageBuild{imageKey: k, opts: clabRuntimes.BuildOptions{
+			Context:    cfg.Build.Context,
+			Dockerfile: cfg.Build.Dockerfile,
+			Args:       cfg.Build.Args,
+			Tag:        cfg.Image,
+			Labels:     map[string]string{ContainerlabLabel: c.Config.Name, BuildHashLabel: hash},
+		}}
+		builds[k] = b
+	}
+	// the always pull policy rebuilds the image even if it is present
+	b.always = b.always || policy == clabRuntimes.PullPolicyAlways
+	b.nodes = append(b.nodes, node)
+	return nil
+}
+
+// buildImage builds the image unless it is present and its rebuild is not forced
+func (c *CLab) buildImage(ctx context.Context, b *imageBuild) error {
+	rt := c.Runtimes[b.runtime]
+	if !b.always {
+		if _, err := rt.PullImage(ctx, b.image, clabRuntimes.PullOptions{Policy: clabRuntimes.PullPolicyNever}); err == nil {
+			log.Debugf("image %s is present, skip building", b.image)
+			return nil
+		}
+	}
+	if err := rt.(clabRuntimes.ImageBuilder).BuildImage(ctx, b.opts); err != nil {
+		return fmt.Errorf("failed to build: %v", err)
+	}
+	return nil
+}
+
+// pullProgress is the progress display shared by the concurrent image pulls.
+// Concurrent pulls write their progress as whole lines prefixed with the image name,
+// while a single pull writes its progress to the output as is
+type pullProgress struct {
+	m      sync.Mutex
+	out    io.Writer
+	shared bool
+	lines  []*prefixedLineWriter
+}
+
+func newPullProgress(out io.Writer, shared bool) *pullProgress {
+	return &pullProgress{out: out, shared: shared}
+}
+
+// writer returns the progress writer of the image pull
+func (p *pullProgress) writer(image string) io.Writer {
+	if p.out == nil {
+		return nil
+	}
+	if !p.shared {
+		return p.out
+	}
+	w := &prefixedLineWriter{m: &p.m, out: p.out, prefix: image + ": "}
+	p.lines = append(p.lines, w)
+	return w
+}
+
+// flush writes the incomplete lines of the pulls
+func (p *pullProgress) flush() {
+	for _, w := range p.lines {
+		_ = w.flush()
+	}
+}
+
+// prefixedLineWriter writes the complete lines with the prefix to the output shared with other writers,
+// the writes to the shared output are serialized with the mutex
+type prefixedLineWriter struct {
+	m      *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *prefixedLineWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		line := w.buf.Next(i + 1)
+		w.m.Lock()
+		_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+		w.m.Unlock()
+		if err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// flush terminates and writes the incomplete line
+func (w *prefixedLineWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime/fake"
+)
+
+func TestVerifyImagesErrors(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	c.Nodes["n1"].Config().Image = "missing:1"
+	c.Nodes["n1"].Config().ImagePullPolicy = "never"
+	c.Nodes["n2"].Config().Image = "unpullable:1"
+	r.FailOn(fake.MethodPullImage, "unpullable:1", errors.New("manifest unknown"))
+
+	err := c.VerifyImages(context.Background())
+	var imgErr *ImagesError
+	if !errors.As(err, &imgErr) {
+		t.Fatalf("got error %v, want *ImagesError", err)
+	}
+	var got []string
+	for _, e := range imgErr.Errors {
+		got = append(got, e.Image+" "+strings.Join(e.Nodes, ","))
+	}
+	want := []string{"missing:1 n1", "unpullable:1 n2"}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected image errors (-want +got):\n%s", d)
+	}
+	// the valid image is pulled despite the failed ones
+	if d := cmp.Diff([]string{"alpine:3", "missing:1", "unpullable:1"}, r.CallArgs(fake.MethodPullImage)); d != "" {
+		t.Errorf("unexpected pulled images (-want +got):\n%s", d)
+	}
+}
+
+func TestVerifyImagesProgress(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	tests := map[string]struct {
+		images []string
+		want   []string
+	}{
+		"single_image": {
+			images: []string{"alpine:3", "alpine:3", "alpine:3"},
+			want:   []string{"Pulled sha256:"},
+		},
+		"shared_display": {
+			images: []string{"alpine:3", "debian:11", "debian:11"},
+			want:   []string{"alpine:3: Pulled sha256:", "debian:11: Pulled sha256:"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c, _ := newFakeLab(t, "test_data/topo_fake.yml")
+			c.pullProgress = &buf
+			for i, n := range []string{"n1", "n2", "n3"} {
+				c.Nodes[n].Config().Image = tc.images[i]
+			}
+			if err := c.VerifyImages(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			sort.Strings(lines)
+			if len(lines) != len(tc.want) {
+				t.Fatalf("got progress %q, want %d lines", lines, len(tc.want))
+			}
+			for i, l := range lines {
+				if !strings.HasPrefix(l, tc.want[i]) {
+					t.Errorf("got progress line %q, want prefix %q", l, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyImagesBuild(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	c, r := newFakeLab(t, "test_data/topo_fake_build.yml")
+	if err := c.VerifyImages(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	image := func(n string) string { return c.Nodes[n].Config().Image }
+	// the nodes of the kind share the built image
+	if image("n1") != image("n2") {
+		t.Errorf("nodes n1 and n2 use different images %q and %q", image("n1"), image("n2"))
+	}
+	for _, n := range []string{"n1", "n3"} {
+		if !strings.HasPrefix(image(n), "clab-fake-client:") {
+			t.Errorf("node %s: got image %q, want the clab-fake-client image", n, image(n))
+		}
+	}
+	// the build args are part of the image tag
+	if image("n1") == image("n3") {
+		t.Errorf("nodes n1 and n3 with different build args use the same image %q", image("n1"))
+	}
+	if image("n4") != "alpine:3" {
+		t.Errorf("node n4: got image %q, want alpine:3", image("n4"))
+	}
+
+	built := r.CallArgs(fake.MethodBuildImage)
+	sort.Strings(built)
+	want := []string{image("n1"), image("n3")}
+	sort.Strings(want)
+	if d := cmp.Diff(want, built); d != "" {
+		t.Errorf("unexpected built images (-want +got):\n%s", d)
+	}
+	opts, ok := r.BuildOptions(image("n3"))
+	if !ok {
+		t.Fatalf("image %q is not built", image("n3"))
+	}
+	if d := cmp.Diff(map[string]string{"VERSION": "2"}, opts.Args); d != "" {
+		t.Errorf("unexpected build args (-want +got):\n%s", d)
+	}
+	if opts.Dockerfile != "Dockerfile" || opts.Labels[ContainerlabLabel] != "fake" {
+		t.Errorf("unexpected build options: %+v", opts)
+	}
+
+	// the present images of an unchanged build are not rebuilt
+	if err := c.VerifyImages(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(r.CallArgs(fake.MethodBuildImage)); got != 2 {
+		t.Errorf("got %d builds, want the 2 builds of the first verification", got)
+	}
+}
@@ -5,23 +5,225 @@
 package clab
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"text/template"
 
+	"github.com/hairyhenderson/gomplate/v3"
+	"github.com/hairyhenderson/gomplate/v3/data"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/nodes"
 	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"gopkg.in/yaml.v2"
 )
 
+// inventoryPlatform holds the platform names of a node kind used by automation frameworks
+type inventoryPlatform struct {
+	// ansible_network_os value
+	AnsibleNetworkOS string
+	// nornir platform, which follows napalm driver names where available
+	NornirPlatform string
+}
+
+// inventoryPlatforms maps node kinds to their platform names
+var inventoryPlatforms = map[string]inventoryPlatform{
+	nodes.NodeKindSRL:     {"nokia.srlinux.srlinux", "nokia_srl"},
+	nodes.NodeKindCEOS:    {"arista.eos.eos", "eos"},
+	nodes.NodeKindVrVEOS:  {"arista.eos.eos", "eos"},
+	nodes.NodeKindVrSROS:  {"nokia.sros.md", "nokia_sros"},
+	nodes.NodeKindVrXRV:   {"cisco.iosxr.iosxr", "iosxr"},
+	nodes.NodeKindVrXRV9K: {"cisco.iosxr.iosxr", "iosxr"},
+	nodes.NodeKindVrCSR:   {"cisco.ios.ios", "ios"},
+	nodes.NodeKindVrN9KV:  {"cisco.nxos.nxos", "nxos"},
+	nodes.NodeKindVrNXOS:  {"cisco.nxos.nxos", "nxos"},
+	nodes.NodeKindVrVMX:   {"junipernetworks.junos.junos", "junos"},
+	nodes.NodeKindVrVQFX:  {"junipernetworks.junos.junos", "junos"},
+	nodes.NodeKindCRPD:    {"junipernetworks.junos.junos", "junos"},
+	nodes.NodeKindVrROS:   {"community.routeros.routeros", "mikrotik_routeros"},
+	nodes.NodeKindVrFTOSV: {"dellemc.os10.os10", "dell_os10"},
+	nodes.NodeKindVrPAN:   {"", "paloalto_panos"},
+	nodes.NodeKindLinux:   {"", "linux"},
+	nodes.NodeKindCVX:     {"", "linux"},
+	nodes.NodeKindSonic:   {"", "linux"},
+}
+
+// inventoryGenerator generates an inventory file and writes it to w
+type inventoryGenerator func(w io.Writer) error
+
+// inventoryGenerators returns inventory generators keyed by the name of the file
+// they write to in the lab directory
+func (c *CLab) inventoryGenerators() map[string]inventoryGenerator {
+	return map[string]inventoryGenerator{
+		"ansible-inventory.yml": c.generateAnsibleInventory,
+		"nornir-hosts.yml":      c.generateNornirHosts,
+		"nornir-groups.yml":     c.generateNornirGroups,
+		"ssh-config":            c.generateSSHConfig,
+		"inventory.json":        c.generateJSONInventory,
+	}
+}
+
 // GenerateInventories generate various inventory files and writes it to a lab location
 func (c *CLab) GenerateInventories() error {
-	ansibleInvFPath := filepath.Join(c.Dir.Lab, "ansible-inventory.yml")
-	f, err := os.Create(ansibleInvFPath)
+	for fname, gen := range c.inventoryGenerators() {
+		if err := writeInventory(filepath.Join(c.Dir.Lab, fname), gen); err != nil {
+			return fmt.Errorf("failed to generate inventory %s: %v", fname, err)
+		}
+	}
+	for _, inv := range c.Config.Inventories {
+		tpl, err := resolvePath(inv.Template, c.baseDir)
+		if err != nil {
+			return err
+		}
+		out := c.inventoryOutputPath(inv)
+		utils.CreateDirectory(filepath.Dir(out), 0755)
+		if err := writeInventory(out, func(w io.Writer) error {
+			return c.renderInventoryTemplate(tpl, w)
+		}); err != nil {
+			return fmt.Errorf("failed to render inventory template %s: %v", inv.Template, err)
+		}
+		log.Infof("Created inventory %s", out)
+	}
+	return nil
+}
+
+// inventoryOutputPath returns the path of a user-defined inventory,
+// relative output paths are resolved within the lab directory
+func (c *CLab) inventoryOutputPath(inv *types.InventoryConfig) string {
+	switch {
+	case filepath.IsAbs(inv.Output):
+		return inv.Output
+	case strings.HasPrefix(inv.Output, "~"):
+		if out, err := resolvePath(inv.Output, c.baseDir); err == nil {
+			return out
+		}
+	}
+	return filepath.Join(c.Dir.Lab, inv.Output)
+}
+
+// verifyInventories verifies that user-defined inventories have existing templates and
+// don't overwrite the inventories generated by containerlab
+func (c *CLab) verifyInventories() error {
+	outputs := map[string]struct{}{}
+	for fname := range c.inventoryGenerators() {
+		outputs[filepath.Join(c.Dir.Lab, fname)] = struct{}{}
+	}
+	for _, inv := range c.Config.Inventories {
+		if inv.Template == "" || inv.Output == "" {
+			return fmt.Errorf("inventory template and output must be set")
+		}
+		tpl, err := resolvePath(inv.Template, c.baseDir)
+		if err != nil {
+			return err
+		}
+		if !utils.FileExists(tpl) {
+			return fmt.Errorf("inventory template %s does not exist", inv.Template)
+		}
+		out := c.inventoryOutputPath(inv)
+		if _, ok := outputs[out]; ok {
+			return fmt.Errorf("inventory output %s is used by another inventory", inv.Output)
+		}
+		outputs[out] = struct{}{}
+	}
+	return nil
+}
+
+// inventoryTemplateData is the data user-defined inventory templates are rendered with
+type inventoryTemplateData struct {
+	// lab name
+	Name string
+	// lab directory
+	LabDir string
+	// lab nodes sorted by name
+	Nodes []*types.NodeConfig
+	// lab nodes aggregated by their kind
+	Kinds map[string][]*types.NodeConfig
+	// lab nodes aggregated by their group
+	Groups map[string][]*types.NodeConfig
+	// lab links in the order of their definition
+	Links []*types.Link
+}
+
+// renderInventoryTemplate renders a user-defined inventory template and writes it to w
+func (c *CLab) renderInventoryTemplate(path string, w io.Writer) error {
+	t, err := template.New(filepath.Base(path)).
+		Funcs(gomplate.CreateFuncs(context.Background(), new(data.Data))).
+		ParseFiles(path)
 	if err != nil {
 		return err
 	}
-	return c.generateAnsibleInventory(f)
+
+	d := inventoryTemplateData{
+		Name:   c.Config.Name,
+		LabDir: c.Dir.Lab,
+		Nodes:  c.sortedNodeConfigs(),
+		Kinds:  make(map[string][]*types.NodeConfig),
+		Groups: make(map[string][]*types.NodeConfig),
+		Links:  make([]*types.Link, 0, len(c.Links)),
+	}
+	for _, n := range d.Nodes {
+		d.Kinds[n.Kind] = append(d.Kinds[n.Kind], n)
+		if n.Group != "" {
+			d.Groups[n.Group] = append(d.Groups[n.Group], n)
+		}
+	}
+	idxs := make([]int, 0, len(c.Links))
+	for i := range c.Links {
+		idxs = append(idxs, i)
+	}
+	sort.Ints(idxs)
+	for _, i := range idxs {
+		d.Links = append(d.Links, c.Links[i])
+	}
+
+	return t.Execute(w, d)
+}
+
+func writeInventory(path string, gen inventoryGenerator) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gen(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sortedNodeConfigs returns the configs of the lab nodes sorted by the node name
+func (c *CLab) sortedNodeConfigs() []*types.NodeConfig {
+	cfgs := make([]*types.NodeConfig, 0, len(c.Nodes))
+	for _, n := range c.Nodes {
+		cfgs = append(cfgs, n.Config())
+	}
+	sort.Slice(cfgs, func(i, j int) bool {
+		return cfgs[i].ShortName < cfgs[j].ShortName
+	})
+	return cfgs
+}
+
+// kindCredentials returns the default username and password of a kind
+func kindCredentials(kind string) (user, password string) {
+	if creds := nodes.DefaultCredentials[kind]; len(creds) >= 2 {
+		return creds[0], creds[1]
+	}
+	return "", ""
+}
+
+// mgmtAddress returns the management address of a node to connect to,
+// preferring IPv4 address over IPv6
+func mgmtAddress(cfg *types.NodeConfig) string {
+	if cfg.MgmtIPv4Address != "" {
+		return cfg.MgmtIPv4Address
+	}
+	return cfg.MgmtIPv6Address
 }
 
 // generateAnsibleInventory generates and writes ansible inventory file to w
@@ -32,6 +234,12 @@ func (c *CLab) generateAnsibleInventory(w io.Writer) error {
   children:
 {{- range $kind, $nodes := .Nodes}}
     {{$kind}}:
+{{- with index $.KindVars $kind}}
+      vars:
+{{- range $k, $v := .}}
+        {{$k}}: {{$v}}
+{{- end}}
+{{- end}}
       hosts:
 {{- range $nodes}}
         {{.LongName}}:
@@ -53,11 +261,14 @@ func (c *CLab) generateAnsibleInventory(w io.Writer) error {
 		Nodes map[string][]*types.NodeConfig
 		// clab nodes aggregated by user-defined groups
 		Groups map[string][]*types.NodeConfig
+		// variables of the kind groups
+		KindVars map[string]map[string]string
 	}
 
 	i := inv{
-		Nodes:  make(map[string][]*types.NodeConfig),
-		Groups: make(map[string][]*types.NodeConfig),
+		Nodes:    make(map[string][]*types.NodeConfig),
+		Groups:   make(map[string][]*types.NodeConfig),
+		KindVars: make(map[string]map[string]string),
 	}
 
 	for _, n := range c.Nodes {
@@ -67,6 +278,20 @@ func (c *CLab) generateAnsibleInventory(w io.Writer) error {
 		}
 	}
 
+	for kind := range i.Nodes {
+		vars := map[string]string{}
+		if nos := inventoryPlatforms[kind].AnsibleNetworkOS; nos != "" {
+			vars["ansible_network_os"] = nos
+		}
+		if user, pass := kindCredentials(kind); user != "" {
+			vars["ansible_user"] = user
+			vars["ansible_password"] = pass
+		}
+		if len(vars) > 0 {
+			i.KindVars[kind] = vars
+		}
+	}
+
 	// sort nodes by name as they are not sorted originally
 	for _, nodes := range i.Nodes {
 		sort.Slice(nodes, func(i, j int) bool {
@@ -92,3 +317,128 @@ func (c *CLab) generateAnsibleInventory(w io.Writer) error {
 	return err
 
 }
+
+// nornirHost is a host of the nornir SimpleInventory
+type nornirHost struct {
+	Hostname string                 `yaml:"hostname,omitempty"`
+	Groups   []string               `yaml:"groups,omitempty"`
+	Data     map[string]interface{} `yaml:"data,omitempty"`
+}
+
+// nornirGroup is a group of the nornir SimpleInventory
+type nornirGroup struct {
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Platform string `yaml:"platform,omitempty"`
+}
+
+// generateNornirHosts generates the hosts file of the nornir SimpleInventory.
+// Hosts are members of the group named after their kind and of the user-defined group
+func (c *CLab) generateNornirHosts(w io.Writer) error {
+	hosts := map[string]nornirHost{}
+	for _, cfg := range c.sortedNodeConfigs() {
+		h := nornirHost{
+			Hostname: mgmtAddress(cfg),
+			Groups:   []string{cfg.Kind},
+			Data: map[string]interface{}{
+				"kind": cfg.Kind,
+			},
+		}
+		if cfg.Image != "" {
+			h.Data["image"] = cfg.Image
+		}
+		if cfg.Group != "" && cfg.Group != cfg.Kind {
+			h.Groups = append(h.Groups, cfg.Group)
+		}
+		if cfg.MgmtIPv6Address != "" {
+			h.Data["mgmt_ipv6"] = cfg.MgmtIPv6Address
+		}
+		hosts[cfg.LongName] = h
+	}
+	return yaml.NewEncoder(w).Encode(hosts)
+}
+
+// generateNornirGroups generates the groups file of the nornir SimpleInventory
+// with platform and credentials set per kind
+func (c *CLab) generateNornirGroups(w io.Writer) error {
+	groups := map[string]nornirGroup{}
+	for _, cfg := range c.sortedNodeConfigs() {
+		user, pass := kindCredentials(cfg.Kind)
+		groups[cfg.Kind] = nornirGroup{
+			Username: user,
+			Password: pass,
+			Platform: inventoryPlatforms[cfg.Kind].NornirPlatform,
+		}
+		if _, ok := groups[cfg.Group]; cfg.Group != "" && !ok {
+			groups[cfg.Group] = nornirGroup{}
+		}
+	}
+	return yaml.NewEncoder(w).Encode(groups)
+}
+
+// generateSSHConfig generates an ssh config snippet with a host entry per lab node
+// that can be included in ~/.ssh/config
+func (c *CLab) generateSSHConfig(w io.Writer) error {
+	fmt.Fprintf(w, "# ssh config for the containerlab lab %s\n", c.Config.Name)
+	for _, cfg := range c.sortedNodeConfigs() {
+		addr := mgmtAddress(cfg)
+		if addr == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\nHost %s\n  HostName %s\n", cfg.LongName, addr)
+		if user, _ := kindCredentials(cfg.Kind); user != "" {
+			fmt.Fprintf(w, "  User %s\n", user)
+		}
+		_, err := fmt.Fprint(w, "  StrictHostKeyChecking no\n  UserKnownHostsFile /dev/null\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonInventoryNode is a node of the JSON inventory
+type jsonInventoryNode struct {
+	Name             string            `json:"name"`
+	LongName         string            `json:"long_name"`
+	Kind             string            `json:"kind"`
+	Image            string            `json:"image,omitempty"`
+	Group            string            `json:"group,omitempty"`
+	MgmtIPv4Address  string            `json:"mgmt_ipv4,omitempty"`
+	MgmtIPv6Address  string            `json:"mgmt_ipv6,omitempty"`
+	AnsibleNetworkOS string            `json:"ansible_network_os,omitempty"`
+	Platform         string            `json:"platform,omitempty"`
+	Username         string            `json:"username,omitempty"`
+	Password         string            `json:"password,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+}
+
+// generateJSONInventory generates a JSON inventory with the lab nodes
+func (c *CLab) generateJSONInventory(w io.Writer) error {
+	inv := struct {
+		Name  string               `json:"name"`
+		Nodes []*jsonInventoryNode `json:"nodes"`
+	}{
+		Name: c.Config.Name,
+	}
+	for _, cfg := range c.sortedNodeConfigs() {
+		user, pass := kindCredentials(cfg.Kind)
+		inv.Nodes = append(inv.Nodes, &jsonInventoryNode{
+			Name:             cfg.ShortName,
+			LongName:         cfg.LongName,
+			Kind:             cfg.Kind,
+			Image:            cfg.Image,
+			Group:            cfg.Group,
+			MgmtIPv4Address:  cfg.MgmtIPv4Address,
+			MgmtIPv6Address:  cfg.MgmtIPv6Address,
+			AnsibleNetworkOS: inventoryPlatforms[cfg.Kind].AnsibleNetworkOS,
+			Platform:         inventoryPlatforms[cfg.Kind].NornirPlatform,
+			Username:         user,
+			Password:         pass,
+			Labels:           cfg.Labels,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv)
+}
@@ -5,10 +5,13 @@
 package clab
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/types"
 )
 
 func TestGenerateAnsibleInventory(t *testing.T) {
@@ -21,6 +24,10 @@ func TestGenerateAnsibleInventory(t *testing.T) {
 			want: `all:
   children:
     srl:
+      vars:
+        ansible_network_os: nokia.srlinux.srlinux
+        ansible_password: admin
+        ansible_user: admin
       hosts:
         clab-topo1-node1:
           ansible_host: 172.100.100.11
@@ -33,6 +40,10 @@ func TestGenerateAnsibleInventory(t *testing.T) {
 			want: `all:
   children:
     srl:
+      vars:
+        ansible_network_os: nokia.srlinux.srlinux
+        ansible_password: admin
+        ansible_user: admin
       hosts:
         clab-topo8_ansible_groups-node1:
           ansible_host: 172.100.100.11
@@ -76,3 +87,129 @@ func TestGenerateAnsibleInventory(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateNornirAndSSHInventories(t *testing.T) {
+	opts := []ClabOption{
+		WithTopoFile("test_data/topo1.yml", ""),
+	}
+	c, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		gen  inventoryGenerator
+		want string
+	}{
+		"nornir_hosts": {
+			gen: c.generateNornirHosts,
+			want: `clab-topo1-node1:
+  hostname: 172.100.100.11
+  groups:
+  - srl
+  data:
+    kind: srl
+clab-topo1-node2:
+  hostname: 172.100.100.12
+  groups:
+  - srl
+  data:
+    kind: srl
+`,
+		},
+		"nornir_groups": {
+			gen: c.generateNornirGroups,
+			want: `srl:
+  username: admin
+  password: admin
+  platform: nokia_srl
+`,
+		},
+		"ssh_config": {
+			gen: c.generateSSHConfig,
+			want: `# ssh config for the containerlab lab topo1
+
+Host clab-topo1-node1
+  HostName 172.100.100.11
+  User admin
+  StrictHostKeyChecking no
+  UserKnownHostsFile /dev/null
+
+Host clab-topo1-node2
+  HostName 172.100.100.12
+  User admin
+  StrictHostKeyChecking no
+  UserKnownHostsFile /dev/null
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s strings.Builder
+			if err := tc.gen(&s); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tc.want, s.String()); d != "" {
+				t.Errorf("inventory mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestRenderInventoryTemplate(t *testing.T) {
+	opts := []ClabOption{
+		WithTopoFile("test_data/topo13-inventories.yml", ""),
+	}
+	c, err := NewContainerLab(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.verifyInventories(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.inventoryOutputPath(c.Config.Inventories[0]); got != filepath.Join(c.Dir.Lab, "prometheus/targets.json") {
+		t.Fatalf("unexpected inventory output path %s", got)
+	}
+
+	var s strings.Builder
+	if err := c.renderInventoryTemplate(c.Config.Inventories[0].Template, &s); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "targets": ["172.100.100.11:9100"],
+    "labels": {"lab": "topo13", "node": "node1", "kind": "srl"}
+  },
+  {
+    "targets": ["172.100.100.12:9100"],
+    "labels": {"lab": "topo13", "node": "node2", "kind": "linux"}
+  }
+]
+`
+	if d := cmp.Diff(want, s.String()); d != "" {
+		t.Errorf("rendered inventory mismatch (-want +got):\n%s", d)
+	}
+
+	// links and the endpoint nodes are available to the templates
+	tpl := filepath.Join(t.TempDir(), "links.tmpl")
+	err = os.WriteFile(tpl, []byte(`{{ range .Links }}{{ .A.Node.ShortName }}:{{ .A.EndpointName }} {{ .B.Node.ShortName }}:{{ .B.EndpointName }}{{ end }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Reset()
+	if err := c.renderInventoryTemplate(tpl, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "node1:e1-1 node2:eth1" {
+		t.Errorf("unexpected rendered links %q", s.String())
+	}
+
+	c.Config.Inventories = append(c.Config.Inventories, &types.InventoryConfig{
+		Template: tpl,
+		Output:   "ansible-inventory.yml",
+	})
+	if err := c.verifyInventories(); err == nil {
+		t.Error("expected an error for an inventory overwriting the ansible inventory")
+	}
+}
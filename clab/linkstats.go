@@ -0,0 +1,134 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/nodes"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/vishvananda/netlink"
+)
+
+// LinkStats holds the state of both endpoints of a link
+type LinkStats struct {
+	Index int            `json:"index"`
+	A     *EndpointStats `json:"a"`
+	B     *EndpointStats `json:"b"`
+}
+
+// EndpointStats holds the state and traffic counters of a link endpoint interface
+type EndpointStats struct {
+	Node      string `json:"node"`
+	Interface string `json:"interface"`
+	// name of the interface when the endpoint resides in the host netns (bridge, ovs-bridge, host endpoints)
+	HostInterface string `json:"host_interface,omitempty"`
+	OperState     string `json:"oper_state"`
+	MTU           int    `json:"mtu,omitempty"`
+	MAC           string `json:"mac,omitempty"`
+	RxPackets     uint64 `json:"rx_packets"`
+	TxPackets     uint64 `json:"tx_packets"`
+	RxBytes       uint64 `json:"rx_bytes"`
+	TxBytes       uint64 `json:"tx_bytes"`
+	RxErrors      uint64 `json:"rx_errors"`
+	TxErrors      uint64 `json:"tx_errors"`
+	RxDropped     uint64 `json:"rx_dropped"`
+	TxDropped     uint64 `json:"tx_dropped"`
+	// error that occurred while retrieving the endpoint state
+	Error string `json:"error,omitempty"`
+}
+
+// LinkStats returns the state and counters of every link of the lab
+// retrieved from the network namespaces of the link endpoints
+func (c *CLab) LinkStats(ctx context.Context) []*LinkStats {
+	idxs := make([]int, 0, len(c.Links))
+	for i := range c.Links {
+		idxs = append(idxs, i)
+	}
+	sort.Ints(idxs)
+
+	stats := make([]*LinkStats, 0, len(idxs))
+	for _, i := range idxs {
+		l := c.Links[i]
+		stats = append(stats, &LinkStats{
+			Index: i,
+			A:     c.endpointStats(ctx, l.A),
+			B:     c.endpointStats(ctx, l.B),
+		})
+	}
+	return stats
+}
+
+// endpointStats collects the interface state for a single endpoint
+func (c *CLab) endpointStats(ctx context.Context, e *types.Endpoint) *EndpointStats {
+	s := &EndpointStats{
+		Node:      e.Node.ShortName,
+		Interface: e.EndpointName,
+		OperState: "unknown",
+	}
+
+	var nsPath string
+	switch e.Node.Kind {
+	case nodes.NodeKindHOST, nodes.NodeKindBridge, nodes.NodeKindOVS:
+		s.HostInterface = e.EndpointName
+	default:
+		n, ok := c.Nodes[e.Node.ShortName]
+		if !ok {
+			s.Error = "node not found"
+			return s
+		}
+		var err error
+		nsPath, err = n.GetRuntime().GetNSPath(ctx, n.Config().LongName)
+		if err != nil {
+			s.Error = fmt.Sprintf("failed to get netns: %v", err)
+			return s
+		}
+	}
+
+	var l netlink.Link
+	lookup := func(_ ns.NetNS) error {
+		var err error
+		l, err = netlink.LinkByName(e.EndpointName)
+		return err
+	}
+
+	var err error
+	if nsPath == "" {
+		err = lookup(nil)
+	} else {
+		var netns ns.NetNS
+		netns, err = ns.GetNS(nsPath)
+		if err == nil {
+			err = netns.Do(lookup)
+			netns.Close()
+		}
+	}
+	if err != nil {
+		log.Debugf("failed to lookup interface %s:%s: %v", e.Node.ShortName, e.EndpointName, err)
+		s.OperState = "missing"
+		s.Error = err.Error()
+		return s
+	}
+
+	attrs := l.Attrs()
+	s.OperState = attrs.OperState.String()
+	s.MTU = attrs.MTU
+	s.MAC = attrs.HardwareAddr.String()
+	if st := attrs.Statistics; st != nil {
+		s.RxPackets = st.RxPackets
+		s.TxPackets = st.TxPackets
+		s.RxBytes = st.RxBytes
+		s.TxBytes = st.TxBytes
+		s.RxErrors = st.RxErrors
+		s.TxErrors = st.TxErrors
+		s.RxDropped = st.RxDropped
+		s.TxDropped = st.TxDropped
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLinkStats(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	c, r := newFakeLab(t, "test_data/topo_fake_links.yml")
+	r.NetNS = true
+	t.Cleanup(func() { c.DeleteNodes(context.Background(), 2, nil) })
+
+	createNodes(c)
+	if len(r.ContainerNames()) != 2 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	c.CreateLinks(context.Background(), 1)
+
+	l := c.Links[0]
+	want := []*LinkStats{{
+		Index: 0,
+		A:     &EndpointStats{Node: "n1", Interface: "eth1", OperState: "up", MTU: DefaultVethLinkMTU, MAC: l.A.MAC},
+		B:     &EndpointStats{Node: "n2", Interface: "eth1", OperState: "up", MTU: DefaultVethLinkMTU, MAC: l.B.MAC},
+	}}
+	// the counters depend on the traffic the kernel sends over the fresh interfaces
+	ignoreCounters := cmpopts.IgnoreFields(EndpointStats{},
+		"RxPackets", "TxPackets", "RxBytes", "TxBytes", "RxErrors", "TxErrors", "RxDropped", "TxDropped")
+	if d := cmp.Diff(want, c.LinkStats(context.Background()), ignoreCounters); d != "" {
+		t.Errorf("unexpected link stats (-want +got):\n%s", d)
+	}
+
+	// deleting an endpoint deletes its veth peer too
+	if err := deleteEndpoint(l.A); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*EndpointStats{c.LinkStats(context.Background())[0].A, c.LinkStats(context.Background())[0].B} {
+		if s.OperState != "missing" || s.Error == "" {
+			t.Errorf("endpoint %s:%s: got state %q and error %q, want missing interface", s.Node, s.Interface, s.OperState, s.Error)
+		}
+	}
+
+	// the endpoints of a node without a container report the netns lookup error
+	if err := r.DeleteContainer(context.Background(), "clab-fake-links-n2"); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.LinkStats(context.Background())[0].B; s.OperState != "unknown" || s.Error == "" {
+		t.Errorf("got state %q and error %q, want the netns lookup error", s.OperState, s.Error)
+	}
+}
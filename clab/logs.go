@@ -0,0 +1,77 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+// NodeLogs writes the container logs of the named nodes to the stdout and stderr writers.
+// The logs of several nodes are multiplexed, with every line prefixed with the node name.
+// When following the logs, NodeLogs returns when the context is canceled
+func (c *CLab) NodeLogs(ctx context.Context, names []string, opts runtime.LogsOptions, stdout, stderr io.Writer) error {
+	streamers := make([]runtime.LogStreamer, len(names))
+	for i, name := range names {
+		n, ok := c.Nodes[name]
+		if !ok {
+			return fmt.Errorf("node %q is not found in the topology", name)
+		}
+		ls, ok := n.GetRuntime().(runtime.LogStreamer)
+		if !ok {
+			return fmt.Errorf("node %q: runtime %q doesn't support container logs", name, n.GetRuntime().GetName())
+		}
+		streamers[i] = ls
+	}
+	if len(names) == 1 {
+		return streamers[0].ContainerLogs(ctx, c.Nodes[names[0]].Config().LongName, opts, stdout, stderr)
+	}
+
+	// pad the prefixes to align the log lines of the nodes
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	failed := make([]bool, len(names))
+	for i, name := range names {
+		prefix := fmt.Sprintf("%-*s | ", width, name)
+		outW := &prefixedLineWriter{m: &m, out: stdout, prefix: prefix}
+		errW := &prefixedLineWriter{m: &m, out: stderr, prefix: prefix}
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			err := streamers[i].ContainerLogs(ctx, c.Nodes[name].Config().LongName, opts, outW, errW)
+			_ = outW.flush()
+			_ = errW.flush()
+			// the errors are logged as they happen, as the other nodes logs may be followed for long
+			if err != nil {
+				log.Errorf("failed to get the logs of node %q: %v", name, err)
+				failed[i] = true
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	var failedNames []string
+	for i, name := range names {
+		if failed[i] {
+			failedNames = append(failedNames, name)
+		}
+	}
+	if len(failedNames) > 0 {
+		return fmt.Errorf("failed to get the logs of nodes: %s", strings.Join(failedNames, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+)
+
+func sortedLines(s string) []string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestNodeLogs(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	createNodes(c)
+	for name, logs := range map[string][2]string{
+		"clab-fake-n1": {"booting\nready\n", ""},
+		"clab-fake-n2": {"started", "warning: no config\n"},
+		"clab-fake-n3": {"up\n", ""},
+	} {
+		if err := r.SetLogs(name, logs[0], logs[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("single_node", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if err := c.NodeLogs(context.Background(), []string{"n2"}, runtime.LogsOptions{}, &stdout, &stderr); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != "started" || stderr.String() != "warning: no config\n" {
+			t.Errorf("unexpected logs: stdout %q, stderr %q", stdout.String(), stderr.String())
+		}
+	})
+
+	t.Run("multiplexed", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if err := c.NodeLogs(context.Background(), c.ContainerNodes(), runtime.LogsOptions{}, &stdout, &stderr); err != nil {
+			t.Fatal(err)
+		}
+		wantOut := []string{"n1 | booting", "n1 | ready", "n2 | started", "n3 | up"}
+		if d := cmp.Diff(wantOut, sortedLines(stdout.String())); d != "" {
+			t.Errorf("unexpected stdout (-want +got):\n%s", d)
+		}
+		if d := cmp.Diff([]string{"n2 | warning: no config"}, sortedLines(stderr.String())); d != "" {
+			t.Errorf("unexpected stderr (-want +got):\n%s", d)
+		}
+	})
+
+	t.Run("failed_node", func(t *testing.T) {
+		r.FailOn(fake.MethodContainerLogs, "clab-fake-n1", errors.New("no such container"))
+		var stdout, stderr bytes.Buffer
+		err := c.NodeLogs(context.Background(), []string{"n1", "n3"}, runtime.LogsOptions{}, &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), "n1") {
+			t.Errorf("got error %v, want the failure of n1", err)
+		}
+		if got := stdout.String(); got != "n3 | up\n" {
+			t.Errorf("got stdout %q, want the logs of n3", got)
+		}
+	})
+
+	t.Run("unknown_node", func(t *testing.T) {
+		if err := c.NodeLogs(context.Background(), []string{"n4"}, runtime.LogsOptions{}, nil, nil); err == nil {
+			t.Error("expected an error for the unknown node")
+		}
+	})
+}
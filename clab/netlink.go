@@ -24,6 +24,11 @@ type vEthEndpoint struct {
 	NSPath    string // netns path
 	Bridge    string // bridge name a veth is destined to be connected to
 	OvsBridge string // ovs-bridge name a veth is destined to be connected to
+	VlanID    int    // vlan id of a sub-interface created on top of the veth
+	// bridge port vlan settings
+	Vlan  int
+	Vlans []int
+	PVID  int
 }
 
 // CreateVirtualWiring creates the virtual topology between the containers
@@ -34,15 +39,23 @@ func (c *CLab) CreateVirtualWiring(l *types.Link) (err error) {
 	// based on the link configuration contained within *Link struct
 	// veth side A
 	vA := vEthEndpoint{
-		LinkName: l.A.EndpointName,
+		LinkName: l.A.IfaceName(),
 		NSName:   l.A.Node.LongName,
 		NSPath:   l.A.Node.NSPath,
+		VlanID:   l.A.VlanID,
+		Vlan:     l.Vlan,
+		Vlans:    l.Vlans,
+		PVID:     l.PVID,
 	}
 	// veth side B
 	vB := vEthEndpoint{
-		LinkName: l.B.EndpointName,
+		LinkName: l.B.IfaceName(),
 		NSName:   l.B.Node.LongName,
 		NSPath:   l.B.Node.NSPath,
+		VlanID:   l.B.VlanID,
+		Vlan:     l.Vlan,
+		Vlans:    l.Vlans,
+		PVID:     l.PVID,
 	}
 
 	// get random names for veth sides as they will be created in root netns first
@@ -78,9 +91,9 @@ func (c *CLab) CreateVirtualWiring(l *types.Link) (err error) {
 		BRndmName = l.B.EndpointName
 	// for host connections random names shouldn't be used
 	case l.A.Node.Kind == "host":
-		ARndmName = l.A.EndpointName
+		ARndmName = l.A.IfaceName()
 	case l.B.Node.Kind == "host":
-		BRndmName = l.B.EndpointName
+		BRndmName = l.B.IfaceName()
 	}
 
 	// Generate MAC addresses
@@ -113,9 +126,14 @@ func (c *CLab) CreateVirtualWiring(l *types.Link) (err error) {
 	}
 	if err = vB.setVethLink(); err != nil {
 		_ = netlink.LinkDel(vB.Link)
+		return err
 	}
-	return err
 
+	c.emit(Event{Type: EventLinkCreated, Link: &EventLink{
+		A: l.A.Node.ShortName + ":" + l.A.EndpointName,
+		B: l.B.Node.ShortName + ":" + l.B.EndpointName,
+	}})
+	return nil
 }
 
 // createVethIface takes two veth endpoint structs and create a veth pair and return
@@ -159,7 +177,7 @@ func (veth *vEthEndpoint) setVethLink() error {
 			return fmt.Errorf("failed to set %q up: %v",
 				veth.LinkName, err)
 		}
-		return nil
+		return veth.addVlanSubIf()
 	}
 	// otherwise it needs to be put into a netns
 	return veth.toNS()
@@ -186,11 +204,39 @@ func (veth *vEthEndpoint) toNS() error {
 			return fmt.Errorf("failed to set %q up: %v",
 				veth.LinkName, err)
 		}
-		return nil
+		return veth.addVlanSubIf()
 	})
 	return err
 }
 
+// addVlanSubIf creates a vlan sub-interface named <iface>.<vlan-id> on top of the veth
+// if the endpoint was defined with a vlan id.
+// Must be called in the netns where the veth resides
+func (veth *vEthEndpoint) addVlanSubIf() error {
+	if veth.VlanID == 0 {
+		return nil
+	}
+	parent, err := netlink.LinkByName(veth.LinkName)
+	if err != nil {
+		return fmt.Errorf("failed to lookup %q: %v", veth.LinkName, err)
+	}
+	name := fmt.Sprintf("%s.%d", veth.LinkName, veth.VlanID)
+	vlan := &netlink.Vlan{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:        name,
+			ParentIndex: parent.Attrs().Index,
+		},
+		VlanId: veth.VlanID,
+	}
+	if err := netlink.LinkAdd(vlan); err != nil {
+		return fmt.Errorf("failed to create vlan sub-interface %q: %v", name, err)
+	}
+	if err := netlink.LinkSetUp(vlan); err != nil {
+		return fmt.Errorf("failed to set %q up: %v", name, err)
+	}
+	return nil
+}
+
 func (veth *vEthEndpoint) toBridge() error {
 	var vethNS ns.NetNS
 	var err error
@@ -209,6 +255,10 @@ func (veth *vEthEndpoint) toBridge() error {
 			return fmt.Errorf("failed to connect %q to bridge %v: %v", veth.LinkName, veth.Bridge, err)
 		}
 
+		if err := veth.setBridgeVlans(br); err != nil {
+			return err
+		}
+
 		if err = netlink.LinkSetUp(veth.Link); err != nil {
 			return fmt.Errorf("failed to set %q up: %v", veth.LinkName, err)
 		}
@@ -217,6 +267,42 @@ func (veth *vEthEndpoint) toBridge() error {
 	return err
 }
 
+// setBridgeVlans configures vlan membership of the bridge port represented by the veth
+func (veth *vEthEndpoint) setBridgeVlans(br *netlink.Bridge) error {
+	if veth.Vlan == 0 && len(veth.Vlans) == 0 && veth.PVID == 0 {
+		return nil
+	}
+	if br.VlanFiltering == nil || !*br.VlanFiltering {
+		log.Warnf("vlan settings are defined for %q, but vlan filtering is disabled on bridge %q", veth.LinkName, veth.Bridge)
+	}
+
+	// remove the default vlan that the bridge assigns to every new port
+	if err := netlink.BridgeVlanDel(veth.Link, 1, true, true, false, true); err != nil {
+		log.Debugf("failed to remove default vlan from %q: %v", veth.LinkName, err)
+	}
+
+	// access port
+	if veth.Vlan != 0 {
+		if err := netlink.BridgeVlanAdd(veth.Link, uint16(veth.Vlan), true, true, false, true); err != nil {
+			return fmt.Errorf("failed to set access vlan %d on %q: %v", veth.Vlan, veth.LinkName, err)
+		}
+		return nil
+	}
+
+	// trunk port
+	for _, vid := range veth.Vlans {
+		if err := netlink.BridgeVlanAdd(veth.Link, uint16(vid), false, false, false, true); err != nil {
+			return fmt.Errorf("failed to add vlan %d to %q: %v", vid, veth.LinkName, err)
+		}
+	}
+	if veth.PVID != 0 {
+		if err := netlink.BridgeVlanAdd(veth.Link, uint16(veth.PVID), true, true, false, true); err != nil {
+			return fmt.Errorf("failed to set pvid %d on %q: %v", veth.PVID, veth.LinkName, err)
+		}
+	}
+	return nil
+}
+
 // DeleteNetnsSymlinks deletes the symlink file created for each container netns
 func (c *CLab) DeleteNetnsSymlinks() (err error) {
 	for _, node := range c.Nodes {
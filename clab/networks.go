@@ -0,0 +1,145 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+)
+
+// initNetworks validates the additional networks defined in the topology and sets their defaults
+func (c *CLab) initNetworks() error {
+	names := make([]string, 0, len(c.Config.Topology.Networks))
+	for name := range c.Config.Topology.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	runtimeNames := map[string]string{c.Config.Mgmt.Network: "mgmt"}
+	for _, name := range names {
+		n := c.Config.Topology.Networks[name]
+		if n == nil {
+			n = new(types.MgmtNet)
+			c.Config.Topology.Networks[name] = n
+		}
+		if n.DNS {
+			return fmt.Errorf("network %q: dns is only served on the management network", name)
+		}
+		if n.Network == "" {
+			n.Network = fmt.Sprintf("clab-%s-%s", c.Config.Name, name)
+		}
+		if other, ok := runtimeNames[n.Network]; ok {
+			return fmt.Errorf("networks %q and %q use the same runtime network %q", other, name, n.Network)
+		}
+		runtimeNames[n.Network] = name
+		if n.MTU == "" {
+			n.MTU = c.Config.Mgmt.MTU
+		}
+	}
+	return nil
+}
+
+// nodeNetworks returns the additional networks the node attaches to
+// with the interface names defaulted to the network names
+func (c *CLab) nodeNetworks(nodeName string) ([]*types.NetworkAttachment, error) {
+	var attachments []*types.NetworkAttachment
+	nets := map[string]struct{}{}
+	ifaces := map[string]struct{}{}
+	for _, a := range c.Config.Topology.GetNodeNetworks(nodeName) {
+		n, ok := c.Config.Topology.Networks[a.Name]
+		if !ok {
+			return nil, fmt.Errorf("network %q is not defined in the topology networks", a.Name)
+		}
+		// the attachments may come from the kind or the defaults and are shared by the nodes
+		na := *a
+		na.Net = n
+		if na.Interface == "" {
+			na.Interface = na.Name
+		}
+		if _, ok := nets[na.Name]; ok {
+			return nil, fmt.Errorf("network %q is attached more than once", na.Name)
+		}
+		if _, ok := ifaces[na.Interface]; ok {
+			return nil, fmt.Errorf("interface %q is attached to more than one network", na.Interface)
+		}
+		nets[na.Name], ifaces[na.Interface] = struct{}{}, struct{}{}
+		if err := na.Validate(); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &na)
+	}
+	return attachments, nil
+}
+
+// verifyNetworkInterfaces ensures that the links don't use the interfaces attached to the additional networks
+func (c *CLab) verifyNetworkInterfaces() error {
+	for _, l := range c.Links {
+		for _, e := range []*types.Endpoint{l.A, l.B} {
+			for _, a := range e.Node.Networks {
+				if a.Interface == e.EndpointName {
+					return fmt.Errorf("endpoint %s:%s uses the interface attached to network %q",
+						e.Node.ShortName, e.EndpointName, a.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// networkRuntimes returns the runtimes of the nodes attached to the additional networks
+// keyed by the network name
+func (c *CLab) networkRuntimes() map[string][]runtime.ExtraNetworker {
+	rts := map[string][]runtime.ExtraNetworker{}
+	seen := map[string]map[string]struct{}{}
+	for _, n := range c.Nodes {
+		rt, ok := n.GetRuntime().(runtime.ExtraNetworker)
+		if !ok {
+			continue
+		}
+		for _, a := range n.Config().Networks {
+			if seen[a.Name] == nil {
+				seen[a.Name] = map[string]struct{}{}
+			}
+			if _, ok := seen[a.Name][n.GetRuntime().GetName()]; ok {
+				continue
+			}
+			seen[a.Name][n.GetRuntime().GetName()] = struct{}{}
+			rts[a.Name] = append(rts[a.Name], rt)
+		}
+	}
+	return rts
+}
+
+// CreateNetworks creates the additional networks the lab nodes attach to
+func (c *CLab) CreateNetworks(ctx context.Context) error {
+	for name, rts := range c.networkRuntimes() {
+		n := c.Config.Topology.Networks[name]
+		log.Infof("Creating network %q: Name='%s', IPv4Subnet='%s', IPv6Subnet='%s', MTU='%s'",
+			name, n.Network, n.IPv4Subnet, n.IPv6Subnet, n.MTU)
+		for _, rt := range rts {
+			if err := rt.CreateExtraNet(ctx, n); err != nil {
+				return fmt.Errorf("failed to create network %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteNetworks deletes the additional networks the lab nodes were attached to,
+// the networks still in use are kept
+func (c *CLab) DeleteNetworks(ctx context.Context) {
+	for name, rts := range c.networkRuntimes() {
+		for _, rt := range rts {
+			if err := rt.DeleteExtraNet(ctx, c.Config.Topology.Networks[name]); err != nil {
+				log.Errorf("failed to delete network %q: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/types"
+)
+
+const (
+	dockerHubRegistry = "docker.io"
+	// dockerHubAuthKey is the key of the Docker Hub credentials in the docker config file
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+// imageRegistry returns the registry host of the image, images without a registry host come from Docker Hub
+func imageRegistry(image string) string {
+	i := strings.IndexByte(image, '/')
+	if i < 0 {
+		return dockerHubRegistry
+	}
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return dockerHubRegistry
+	}
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// registryAuths resolves the registries credentials defined in the topology
+// or stored in the docker config file by `docker login`
+type registryAuths struct {
+	topo map[string]*types.RegistryAuth
+	// docker config file, loaded on the first lookup
+	dockerCfg *configfile.ConfigFile
+	loaded    bool
+}
+
+// get returns the credentials of the image registry, nil if the registry has no credentials.
+// Credentials that can't be read from the docker config file are skipped with a warning
+func (r *registryAuths) get(image string) *types.RegistryAuth {
+	host := imageRegistry(image)
+	if auth, ok := r.topo[host]; ok {
+		return auth
+	}
+
+	if !r.loaded {
+		r.loaded = true
+		cf, err := dockerconfig.Load(dockerConfigDir())
+		if err != nil {
+			log.Warnf("failed to load docker config file: %v", err)
+		} else {
+			r.dockerCfg = cf
+		}
+	}
+	if r.dockerCfg == nil {
+		return nil
+	}
+
+	key := host
+	if host == dockerHubRegistry {
+		key = dockerHubAuthKey
+	}
+	ac, err := r.dockerCfg.GetAuthConfig(key)
+	if err != nil {
+		log.Warnf("failed to get %s registry credentials from docker config: %v", host, err)
+		return nil
+	}
+	if ac.Username == "" && ac.Password == "" && ac.IdentityToken == "" {
+		return nil
+	}
+	return &types.RegistryAuth{
+		Username:      ac.Username,
+		Password:      ac.Password,
+		IdentityToken: ac.IdentityToken,
+	}
+}
+
+// dockerConfigDir returns the docker config directory.
+// When containerlab runs with sudo, the config directory of the invoking user is used,
+// so that the credentials of `docker login` made without sudo are found
+func dockerConfigDir() string {
+	if d := os.Getenv("DOCKER_CONFIG"); d != "" {
+		return d
+	}
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		if u, err := user.Lookup(name); err == nil {
+			return filepath.Join(u.HomeDir, ".docker")
+		}
+	}
+	// default docker config directory
+	return ""
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := map[string]string{
+		"alpine":                          "docker.io",
+		"library/alpine:3":                "docker.io",
+		"index.docker.io/library/alpine":  "docker.io",
+		"ghcr.io/nokia/srlinux:21.6":      "ghcr.io",
+		"registry.local:5000/nos/srl:dev": "registry.local:5000",
+		"localhost/srl":                   "localhost",
+	}
+	for image, want := range tests {
+		if got := imageRegistry(image); got != want {
+			t.Errorf("%s: got registry %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestRegistryAuths(t *testing.T) {
+	dir := t.TempDir()
+	cfg := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass")) + `"},
+		"registry.local": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("user:pass")) + `"}
+	}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	r := &registryAuths{topo: map[string]*types.RegistryAuth{
+		"registry.local": {Username: "topo", Password: "secret"},
+		"ghcr.io":        {IdentityToken: "token"},
+	}}
+	tests := map[string]*types.RegistryAuth{
+		"alpine":                      {Username: "hubuser", Password: "hubpass"},
+		"registry.local/nos/srl":      {Username: "topo", Password: "secret"},
+		"ghcr.io/nokia/srlinux":       {IdentityToken: "token"},
+		"quay.io/frrouting/frr:8.1.0": nil,
+	}
+	for image, want := range tests {
+		if d := cmp.Diff(want, r.get(image)); d != "" {
+			t.Errorf("%s: unexpected credentials (-want +got):\n%s", image, d)
+		}
+	}
+}
+
+func TestVerifyImages(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	tests := map[string]struct {
+		policies   map[string]string // node -> pull policy
+		present    bool
+		wantPolicy runtime.PullPolicy
+		wantErr    bool
+	}{
+		"default_policy": {
+			present:    true,
+			wantPolicy: runtime.PullPolicyIfNotPresent,
+		},
+		"most_demanding_policy": {
+			policies:   map[string]string{"n1": "never", "n2": "always"},
+			present:    true,
+			wantPolicy: runtime.PullPolicyAlways,
+		},
+		"never_missing": {
+			policies: map[string]string{"n1": "never", "n2": "never", "n3": "never"},
+			wantErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, r := newFakeLab(t, "test_data/topo_fake.yml")
+			c.Config.Registries = map[string]*types.RegistryAuth{"docker.io": {Username: "user", Password: "pass"}}
+			for n, p := range tc.policies {
+				c.Nodes[n].Config().ImagePullPolicy = p
+			}
+			if tc.present {
+				r.AddImage("alpine:3", "sha256:abc")
+			}
+
+			err := c.VerifyImages(context.Background())
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the image shared by the nodes is pulled once
+			if d := cmp.Diff([]string{"alpine:3"}, r.CallArgs(fake.MethodPullImage)); d != "" {
+				t.Errorf("unexpected pulled images (-want +got):\n%s", d)
+			}
+			opts, _ := r.PullOptions("alpine:3")
+			if opts.Policy != tc.wantPolicy {
+				t.Errorf("got pull policy %q, want %q", opts.Policy, tc.wantPolicy)
+			}
+			if d := cmp.Diff(&types.RegistryAuth{Username: "user", Password: "pass"}, opts.Auth); d != "" {
+				t.Errorf("unexpected registry credentials (-want +got):\n%s", d)
+			}
+			for _, n := range c.Nodes {
+				if n.Config().ImageDigest == "" || n.Config().Labels[ImageDigestLabel] != n.Config().ImageDigest {
+					t.Errorf("node %s: image digest is not recorded: %q", n.Config().ShortName, n.Config().ImageDigest)
+				}
+			}
+		})
+	}
+}
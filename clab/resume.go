@@ -0,0 +1,204 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/nodes"
+	clabRuntimes "github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"github.com/vishvananda/netlink"
+)
+
+// ResumeOptions are the options of a lab resumption
+type ResumeOptions struct {
+	// Executable is the path to the containerlab binary running the lab DNS server,
+	// defaults to the current executable
+	Executable string
+}
+
+// ResumeResult is the result of a lab resumption
+type ResumeResult struct {
+	// Started are the names of the nodes which stopped containers were started
+	Started []string
+	// Links are the re-created links
+	Links []*EventLink
+	// Errors are the errors of the nodes and links which couldn't be resumed,
+	// the links of the failed nodes are not re-created
+	Errors []error
+}
+
+// Resume brings a deployed lab back to life after a host reboot or a restart of its containers.
+// The stopped containers are started, the netns symlinks, the linux and ovs bridges managed by containerlab
+// and the missing links are re-created and the lab DNS server is restarted.
+// The existing links are left untouched
+func (c *CLab) Resume(ctx context.Context, o ResumeOptions) (*ResumeResult, error) {
+	if err := c.subscribeWebhooks(); err != nil {
+		return nil, err
+	}
+
+	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
+	containers, err := c.ListContainers(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("lab %q has no containers, deploy it first", c.Config.Name)
+	}
+	nodeContainers := make(map[string]types.GenericContainer, len(containers))
+	for _, cont := range containers {
+		nodeContainers[cont.Labels[NodeNameLabel]] = cont
+	}
+
+	log.Infof("Resuming lab: %s", c.Config.Name)
+	res := &ResumeResult{}
+	// the nodes sharing a netns are resumed after the node owning it
+	names := c.ContainerNodes()
+	sort.SliceStable(names, func(i, j int) bool { return c.netnsDepth(names[i]) < c.netnsDepth(names[j]) })
+	for _, name := range names {
+		started, err := c.resumeNode(ctx, c.Nodes[name], nodeContainers)
+		if err != nil {
+			log.Errorf("failed to resume node %q: %v", name, err)
+			res.Errors = append(res.Errors, &NodeError{Node: name, Err: err})
+			continue
+		}
+		if started {
+			res.Started = append(res.Started, name)
+		}
+	}
+
+	for _, n := range c.Nodes {
+		kind := n.Config().Kind
+		if (kind != nodes.NodeKindBridge && kind != nodes.NodeKindOVS) || n.Config().DeploymentStatus == "created" {
+			continue
+		}
+		// the bridges created by containerlab are gone after a reboot,
+		// the missing bridges which are not managed by containerlab fail the creation of their links
+		if _, err := netlink.LinkByName(n.Config().ShortName); err != nil {
+			if err := n.Deploy(ctx); err != nil {
+				res.Errors = append(res.Errors, &NodeError{Node: n.Config().ShortName, Err: err})
+				continue
+			}
+		}
+		n.Config().DeploymentStatus = "created"
+	}
+
+	for _, l := range c.sortedLinks() {
+		if l.A.Node.DeploymentStatus != "created" || l.B.Node.DeploymentStatus != "created" {
+			continue
+		}
+		a, b := endpointExists(l.A), endpointExists(l.B)
+		if a && b {
+			continue
+		}
+		if a || b {
+			err := fmt.Errorf("link %s:%s <--> %s:%s is partially present, delete its remaining interface to re-create it",
+				l.A.Node.ShortName, l.A.EndpointName, l.B.Node.ShortName, l.B.EndpointName)
+			log.Error(err)
+			res.Errors = append(res.Errors, err)
+			continue
+		}
+		if err := c.CreateVirtualWiring(l); err != nil {
+			log.Error(err)
+			res.Errors = append(res.Errors, err)
+			continue
+		}
+		res.Links = append(res.Links, &EventLink{
+			A: l.A.Node.ShortName + ":" + l.A.EndpointName,
+			B: l.B.Node.ShortName + ":" + l.B.EndpointName,
+		})
+	}
+
+	if c.Config.Mgmt.DNS {
+		if _, err := c.StartDNS(o.Executable); err != nil {
+			log.Errorf("failed to start the lab DNS server: %v", err)
+			res.Errors = append(res.Errors, err)
+		}
+	}
+
+	c.emit(Event{Type: EventLabResumed})
+	return res, nil
+}
+
+// resumeNode starts the stopped container of the node and re-creates its netns symlink.
+// It returns true if the container was started
+func (c *CLab) resumeNode(ctx context.Context, n nodes.Node, containers map[string]types.GenericContainer) (bool, error) {
+	cfg := n.Config()
+	cont, ok := containers[cfg.ShortName]
+	if !ok {
+		return false, errors.New("container is not found")
+	}
+	var started bool
+	if cont.State != "running" {
+		log.Infof("Starting container: %s", cfg.LongName)
+		if err := n.GetRuntime().StartContainer(ctx, cfg.LongName); err != nil {
+			return false, fmt.Errorf("failed to start container: %v", err)
+		}
+		started = true
+	}
+	nsPath, err := n.GetRuntime().GetNSPath(ctx, cfg.LongName)
+	if err != nil {
+		return started, err
+	}
+	if err := utils.LinkContainerNS(nsPath, cfg.LongName); err != nil {
+		return started, err
+	}
+	c.m.Lock()
+	cfg.NSPath = nsPath
+	c.m.Unlock()
+	// the restarted containers get the interfaces of the additional networks with the default names
+	if rt, ok := n.GetRuntime().(clabRuntimes.ExtraNetRenamer); ok && len(cfg.Networks) > 0 {
+		if err := rt.RenameExtraNetIfaces(ctx, cfg); err != nil {
+			return started, fmt.Errorf("failed to rename the interfaces of the additional networks: %v", err)
+		}
+	}
+	c.m.Lock()
+	cfg.DeploymentStatus = "created"
+	c.m.Unlock()
+	return started, nil
+}
+
+// sortedLinks returns the links in the order of the topology file
+func (c *CLab) sortedLinks() []*types.Link {
+	idx := make([]int, 0, len(c.Links))
+	for i := range c.Links {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	links := make([]*types.Link, 0, len(idx))
+	for _, i := range idx {
+		links = append(links, c.Links[i])
+	}
+	return links
+}
+
+// endpointExists returns true if the interface of the link endpoint exists
+func endpointExists(e *types.Endpoint) bool {
+	switch e.Node.Kind {
+	// the interfaces of these nodes are in the host netns
+	case nodes.NodeKindHOST, nodes.NodeKindBridge, nodes.NodeKindOVS:
+		_, err := netlink.LinkByName(e.IfaceName())
+		return err == nil
+	}
+	netns, err := ns.GetNS(e.Node.NSPath)
+	if err != nil {
+		return false
+	}
+	defer netns.Close()
+	var exists bool
+	_ = netns.Do(func(_ ns.NetNS) error {
+		_, err := netlink.LinkByName(e.IfaceName())
+		exists = err == nil
+		return nil
+	})
+	return exists
+}
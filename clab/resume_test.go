@@ -0,0 +1,217 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime/fake"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"github.com/vishvananda/netlink"
+)
+
+func TestResume(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	c, r := newFakeLab(t, "test_data/topo_fake_links.yml")
+	r.NetNS = true
+	t.Cleanup(func() {
+		c.DeleteNodes(context.Background(), 2, nil)
+		_ = c.DeleteNetnsSymlinks()
+	})
+
+	createNodes(c)
+	if len(r.ContainerNames()) != 2 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	c.CreateLinks(context.Background(), 1)
+
+	t.Run("links_present", func(t *testing.T) {
+		res, err := c.Resume(context.Background(), ResumeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Started) != 0 || len(res.Links) != 0 || len(res.Errors) != 0 {
+			t.Errorf("unexpected resume result: %+v", res)
+		}
+	})
+
+	t.Run("stopped_container", func(t *testing.T) {
+		// deleting a veth end deletes its peer, as the restart of a container does
+		l := c.Links[0]
+		if err := utils.DeleteNetnsSymlink(l.A.Node.LongName); err != nil {
+			t.Fatal(err)
+		}
+		if err := deleteEndpoint(l.A); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.StopContainer(context.Background(), "clab-fake-links-n1"); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := c.Resume(context.Background(), ResumeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &ResumeResult{
+			Started: []string{"n1"},
+			Links:   []*EventLink{{A: "n1:eth1", B: "n2:eth1"}},
+		}
+		if d := cmp.Diff(want, res); d != "" {
+			t.Errorf("unexpected resume result (-want +got):\n%s", d)
+		}
+		if !endpointExists(l.A) || !endpointExists(l.B) {
+			t.Error("link is not re-created")
+		}
+		if _, err := os.Lstat("/run/netns/" + l.A.Node.LongName); err != nil {
+			t.Errorf("netns symlink is not re-created: %v", err)
+		}
+	})
+}
+
+func TestResumeNetworks(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	c, r := newFakeLab(t, "test_data/topo_fake_networks.yml")
+	r.NetNS = true
+	t.Cleanup(func() {
+		c.DeleteNodes(context.Background(), 2, nil)
+		_ = c.DeleteNetnsSymlinks()
+	})
+
+	if err := c.CreateNetworks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	createNodes(c)
+	if len(r.ContainerNames()) != 2 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	if err := r.StopContainer(context.Background(), "clab-fake-n2"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Resume(context.Background(), ResumeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(&ResumeResult{Started: []string{"n2"}}, res); d != "" {
+		t.Errorf("unexpected resume result (-want +got):\n%s", d)
+	}
+	// the interfaces are renamed after the netns of the started container is looked up
+	var calls []string
+	for _, call := range r.Calls() {
+		if call.Arg == "clab-fake-n2" && call.Method != fake.MethodStopContainer {
+			calls = append(calls, call.Method)
+		}
+	}
+	wantCalls := []string{
+		fake.MethodCreateContainer,
+		fake.MethodStartContainer,
+		fake.MethodGetNSPath,
+		fake.MethodRenameExtraNetIfaces,
+	}
+	if d := cmp.Diff(wantCalls, calls); d != "" {
+		t.Errorf("unexpected calls of the node (-want +got):\n%s", d)
+	}
+	if d := cmp.Diff([]string{"clab-fake-n1", "clab-fake-n2"}, r.CallArgs(fake.MethodRenameExtraNetIfaces)); d != "" {
+		t.Errorf("unexpected renamed containers (-want +got):\n%s", d)
+	}
+
+	r.FailOn(fake.MethodRenameExtraNetIfaces, "clab-fake-n2", errors.New("interface is not found"))
+	res, err = c.Resume(context.Background(), ResumeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nodeErr *NodeError
+	if len(res.Errors) != 1 || !errors.As(res.Errors[0], &nodeErr) || nodeErr.Node != "n2" {
+		t.Errorf("unexpected resume errors: %v", res.Errors)
+	}
+}
+
+func TestResumeOVS(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	// ovs-vsctl is simulated, a veth interface stands for the existing ovs bridge
+	bin := t.TempDir()
+	for name, script := range map[string]string{
+		"sudo":      "#!/bin/sh\nexec \"$@\"\n",
+		"ovs-vsctl": "#!/bin/sh\nexit 0\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	br := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "ovs-br1"}, PeerName: "ovs-br1-peer"}
+	if err := netlink.LinkAdd(br); err != nil {
+		t.Skipf("failed to create the ovs bridge interface: %v", err)
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(br) })
+
+	c, r := newFakeLab(t, "test_data/topo_fake_ovs.yml")
+	r.NetNS = true
+	t.Cleanup(func() {
+		c.DeleteNodes(context.Background(), 2, nil)
+		_ = c.DeleteNetnsSymlinks()
+	})
+
+	createNodes(c)
+	if len(r.ContainerNames()) != 1 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	c.CreateLinks(context.Background(), 1)
+	l := c.Links[0]
+	if !endpointExists(l.A) || !endpointExists(l.B) {
+		t.Fatal("link is not created")
+	}
+
+	// the link is lost with the container restart and resumed by a new containerlab process
+	if err := deleteEndpoint(l.A); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range c.Nodes {
+		n.Config().DeploymentStatus = ""
+	}
+	res, err := c.Resume(context.Background(), ResumeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ResumeResult{
+		Links: []*EventLink{{A: "n1:eth1", B: "ovs-br1:ovs-n1-eth1"}},
+	}
+	if d := cmp.Diff(want, res); d != "" {
+		t.Errorf("unexpected resume result (-want +got):\n%s", d)
+	}
+	if !endpointExists(l.A) || !endpointExists(l.B) {
+		t.Error("link is not re-created")
+	}
+}
+
+// deleteEndpoint deletes the interface of the link endpoint in the node netns
+func deleteEndpoint(e *types.Endpoint) error {
+	netns, err := ns.GetNS(e.Node.NSPath)
+	if err != nil {
+		return err
+	}
+	defer netns.Close()
+	return netns.Do(func(_ ns.NetNS) error {
+		l, err := netlink.LinkByName(e.IfaceName())
+		if err != nil {
+			return err
+		}
+		return netlink.LinkDel(l)
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+// NodeStats is the resource usage of a lab node next to the limits set in the topology
+type NodeStats struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// CPUPercent is the CPU usage since the previous collection, 100% is a single fully used CPU
+	CPUPercent float64 `json:"cpu_percent"`
+	// CPULimit is the number of CPUs the node is limited to, zero if unlimited
+	CPULimit    float64 `json:"cpu_limit,omitempty"`
+	MemoryUsage uint64  `json:"memory_usage"`
+	// MemoryLimit is the memory limit of the node in bytes, zero if unlimited
+	MemoryLimit uint64 `json:"memory_limit,omitempty"`
+	NetRxBytes  uint64 `json:"net_rx_bytes"`
+	NetTxBytes  uint64 `json:"net_tx_bytes"`
+	PIDs        uint64 `json:"pids"`
+	// Error is set when the node usage can't be collected
+	Error string `json:"error,omitempty"`
+}
+
+// HostStats summarizes the container host resources and the share of them used by the lab
+type HostStats struct {
+	VCPUs       int    `json:"vcpus"`
+	TotalMemory uint64 `json:"total_memory"`
+	FreeMemory  uint64 `json:"free_memory"`
+	// lab nodes usage totals
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryUsage uint64  `json:"memory_usage"`
+}
+
+// LabStats is the resource usage of the lab nodes and the host
+type LabStats struct {
+	Host  HostStats    `json:"host"`
+	Nodes []*NodeStats `json:"nodes"`
+}
+
+// StatsCollector collects the resource usage of the lab nodes.
+// The CPU usage is computed between successive collections, so it is zero on the first one
+type StatsCollector struct {
+	c    *CLab
+	m    sync.Mutex
+	prev map[string]*runtime.ContainerStats
+}
+
+// NewStatsCollector returns the resource usage collector of the lab nodes
+func (c *CLab) NewStatsCollector() *StatsCollector {
+	return &StatsCollector{c: c, prev: map[string]*runtime.ContainerStats{}}
+}
+
+// Collect returns the current resource usage of the lab nodes sorted by name
+func (s *StatsCollector) Collect(ctx context.Context) *LabStats {
+	names := s.c.ContainerNodes()
+	nodeStats := make([]*NodeStats, len(names))
+	var wg sync.WaitGroup
+	wg.Add(len(names))
+	for i, name := range names {
+		go func(i int, name string) {
+			defer wg.Done()
+			nodeStats[i] = s.nodeStats(ctx, name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	vcpu, totalMem, freeMem := hostResources()
+	ls := &LabStats{
+		Host:  HostStats{VCPUs: vcpu, TotalMemory: totalMem, FreeMemory: freeMem},
+		Nodes: nodeStats,
+	}
+	for _, ns := range nodeStats {
+		ls.Host.CPUPercent += ns.CPUPercent
+		ls.Host.MemoryUsage += ns.MemoryUsage
+	}
+	return ls
+}
+
+func (s *StatsCollector) nodeStats(ctx context.Context, name string) *NodeStats {
+	n := s.c.Nodes[name]
+	cfg := n.Config()
+	ns := &NodeStats{Name: name, Kind: cfg.Kind, CPULimit: cfg.CPU}
+	if cfg.Memory != "" {
+		// the limit is validated by the runtimes on deploy
+		ns.MemoryLimit, _ = humanize.ParseBytes(cfg.Memory)
+	}
+	sp, ok := n.GetRuntime().(runtime.StatsProvider)
+	if !ok {
+		ns.Error = fmt.Sprintf("runtime %q doesn't support container stats", n.GetRuntime().GetName())
+		return ns
+	}
+	cur, err := sp.ContainerStats(ctx, cfg.LongName)
+	if err != nil {
+		ns.Error = err.Error()
+		return ns
+	}
+	ns.MemoryUsage = cur.MemoryUsage
+	ns.NetRxBytes = cur.NetRxBytes
+	ns.NetTxBytes = cur.NetTxBytes
+	ns.PIDs = cur.PIDs
+
+	s.m.Lock()
+	prev := s.prev[name]
+	s.prev[name] = cur
+	s.m.Unlock()
+	ns.CPUPercent = cpuPercent(prev, cur)
+	return ns
+}
+
+// cpuPercent returns the CPU usage between two snapshots of a container,
+// the usage is zero without the previous snapshot or if the container was restarted in between
+func cpuPercent(prev, cur *runtime.ContainerStats) float64 {
+	if prev == nil || cur.CPUUsage < prev.CPUUsage {
+		return 0
+	}
+	elapsed := cur.Time.Sub(prev.Time)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(cur.CPUUsage-prev.CPUUsage) / float64(elapsed) * 100
+}
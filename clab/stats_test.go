@@ -0,0 +1,67 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package clab
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+)
+
+func TestStatsCollector(t *testing.T) {
+	c, r := newFakeLab(t, "test_data/topo_fake.yml")
+	createNodes(c)
+	r.FailOn(fake.MethodContainerStats, "clab-fake-n3", errors.New("no such container"))
+
+	t0 := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	setStats := func(name string, s runtime.ContainerStats) {
+		t.Helper()
+		if err := r.SetStats(name, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setStats("clab-fake-n1", runtime.ContainerStats{Time: t0, CPUUsage: time.Second, MemoryUsage: 100 << 20})
+	setStats("clab-fake-n2", runtime.ContainerStats{Time: t0, CPUUsage: 5 * time.Second, MemoryUsage: 50 << 20})
+
+	collector := c.NewStatsCollector()
+	first := collector.Collect(context.Background())
+	for _, ns := range first.Nodes {
+		if ns.CPUPercent != 0 {
+			t.Errorf("node %s: got CPU usage %.1f%% on the first collection, want 0", ns.Name, ns.CPUPercent)
+		}
+	}
+
+	// n1 uses 3 CPUs over 2 seconds, n2 is idle
+	setStats("clab-fake-n1", runtime.ContainerStats{
+		Time: t0.Add(2 * time.Second), CPUUsage: 7 * time.Second, MemoryUsage: 200 << 20,
+		NetRxBytes: 1000, NetTxBytes: 2000, PIDs: 4,
+	})
+	setStats("clab-fake-n2", runtime.ContainerStats{Time: t0.Add(2 * time.Second), CPUUsage: 5 * time.Second, MemoryUsage: 50 << 20, PIDs: 1})
+
+	got := collector.Collect(context.Background())
+	want := []*NodeStats{
+		{
+			Name: "n1", Kind: "linux", CPUPercent: 300, CPULimit: 1.5,
+			MemoryUsage: 200 << 20, MemoryLimit: 512 << 20, NetRxBytes: 1000, NetTxBytes: 2000, PIDs: 4,
+		},
+		{Name: "n2", Kind: "linux", MemoryUsage: 50 << 20, PIDs: 1},
+		{Name: "n3", Kind: "linux", Error: "no such container"},
+	}
+	if d := cmp.Diff(want, got.Nodes); d != "" {
+		t.Errorf("unexpected node stats (-want +got):\n%s", d)
+	}
+	if got.Host.CPUPercent != 300 || got.Host.MemoryUsage != 250<<20 {
+		t.Errorf("got lab totals of %.1f%% CPU and %d bytes of memory, want 300%% and %d bytes",
+			got.Host.CPUPercent, got.Host.MemoryUsage, 250<<20)
+	}
+	if got.Host.VCPUs == 0 || got.Host.TotalMemory == 0 {
+		t.Errorf("host resources are not set: %+v", got.Host)
+	}
+}
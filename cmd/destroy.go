@@ -96,10 +96,15 @@ var destroyCmd = &cobra.Command{
 		}
 
 		var errs []error
-		for _, clab := range labs {
-			err = destroyLab(ctx, clab)
+		for _, lab := range labs {
+			err = lab.Destroy(ctx, clab.DestroyOptions{
+				Cleanup:     cleanup,
+				MaxWorkers:  maxWorkers,
+				KeepMgmtNet: keepMgmtNet,
+			})
+			lab.Events.Close()
 			if err != nil {
-				log.Errorf("Error occurred during the %s lab deletion %v", clab.Config.Name, err)
+				log.Errorf("Error occurred during the %s lab deletion %v", lab.Config.Name, err)
 				errs = append(errs, err)
 			}
 		}
@@ -118,73 +123,3 @@ func init() {
 	destroyCmd.Flags().UintVarP(&maxWorkers, "max-workers", "", 0, "limit the maximum number of workers deleting nodes")
 	destroyCmd.Flags().BoolVarP(&keepMgmtNet, "keep-mgmt-net", "", false, "do not remove the management network")
 }
-
-func destroyLab(ctx context.Context, c *clab.CLab) (err error) {
-
-	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
-	containers, err := c.ListContainers(ctx, labels)
-	if err != nil {
-		return err
-	}
-	if len(containers) == 0 {
-		return nil
-	}
-
-	var labDir string
-	if cleanup {
-		labDir = filepath.Dir(containers[0].Labels["clab-node-lab-dir"])
-	}
-
-	if maxWorkers == 0 {
-		maxWorkers = uint(len(c.Nodes))
-	}
-
-	// a set of workers that do not support concurrency
-	serialNodes := make(map[string]struct{})
-	for _, n := range c.Nodes {
-		if n.GetRuntime().GetName() == runtime.IgniteRuntime {
-			serialNodes[n.Config().LongName] = struct{}{}
-			// decreasing the num of maxWorkers as they are used for concurrent nodes
-			maxWorkers = maxWorkers - 1
-		}
-	}
-
-	// Serializing ignite workers due to busy device error
-	if _, ok := c.Runtimes[runtime.IgniteRuntime]; ok {
-		maxWorkers = 1
-	}
-
-	log.Infof("Destroying lab: %s", c.Config.Name)
-	c.DeleteNodes(ctx, maxWorkers, serialNodes)
-
-	// remove the lab directories
-	if cleanup {
-		err = os.RemoveAll(labDir)
-		if err != nil {
-			log.Errorf("error deleting lab directory: %v", err)
-		}
-	}
-
-	log.Info("Removing containerlab host entries from /etc/hosts file")
-	err = clab.DeleteEntriesFromHostsFile(c.Config.Name)
-	if err != nil {
-		return fmt.Errorf("error while trying to clean up the hosts file: %w", err)
-	}
-
-	// delete lab management network
-	if c.Config.Mgmt.Network != "bridge" && !keepMgmtNet {
-		log.Debugf("Calling DeleteNet method. *CLab.Config.Mgmt value is: %+v", c.Config.Mgmt)
-		if err = c.GlobalRuntime().DeleteNet(ctx); err != nil {
-			// do not log error message if deletion error simply says that such network doesn't exist
-			if err.Error() != fmt.Sprintf("Error: No such network: %s", c.Config.Mgmt.Network) {
-				log.Error(err)
-			}
-		}
-	}
-	// delete container network namespaces symlinks
-	err = c.DeleteNetnsSymlinks()
-	if err != nil {
-		return fmt.Errorf("error while deleting netns symlinks: %w", err)
-	}
-	return nil
-}
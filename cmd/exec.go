@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/google/shlex"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/srl-labs/containerlab/clab"
@@ -118,42 +117,49 @@ func execCmds(
 	cmds []string,
 	format string,
 ) (result map[string]map[string]interface{}, err error) {
-	var doc interface{}
+	res, err := clab.ExecCmds(ctx, cont, runtime, cmds)
+	if err != nil {
+		log.Error(err)
+		return nil, nil
+	}
+	return formatExecResults(cont, cmds, res, format), nil
+}
 
-	result = make(map[string]map[string]interface{})
+// formatExecResults returns the exec results in json format keyed by the command,
+// in plain format the results are logged and an empty map is returned
+func formatExecResults(
+	cont types.GenericContainer,
+	cmds []string,
+	res map[string]*clab.ExecResult,
+	format string,
+) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
 	for _, cmd := range cmds {
-		c, err := shlex.Split(cmd)
-		if err != nil {
-			return nil, err
+		r, ok := res[cmd]
+		if !ok {
+			continue
 		}
-
-		stdout, stderr, err := runtime.Exec(ctx, cont.ID, c)
-		if err != nil {
-			log.Errorf("%s: failed to execute cmd: %v", cont.Names, err)
-			return nil, nil
-		}
-
 		switch format {
 		case "json":
+			var doc interface{}
 			result[cmd] = make(map[string]interface{})
-			if json.Unmarshal([]byte(stdout), &doc) == nil {
+			if json.Unmarshal(r.Stdout, &doc) == nil {
 				result[cmd]["stdout"] = doc
 			} else {
-				result[cmd]["stdout"] = string(stdout)
+				result[cmd]["stdout"] = string(r.Stdout)
 			}
-			result[cmd]["stderr"] = string(stderr)
+			result[cmd]["stderr"] = string(r.Stderr)
 		case "plain", "table":
 			contName := strings.TrimLeft(cont.Names[0], "/")
-			if len(stdout) > 0 {
-				log.Infof("Executed command '%s' on %s. stdout:\n%s", cmd, contName, string(stdout))
+			if len(r.Stdout) > 0 {
+				log.Infof("Executed command '%s' on %s. stdout:\n%s", cmd, contName, string(r.Stdout))
 			}
-			if len(stderr) > 0 {
-				log.Infof("Executed command '%s' on %s. stderr:\n%s", cmd, contName, string(stderr))
+			if len(r.Stderr) > 0 {
+				log.Infof("Executed command '%s' on %s. stderr:\n%s", cmd, contName, string(r.Stderr))
 			}
 		}
 	}
-
-	return result, nil
+	return result
 }
 
 func init() {
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -26,15 +27,30 @@ var (
 	tmpl    string
 	offline bool
 	dot     bool
+	// diagram format to export the graph to
+	graphFormat string
+	// interval of the live graph updates
+	graphRefresh time.Duration
+	// enables node and link actions in the graph web view
+	graphActions bool
 
 	//go:embed graph-template.html
 	graphTemplate string
 )
 
 type graphTopo struct {
-	Nodes []containerDetails `json:"nodes,omitempty"`
-	Links []link             `json:"links,omitempty"`
+	Nodes []graphNode `json:"nodes,omitempty"`
+	Links []link      `json:"links,omitempty"`
 }
+
+// graphNode is a node of the web view graph with its coordinates in the layout grid
+// col and row keys are used since x and y are the positions set by the d3 force simulation
+type graphNode struct {
+	containerDetails
+	X float64 `json:"col"`
+	Y float64 `json:"row"`
+}
+
 type link struct {
 	Source         string `json:"source,omitempty"`
 	SourceEndpoint string `json:"source_endpoint,omitempty"`
@@ -45,6 +61,10 @@ type link struct {
 type topoData struct {
 	Name string
 	Data template.JS
+	// Live is set when the lab state is streamed over the /events endpoint
+	Live bool
+	// Actions is set when node and link actions are enabled
+	Actions bool
 }
 
 // graphCmd represents the graph command
@@ -72,12 +92,21 @@ var graphCmd = &cobra.Command{
 			return err
 		}
 
-		if dot {
+		if dot || graphFormat == "dot" {
 			return c.GenerateGraph(topo)
 		}
 
+		if graphFormat != "" {
+			_, err = c.ExportGraph(graphFormat)
+			return err
+		}
+
+		if graphRefresh <= 0 {
+			return fmt.Errorf("refresh interval must be positive, got %s", graphRefresh)
+		}
+
 		gtopo := graphTopo{
-			Nodes: make([]containerDetails, 0, len(c.Nodes)),
+			Nodes: make([]graphNode, 0, len(c.Nodes)),
 			Links: make([]link, 0, len(c.Links)),
 		}
 
@@ -103,6 +132,11 @@ var graphCmd = &cobra.Command{
 			buildGraphFromDeployedLab(&gtopo, c, containers)
 		}
 
+		layout := c.GraphLayout()
+		for i := range gtopo.Nodes {
+			p := layout[gtopo.Nodes[i].Name]
+			gtopo.Nodes[i].X, gtopo.Nodes[i].Y = p.X, p.Y
+		}
 		sort.Slice(gtopo.Nodes, func(i, j int) bool {
 			return gtopo.Nodes[i].Name < gtopo.Nodes[j].Name
 		})
@@ -120,8 +154,10 @@ var graphCmd = &cobra.Command{
 		}
 		log.Debugf("generating graph using data: %s", string(b))
 		topoD := topoData{
-			Name: c.Config.Name,
-			Data: template.JS(string(b)), // skipcq: GSC-G203
+			Name:    c.Config.Name,
+			Data:    template.JS(string(b)), // skipcq: GSC-G203
+			Live:    !offline,
+			Actions: !offline && graphActions,
 		}
 		var t *template.Template
 		if tmpl != "" {
@@ -133,6 +169,15 @@ var graphCmd = &cobra.Command{
 		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			_ = t.Execute(w, topoD)
 		})
+		if topoD.Live {
+			registerLiveGraphHandlers(c, graphRefresh, topoD.Actions)
+		}
+
+		if topoD.Actions {
+			if srv, err = graphActionsAddr(srv, cmd.Flags().Changed("srv")); err != nil {
+				return err
+			}
+		}
 
 		log.Infof("Listening on %s...", srv)
 		err = http.ListenAndServe(srv, nil)
@@ -146,7 +191,7 @@ var graphCmd = &cobra.Command{
 func buildGraphFromTopo(g *graphTopo, c *clab.CLab) {
 	log.Info("building graph from topology file")
 	for _, node := range c.Nodes {
-		g.Nodes = append(g.Nodes, containerDetails{
+		g.Nodes = append(g.Nodes, graphNode{containerDetails: containerDetails{
 			Name:        node.Config().ShortName,
 			Kind:        node.Config().Kind,
 			Image:       node.Config().Image,
@@ -154,7 +199,7 @@ func buildGraphFromTopo(g *graphTopo, c *clab.CLab) {
 			State:       "N/A",
 			IPv4Address: node.Config().MgmtIPv4Address,
 			IPv6Address: node.Config().MgmtIPv6Address,
-		})
+		}})
 	}
 
 }
@@ -167,7 +212,7 @@ func buildGraphFromDeployedLab(g *graphTopo, c *clab.CLab, containers []types.Ge
 		}
 		log.Debugf("looking for node name %s", name)
 		if node, ok := c.Nodes[name]; ok {
-			g.Nodes = append(g.Nodes, containerDetails{
+			g.Nodes = append(g.Nodes, graphNode{containerDetails: containerDetails{
 				Name:        name,
 				Kind:        node.Config().Kind,
 				Image:       cont.Image,
@@ -175,7 +220,7 @@ func buildGraphFromDeployedLab(g *graphTopo, c *clab.CLab, containers []types.Ge
 				State:       fmt.Sprintf("%s/%s", cont.State, cont.Status),
 				IPv4Address: getContainerIPv4(cont),
 				IPv6Address: getContainerIPv6(cont),
-			})
+			}})
 		}
 	}
 }
@@ -185,5 +230,9 @@ func init() {
 	graphCmd.Flags().StringVarP(&srv, "srv", "s", ":50080", "HTTP server address to view, customize and export your topology")
 	graphCmd.Flags().BoolVarP(&offline, "offline", "o", false, "use only information from topo file when building graph")
 	graphCmd.Flags().BoolVarP(&dot, "dot", "", false, "generate dot file instead of launching the web server")
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "", "",
+		fmt.Sprintf("export the graph to a diagram file instead of launching the web server, one of %q", append([]string{"dot"}, clab.GraphFormats...)))
+	graphCmd.Flags().DurationVarP(&graphRefresh, "refresh", "", 2*time.Second, "interval of the node and link state updates streamed to the web view")
+	graphCmd.Flags().BoolVarP(&graphActions, "actions", "", false, "enable exec, save and capture actions in the web view")
 	graphCmd.Flags().StringVarP(&tmpl, "template", "", "", "Go html template used to generate the graph")
 }
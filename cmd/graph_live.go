@@ -0,0 +1,266 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os/exec"
+	"reflect"
+	"time"
+
+	"github.com/google/shlex"
+	log "github.com/sirupsen/logrus"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/nodes"
+	"github.com/srl-labs/containerlab/types"
+)
+
+const (
+	// maximum duration of a packet capture started from the graph web view
+	maxCaptureDuration     = 5 * time.Minute
+	defaultCaptureDuration = 10 * time.Second
+)
+
+// registerLiveGraphHandlers registers the handlers that stream the lab state to the graph web view
+// and, when enabled, the handlers of node and link actions
+func registerLiveGraphHandlers(c *clab.CLab, refresh time.Duration, actions bool) {
+	http.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		streamGraphEvents(w, r, c, refresh)
+	})
+
+	if !actions {
+		return
+	}
+	http.HandleFunc("/api/exec", func(w http.ResponseWriter, r *http.Request) {
+		graphExecHandler(w, r, c)
+	})
+	http.HandleFunc("/api/save", func(w http.ResponseWriter, r *http.Request) {
+		graphSaveHandler(w, r, c)
+	})
+	http.HandleFunc("/api/capture", func(w http.ResponseWriter, r *http.Request) {
+		graphCaptureHandler(w, r, c)
+	})
+}
+
+// streamGraphEvents sends server-sent events with the node states when they change
+// and the link states and counters on every refresh interval
+func streamGraphEvents(w http.ResponseWriter, r *http.Request, c *clab.CLab, refresh time.Duration) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ctx := r.Context()
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	var prev map[string]string
+	for {
+		states, err := graphNodeStates(ctx, c)
+		if err != nil {
+			log.Debugf("failed to list lab containers: %v", err)
+		}
+		if err == nil && !reflect.DeepEqual(prev, states) {
+			if err := writeGraphEvent(w, "nodes", states); err != nil {
+				return
+			}
+			prev = states
+		}
+		if err := writeGraphEvent(w, "links", c.LinkStats(ctx)); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeGraphEvent(w http.ResponseWriter, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// graphNodeStates returns the state of the lab nodes keyed by the node name.
+// Nodes without a container are reported as absent
+func graphNodeStates(ctx context.Context, c *clab.CLab) (map[string]string, error) {
+	labels := []*types.GenericFilter{{FilterType: "label", Match: c.Config.Name, Field: "containerlab", Operator: "="}}
+	containers, err := c.ListContainers(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]string, len(c.Nodes))
+	for name := range c.Nodes {
+		states[name] = "absent"
+	}
+	for _, cont := range containers {
+		name := cont.Labels[clab.NodeNameLabel]
+		if _, ok := states[name]; ok {
+			states[name] = fmt.Sprintf("%s/%s", cont.State, cont.Status)
+		}
+	}
+	return states, nil
+}
+
+// graphActionsAddr returns the address the web server listens on when the actions are enabled.
+// The actions are served on the loopback interface only, the default address is rebound to it
+// and explicitly set non-loopback addresses are rejected
+func graphActionsAddr(addr string, explicit bool) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid server address %q: %v", addr, err)
+	}
+	if host == "localhost" {
+		return addr, nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return addr, nil
+	}
+	if explicit {
+		return "", fmt.Errorf("graph actions are served on a loopback address only, %q is not a loopback address", addr)
+	}
+	return net.JoinHostPort("127.0.0.1", port), nil
+}
+
+// graphActionRequest is a body of the requests of the graph web view actions
+type graphActionRequest struct {
+	Node string `json:"node"`
+	Cmd  string `json:"cmd,omitempty"`
+	// interface and duration in seconds of a packet capture
+	Iface    string `json:"iface,omitempty"`
+	Duration int    `json:"duration,omitempty"`
+}
+
+// decodeGraphAction decodes the action request and looks up the node it refers to.
+// Only POST requests with a JSON body are accepted, so that the actions
+// can't be triggered by cross-origin form submissions
+func decodeGraphAction(w http.ResponseWriter, r *http.Request, c *clab.CLab) (*graphActionRequest, nodes.Node, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, nil, false
+	}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		http.Error(w, "expected application/json body", http.StatusUnsupportedMediaType)
+		return nil, nil, false
+	}
+	req := &graphActionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	node, ok := c.Nodes[req.Node]
+	if !ok {
+		http.Error(w, fmt.Sprintf("node %q not found", req.Node), http.StatusNotFound)
+		return nil, nil, false
+	}
+	return req, node, true
+}
+
+func graphExecHandler(w http.ResponseWriter, r *http.Request, c *clab.CLab) {
+	req, node, ok := decodeGraphAction(w, r, c)
+	if !ok {
+		return
+	}
+	cmd, err := shlex.Split(req.Cmd)
+	if err != nil || len(cmd) == 0 {
+		http.Error(w, "provide command to execute", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	log.Infof("Executing command '%s' on %s", req.Cmd, node.Config().ShortName)
+	stdout, stderr, err := node.GetRuntime().Exec(ctx, node.Config().LongName, cmd)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute cmd: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"stdout": string(stdout),
+		"stderr": string(stderr),
+	})
+}
+
+func graphSaveHandler(w http.ResponseWriter, r *http.Request, c *clab.CLab) {
+	_, node, ok := decodeGraphAction(w, r, c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	if err := node.SaveConfig(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// graphCaptureHandler runs tcpdump on a node interface for a given duration
+// and streams the captured packets to the client in pcap format
+func graphCaptureHandler(w http.ResponseWriter, r *http.Request, c *clab.CLab) {
+	req, node, ok := decodeGraphAction(w, r, c)
+	if !ok {
+		return
+	}
+	iface := req.Iface
+	if iface == "" {
+		http.Error(w, "provide interface name to capture on", http.StatusBadRequest)
+		return
+	}
+
+	duration := defaultCaptureDuration
+	if req.Duration < 0 {
+		http.Error(w, "duration is expected to be a positive number of seconds", http.StatusBadRequest)
+		return
+	}
+	if req.Duration > 0 {
+		duration = time.Duration(req.Duration) * time.Second
+	}
+	if duration > maxCaptureDuration {
+		duration = maxCaptureDuration
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), duration)
+	defer cancel()
+
+	args := []string{"tcpdump", "-U", "-nni", iface, "-w", "-"}
+	switch node.Config().Kind {
+	case nodes.NodeKindHOST, nodes.NodeKindBridge, nodes.NodeKindOVS:
+		// interfaces of these nodes reside in the host netns
+	default:
+		args = append([]string{"ip", "netns", "exec", node.Config().LongName}, args...)
+	}
+
+	log.Infof("Capturing packets on %s:%s for %s", node.Config().ShortName, iface, duration)
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%s.pcap", node.Config().ShortName, iface)))
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) // skipcq: GSC-G204
+	cmd.Stdout = w
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		log.Errorf("packet capture on %s:%s failed: %v", node.Config().ShortName, iface, err)
+	}
+}
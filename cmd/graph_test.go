@@ -0,0 +1,118 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/nodes"
+)
+
+func TestGraphTemplateLiveFlags(t *testing.T) {
+	tpl := template.Must(template.New("graph").Parse(graphTemplate))
+	buf := new(strings.Builder)
+	err := tpl.Execute(buf, topoData{
+		Name:    "test",
+		Data:    template.JS(`{"nodes":[],"links":[]}`),
+		Live:    true,
+		Actions: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, re := range []string{`var live =\s*true\s*;`, `var actions =\s*false\s*;`} {
+		if !regexp.MustCompile(re).MatchString(buf.String()) {
+			t.Errorf("rendered template doesn't match %q", re)
+		}
+	}
+}
+
+func TestDecodeGraphAction(t *testing.T) {
+	c := &clab.CLab{Nodes: map[string]nodes.Node{}}
+	tests := map[string]struct {
+		method      string
+		contentType string
+		body        string
+		code        int
+	}{
+		"get": {
+			method: http.MethodGet,
+			code:   http.StatusMethodNotAllowed,
+		},
+		"form_post": {
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "node=n1&cmd=ls",
+			code:        http.StatusUnsupportedMediaType,
+		},
+		"unknown_node": {
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"node":"n1","cmd":"ls"}`,
+			code:        http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "/api/exec", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			if _, _, ok := decodeGraphAction(w, r, c); ok {
+				t.Fatal("expected the request to be rejected")
+			}
+			if w.Code != tc.code {
+				t.Fatalf("wanted status %d, got %d", tc.code, w.Code)
+			}
+		})
+	}
+}
+
+func TestGraphCaptureRequest(t *testing.T) {
+	c := &clab.CLab{Nodes: map[string]nodes.Node{}}
+	// capture parameters in the query of a GET request are rejected
+	r := httptest.NewRequest(http.MethodGet, "/api/capture?node=n1&iface=eth1&duration=10", nil)
+	w := httptest.NewRecorder()
+	graphCaptureHandler(w, r, c)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("wanted status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestGraphActionsAddr(t *testing.T) {
+	tests := map[string]struct {
+		addr     string
+		explicit bool
+		want     string
+		err      bool
+	}{
+		"default":           {addr: ":50080", want: "127.0.0.1:50080"},
+		"explicit_loopback": {addr: "127.0.0.1:8080", explicit: true, want: "127.0.0.1:8080"},
+		"explicit_v6":       {addr: "[::1]:8080", explicit: true, want: "[::1]:8080"},
+		"localhost":         {addr: "localhost:8080", explicit: true, want: "localhost:8080"},
+		"explicit_any":      {addr: ":8080", explicit: true, err: true},
+		"explicit_public":   {addr: "10.0.0.1:8080", explicit: true, err: true},
+		"no_port":           {addr: "127.0.0.1", err: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := graphActionsAddr(tc.addr, tc.explicit)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, wanted error: %v", err, tc.err)
+			}
+			if got != tc.want {
+				t.Fatalf("wanted %q got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -6,8 +6,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -19,26 +21,68 @@ import (
 	"github.com/srl-labs/containerlab/clab"
 	"github.com/srl-labs/containerlab/runtime"
 	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"gopkg.in/yaml.v2"
 )
 
 var format string
 var details bool
 var all bool
+var links bool
+var fields []string
 
 type containerDetails struct {
-	LabName     string `json:"lab_name,omitempty"`
-	LabPath     string `json:"labPath,omitempty"`
-	Name        string `json:"name,omitempty"`
-	ContainerID string `json:"container_id,omitempty"`
-	Image       string `json:"image,omitempty"`
-	Kind        string `json:"kind,omitempty"`
-	Group       string `json:"group,omitempty"`
-	State       string `json:"state,omitempty"`
-	IPv4Address string `json:"ipv4_address,omitempty"`
-	IPv6Address string `json:"ipv6_address,omitempty"`
+	LabName     string `json:"lab_name,omitempty" yaml:"lab_name,omitempty"`
+	LabPath     string `json:"labPath,omitempty" yaml:"labPath,omitempty"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	ContainerID string `json:"container_id,omitempty" yaml:"container_id,omitempty"`
+	Image       string `json:"image,omitempty" yaml:"image,omitempty"`
+	ImageDigest string `json:"image_digest,omitempty" yaml:"image_digest,omitempty"`
+	Kind        string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Group       string `json:"group,omitempty" yaml:"group,omitempty"`
+	State       string `json:"state,omitempty" yaml:"state,omitempty"`
+	Uptime      string `json:"uptime,omitempty" yaml:"uptime,omitempty"`
+	Health      string `json:"health,omitempty" yaml:"health,omitempty"`
+	Runtime     string `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	IPv4Address string `json:"ipv4_address,omitempty" yaml:"ipv4_address,omitempty"`
+	IPv6Address string `json:"ipv6_address,omitempty" yaml:"ipv6_address,omitempty"`
+	MgmtMAC     string `json:"mgmt_mac,omitempty" yaml:"mgmt_mac,omitempty"`
+	Ports       string `json:"ports,omitempty" yaml:"ports,omitempty"`
 }
 type BridgeDetails struct{}
 
+// inspectField describes a containerDetails field that can be selected with --fields flag
+type inspectField struct {
+	header string
+	value  func(d *containerDetails) string
+}
+
+// inspectFields maps field names, which match the json keys of containerDetails, to their
+// table headers and values
+var inspectFields = map[string]inspectField{
+	"lab_name":     {"Lab Name", func(d *containerDetails) string { return d.LabName }},
+	"labPath":      {"Topo Path", func(d *containerDetails) string { return d.LabPath }},
+	"name":         {"Name", func(d *containerDetails) string { return d.Name }},
+	"container_id": {"Container ID", func(d *containerDetails) string { return d.ContainerID }},
+	"image":        {"Image", func(d *containerDetails) string { return d.Image }},
+	"image_digest": {"Image Digest", func(d *containerDetails) string { return d.ImageDigest }},
+	"kind":         {"Kind", func(d *containerDetails) string { return d.Kind }},
+	"group":        {"Group", func(d *containerDetails) string { return d.Group }},
+	"state":        {"State", func(d *containerDetails) string { return d.State }},
+	"uptime":       {"Uptime", func(d *containerDetails) string { return d.Uptime }},
+	"health":       {"Health", func(d *containerDetails) string { return d.Health }},
+	"runtime":      {"Runtime", func(d *containerDetails) string { return d.Runtime }},
+	"ipv4_address": {"IPv4 Address", func(d *containerDetails) string { return d.IPv4Address }},
+	"ipv6_address": {"IPv6 Address", func(d *containerDetails) string { return d.IPv6Address }},
+	"mgmt_mac":     {"Mgmt MAC", func(d *containerDetails) string { return d.MgmtMAC }},
+	"ports":        {"Ports", func(d *containerDetails) string { return d.Ports }},
+}
+
+// defaultInspectFields is the list of fields displayed when --fields flag is not set
+var defaultInspectFields = []string{"name", "container_id", "image", "kind", "state", "ipv4_address", "ipv6_address"}
+
+var inspectFormats = []string{"table", "json", "yaml", "csv", "markdown"}
+
 // inspectCmd represents the inspect command
 var inspectCmd = &cobra.Command{
 	Use:     "inspect",
@@ -51,6 +95,9 @@ var inspectCmd = &cobra.Command{
 			fmt.Println("provide either a lab name (--name) or a topology file path (--topo) or the flag --all")
 			return nil
 		}
+		if err := checkInspectFlags(); err != nil {
+			return err
+		}
 		opts := []clab.ClabOption{
 			clab.WithTimeout(timeout),
 			clab.WithRuntime(rt,
@@ -94,8 +141,16 @@ var inspectCmd = &cobra.Command{
 			log.Println("no containers found")
 			return nil
 		}
+		if links {
+			return printLinksInspect(ctx, os.Stdout, c, containers, format)
+		}
 		if details {
-			b, err := json.MarshalIndent(containers, "", "  ")
+			var b []byte
+			if format == "yaml" {
+				b, err = yaml.Marshal(containers)
+			} else {
+				b, err = json.MarshalIndent(containers, "", "  ")
+			}
 			if err != nil {
 				return fmt.Errorf("failed to marshal containers struct: %v", err)
 			}
@@ -112,40 +167,95 @@ func init() {
 	rootCmd.AddCommand(inspectCmd)
 
 	inspectCmd.Flags().BoolVarP(&details, "details", "", false, "print all details of lab containers")
-	inspectCmd.Flags().StringVarP(&format, "format", "f", "table", "output format. One of [table, json]")
+	inspectCmd.Flags().StringVarP(&format, "format", "f", "table", "output format. One of [table, json, yaml, csv, markdown]")
 	inspectCmd.Flags().BoolVarP(&all, "all", "a", false, "show all deployed containerlab labs")
+	inspectCmd.Flags().BoolVarP(&links, "links", "", false, "show state and counters of lab links")
+	inspectCmd.Flags().StringSliceVarP(&fields, "fields", "", nil,
+		"comma separated list of fields to display, e.g. name,kind,ipv4_address")
+}
+
+// checkInspectFlags validates the format and fields flags values
+func checkInspectFlags() error {
+	if _, ok := utils.StringInSlice(inspectFormats, format); !ok {
+		return fmt.Errorf("format %q is not supported, use one of %q", format, inspectFormats)
+	}
+	switch {
+	case links && format != "table" && format != "json":
+		return fmt.Errorf("format %q is not supported with --links flag, use one of %q", format, []string{"table", "json"})
+	case details && format != "table" && format != "json" && format != "yaml":
+		return fmt.Errorf("format %q is not supported with --details flag, use one of %q", format, []string{"json", "yaml"})
+	}
+	for _, f := range fields {
+		if _, ok := inspectFields[f]; !ok {
+			known := make([]string, 0, len(inspectFields))
+			for k := range inspectFields {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown inspect field %q, use one of %q", f, known)
+		}
+	}
+	return nil
 }
 
-func toTableData(det []containerDetails) [][]string {
+// selectedFields returns the list of fields to display
+func selectedFields() []string {
+	if len(fields) > 0 {
+		return fields
+	}
+	if all {
+		return append([]string{"labPath", "lab_name"}, defaultInspectFields...)
+	}
+	return defaultInspectFields
+}
+
+func toTableData(det []containerDetails, flds []string) [][]string {
 	tabData := make([][]string, 0, len(det))
-	for i, d := range det {
-		if all {
-			tabData = append(tabData, []string{fmt.Sprintf("%d", i+1), d.LabPath, d.LabName, d.Name, d.ContainerID, d.Image, d.Kind, d.State, d.IPv4Address, d.IPv6Address})
-			continue
+	for i := range det {
+		row := []string{fmt.Sprintf("%d", i+1)}
+		for _, f := range flds {
+			row = append(row, inspectFields[f].value(&det[i]))
 		}
-		tabData = append(tabData, []string{fmt.Sprintf("%d", i+1), d.Name, d.ContainerID, d.Image, d.Kind, d.State, d.IPv4Address, d.IPv6Address})
+		tabData = append(tabData, row)
 	}
 	return tabData
 }
 
-func printContainerInspect(c *clab.CLab, containers []types.GenericContainer, format string) error {
+// toFieldMaps returns container details as a list of maps containing only the selected fields
+func toFieldMaps(det []containerDetails, flds []string) []map[string]string {
+	res := make([]map[string]string, 0, len(det))
+	for i := range det {
+		m := make(map[string]string, len(flds))
+		for _, f := range flds {
+			m[f] = inspectFields[f].value(&det[i])
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// toContainerDetails returns the details of the containers sorted by the lab and the container names
+func toContainerDetails(containers []types.GenericContainer) []containerDetails {
 	contDetails := make([]containerDetails, 0, len(containers))
-	// do not print published ports unless mysocketio kind is found
-	printMysocket := false
-	var mysocketCID string
+	// get topo file path relative of the cwd
+	cwd, _ := os.Getwd()
 
 	for _, cont := range containers {
-		// get topo file path relative of the cwd
-		cwd, _ := os.Getwd()
 		path, _ := filepath.Rel(cwd, cont.Labels["clab-topo-file"])
 
 		cdet := containerDetails{
 			LabName:     cont.Labels["containerlab"],
 			LabPath:     path,
 			Image:       cont.Image,
+			ImageDigest: cont.Labels[clab.ImageDigestLabel],
 			State:       cont.State,
+			Uptime:      getContainerUptime(cont),
+			Health:      getContainerHealth(cont),
+			Runtime:     cont.Runtime,
 			IPv4Address: getContainerIPv4(cont),
 			IPv6Address: getContainerIPv6(cont),
+			MgmtMAC:     cont.NetworkSettings.MAC,
+			Ports:       getContainerPorts(cont),
 		}
 		cdet.ContainerID = cont.ShortID
 
@@ -154,10 +264,6 @@ func printContainerInspect(c *clab.CLab, containers []types.GenericContainer, fo
 		}
 		if kind, ok := cont.Labels["clab-node-kind"]; ok {
 			cdet.Kind = kind
-			if kind == "mysocketio" {
-				printMysocket = true
-				mysocketCID = cont.ID
-			}
 		}
 		if group, ok := cont.Labels["clab-node-group"]; ok {
 			cdet.Group = group
@@ -171,36 +277,82 @@ func printContainerInspect(c *clab.CLab, containers []types.GenericContainer, fo
 		}
 		return contDetails[i].LabName < contDetails[j].LabName
 	})
+	return contDetails
+}
 
-	if format == "json" {
-		b, err := json.MarshalIndent(contDetails, "", "  ")
+func printContainerInspect(c *clab.CLab, containers []types.GenericContainer, format string) error {
+	contDetails := toContainerDetails(containers)
+	// do not print published ports unless mysocketio kind is found
+	printMysocket := false
+	var mysocketCID string
+
+	for _, cont := range containers {
+		if cont.Labels["clab-node-kind"] == "mysocketio" {
+			printMysocket = true
+			mysocketCID = cont.ID
+		}
+	}
+
+	flds := selectedFields()
+
+	switch format {
+	case "json":
+		var b []byte
+		var err error
+		if len(fields) > 0 {
+			b, err = json.MarshalIndent(toFieldMaps(contDetails, flds), "", "  ")
+		} else {
+			b, err = json.MarshalIndent(contDetails, "", "  ")
+		}
 		if err != nil {
 			return fmt.Errorf("failed to marshal container details: %v", err)
 		}
 		fmt.Println(string(b))
 		return nil
+	case "yaml":
+		var b []byte
+		var err error
+		if len(fields) > 0 {
+			b, err = yaml.Marshal(toFieldMaps(contDetails, flds))
+		} else {
+			b, err = yaml.Marshal(contDetails)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal container details: %v", err)
+		}
+		fmt.Print(string(b))
+		return nil
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(flds); err != nil {
+			return err
+		}
+		for _, row := range toTableData(contDetails, flds) {
+			// skip the row number
+			if err := w.Write(row[1:]); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
 	}
-	tabData := toTableData(contDetails)
+
+	tabData := toTableData(contDetails, flds)
 	table := tablewriter.NewWriter(os.Stdout)
-	header := []string{
-		"Lab Name",
-		"Name",
-		"Container ID",
-		"Image",
-		"Kind",
-		"State",
-		"IPv4 Address",
-		"IPv6 Address",
-	}
-	if all {
-		table.SetHeader(append([]string{"#", "Topo Path"}, header...))
-	} else {
-		table.SetHeader(append([]string{"#"}, header[1:]...))
+	header := []string{"#"}
+	for _, f := range flds {
+		header = append(header, inspectFields[f].header)
 	}
+	table.SetHeader(header)
 	table.SetAutoFormatHeaders(false)
 	table.SetAutoWrapText(false)
-	// merge cells with lab name and topo file path
-	table.SetAutoMergeCellsByColumnIndex([]int{1, 2})
+	if format == "markdown" {
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+	} else if len(fields) == 0 && all {
+		// merge cells with lab name and topo file path
+		table.SetAutoMergeCellsByColumnIndex([]int{1, 2})
+	}
 	table.AppendBulk(tabData)
 	table.Render()
 
@@ -225,6 +377,129 @@ func printContainerInspect(c *clab.CLab, containers []types.GenericContainer, fo
 	return nil
 }
 
+// printLinksInspect writes the state and counters of the links of the labs the containers belong to
+func printLinksInspect(ctx context.Context, w io.Writer, c *clab.CLab, containers []types.GenericContainer, format string) error {
+	labs := []*clab.CLab{}
+	if topo != "" {
+		labs = append(labs, c)
+	} else {
+		// topology files are taken from the containers labels
+		topos := map[string]struct{}{}
+		for _, cont := range containers {
+			topos[cont.Labels[clab.TopoFileLabel]] = struct{}{}
+		}
+		for t := range topos {
+			lab, err := clab.NewContainerLab(
+				clab.WithTimeout(timeout),
+				clab.WithRuntime(rt,
+					&runtime.RuntimeConfig{
+						Debug:   debug,
+						Timeout: timeout,
+					},
+				),
+				clab.WithTopoFile(t, varsFile),
+			)
+			if err != nil {
+				return fmt.Errorf("could not parse the topology file %s: %v", t, err)
+			}
+			labs = append(labs, lab)
+		}
+	}
+	sort.Slice(labs, func(i, j int) bool {
+		return labs[i].Config.Name < labs[j].Config.Name
+	})
+
+	stats := map[string][]*clab.LinkStats{}
+	for _, lab := range labs {
+		stats[lab.Config.Name] = lab.LinkStats(ctx)
+	}
+
+	if format == "json" {
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal link stats: %v", err)
+		}
+		fmt.Fprintln(w, string(b))
+		return nil
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{
+		"Lab Name",
+		"#",
+		"Node",
+		"Interface",
+		"State",
+		"MTU",
+		"RX Packets",
+		"RX Bytes",
+		"TX Packets",
+		"TX Bytes",
+		"Errors (RX/TX)",
+		"Drops (RX/TX)",
+	})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	// merge cells with lab name and link index
+	table.SetAutoMergeCellsByColumnIndex([]int{0, 1})
+	for _, lab := range labs {
+		for _, ls := range stats[lab.Config.Name] {
+			for _, e := range []*clab.EndpointStats{ls.A, ls.B} {
+				table.Append([]string{
+					lab.Config.Name,
+					fmt.Sprintf("%d", ls.Index+1),
+					e.Node,
+					e.Interface,
+					e.OperState,
+					fmt.Sprintf("%d", e.MTU),
+					fmt.Sprintf("%d", e.RxPackets),
+					fmt.Sprintf("%d", e.RxBytes),
+					fmt.Sprintf("%d", e.TxPackets),
+					fmt.Sprintf("%d", e.TxBytes),
+					fmt.Sprintf("%d/%d", e.RxErrors, e.TxErrors),
+					fmt.Sprintf("%d/%d", e.RxDropped, e.TxDropped),
+				})
+			}
+		}
+	}
+	table.Render()
+	return nil
+}
+
+// getContainerUptime extracts the uptime from the status of a running container, e.g. "Up 2 hours (healthy)"
+func getContainerUptime(ctr types.GenericContainer) string {
+	if !strings.HasPrefix(ctr.Status, "Up ") {
+		return ""
+	}
+	up := strings.TrimPrefix(ctr.Status, "Up ")
+	if i := strings.Index(up, " ("); i > 0 {
+		up = up[:i]
+	}
+	return up
+}
+
+// getContainerHealth extracts the health check state from the container status, e.g. "Up 2 hours (healthy)"
+func getContainerHealth(ctr types.GenericContainer) string {
+	i := strings.LastIndex(ctr.Status, "(")
+	if i < 0 || !strings.HasSuffix(ctr.Status, ")") {
+		return ""
+	}
+	h := strings.TrimPrefix(ctr.Status[i+1:len(ctr.Status)-1], "health: ")
+	switch h {
+	case "healthy", "unhealthy", "starting":
+		return h
+	}
+	return ""
+}
+
+func getContainerPorts(ctr types.GenericContainer) string {
+	ports := make([]string, 0, len(ctr.Ports))
+	for _, p := range ctr.Ports {
+		ports = append(ports, p.String())
+	}
+	return strings.Join(ports, ", ")
+}
+
 func getContainerIPv4(ctr types.GenericContainer) string {
 	if ctr.NetworkSettings.IPv4addr == "" {
 		return "N/A"
@@ -0,0 +1,189 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+	"github.com/srl-labs/containerlab/types"
+)
+
+func TestContainerStatusParsing(t *testing.T) {
+	tests := map[string]struct {
+		status string
+		uptime string
+		health string
+	}{
+		"running": {
+			status: "Up 2 hours",
+			uptime: "2 hours",
+		},
+		"healthy": {
+			status: "Up 5 minutes (healthy)",
+			uptime: "5 minutes",
+			health: "healthy",
+		},
+		"health_starting": {
+			status: "Up 3 seconds (health: starting)",
+			uptime: "3 seconds",
+			health: "starting",
+		},
+		"exited": {
+			status: "Exited (137) 2 seconds ago",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctr := types.GenericContainer{Status: tc.status}
+			if got := getContainerUptime(ctr); got != tc.uptime {
+				t.Errorf("wanted uptime %q got %q", tc.uptime, got)
+			}
+			if got := getContainerHealth(ctr); got != tc.health {
+				t.Errorf("wanted health %q got %q", tc.health, got)
+			}
+		})
+	}
+}
+
+func TestInspectFieldSelection(t *testing.T) {
+	det := []containerDetails{
+		{Name: "clab-lab-n1", Kind: "srl", IPv4Address: "172.20.20.2/24"},
+	}
+	flds := []string{"name", "kind", "ipv4_address"}
+
+	want := []map[string]string{
+		{"name": "clab-lab-n1", "kind": "srl", "ipv4_address": "172.20.20.2/24"},
+	}
+	if got := toFieldMaps(det, flds); !cmp.Equal(got, want) {
+		t.Fatalf("wanted %v got %v", want, got)
+	}
+
+	wantRows := [][]string{{"1", "clab-lab-n1", "srl", "172.20.20.2/24"}}
+	if got := toTableData(det, flds); !cmp.Equal(got, wantRows) {
+		t.Fatalf("wanted %v got %v", wantRows, got)
+	}
+}
+
+func TestPrintLinksInspect(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root privileges")
+	}
+	topoFile := "../clab/test_data/topo_fake_links.yml"
+	c, err := clab.NewContainerLab(
+		clab.WithBaseDir(t.TempDir()),
+		clab.WithTopoFile(topoFile, ""),
+		clab.WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.GlobalRuntime().(*fake.Runtime)
+	r.NetNS = true
+	ctx := context.Background()
+	t.Cleanup(func() { c.DeleteNodes(ctx, 2, nil) })
+
+	// with --topo the links of the given lab are inspected
+	defer func(t string) { topo = t }(topo)
+	topo = topoFile
+
+	staticWg, dynWg := c.CreateNodes(ctx, 2, nil)
+	if staticWg != nil {
+		staticWg.Wait()
+	}
+	if dynWg != nil {
+		dynWg.Wait()
+	}
+	if len(r.ContainerNames()) != 2 {
+		t.Skipf("failed to create nodes with network namespaces: %v", r.ContainerNames())
+	}
+	c.CreateLinks(ctx, 1)
+
+	var buf bytes.Buffer
+	if err := printLinksInspect(ctx, &buf, c, nil, "json"); err != nil {
+		t.Fatal(err)
+	}
+	stats := map[string][]*clab.LinkStats{}
+	if err := json.Unmarshal(buf.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to unmarshal %q: %v", buf.String(), err)
+	}
+	ls := stats["fake-links"]
+	if len(ls) != 1 {
+		t.Fatalf("wanted the stats of 1 link got %v", stats)
+	}
+	for _, e := range []*clab.EndpointStats{ls[0].A, ls[0].B} {
+		if e.Interface != "eth1" || e.OperState != "up" {
+			t.Errorf("endpoint %s: wanted eth1 in up state got %s in %q state", e.Node, e.Interface, e.OperState)
+		}
+	}
+
+	buf.Reset()
+	if err := printLinksInspect(ctx, &buf, c, nil, "table"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"fake-links", "n1", "n2", "eth1", "up"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("table does not contain %q:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestCheckInspectFlags(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		links   bool
+		details bool
+		wantErr bool
+	}{
+		"table":            {format: "table"},
+		"csv":              {format: "csv"},
+		"unknown_format":   {format: "xml", wantErr: true},
+		"links_json":       {format: "json", links: true},
+		"links_yaml":       {format: "yaml", links: true, wantErr: true},
+		"links_csv":        {format: "csv", links: true, wantErr: true},
+		"links_markdown":   {format: "markdown", links: true, wantErr: true},
+		"details_yaml":     {format: "yaml", details: true},
+		"details_csv":      {format: "csv", details: true, wantErr: true},
+		"details_markdown": {format: "markdown", details: true, wantErr: true},
+	}
+	defer func(f string, l, d bool) { format, links, details = f, l, d }(format, links, details)
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			format, links, details = tc.format, tc.links, tc.details
+			if err := checkInspectFlags(); (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, wanted error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestInspectImageDigest(t *testing.T) {
+	containers := []types.GenericContainer{
+		{
+			Names:   []string{"clab-lab-n1"},
+			ImageID: "sha256:0123",
+			Labels:  map[string]string{clab.ImageDigestLabel: "alpine@sha256:abcd"},
+		},
+		{
+			Names:   []string{"clab-lab-n2"},
+			ImageID: "sha256:4567",
+		},
+	}
+	det := toContainerDetails(containers)
+	// the image ID is not a digest of the image in a registry
+	if det[0].ImageDigest != "alpine@sha256:abcd" || det[1].ImageDigest != "" {
+		t.Errorf("got image digests %q and %q", det[0].ImageDigest, det[1].ImageDigest)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+var (
+	logsNodes  []string
+	logsAll    bool
+	logsFollow bool
+	logsSince  string
+)
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "show the logs of the lab nodes",
+	Long: `logs shows the stdout and stderr of the node containers, which is the console output for the VM based nodes.
+The logs of several nodes are multiplexed with every line prefixed with the node name.
+Refer to the https://containerlab.srlinux.dev/cmd/logs/ documentation for the details`,
+	PreRunE: sudoCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if topo == "" {
+			return errors.New("provide topology file path with --topo flag")
+		}
+		if len(logsNodes) == 0 && !logsAll {
+			return errors.New("provide the nodes with --node flag or use --all flag")
+		}
+		if len(logsNodes) > 0 && logsAll {
+			return errors.New("--node and --all flags are mutually exclusive")
+		}
+		since, err := parseSince(logsSince, time.Now())
+		if err != nil {
+			return err
+		}
+
+		opts := []clab.ClabOption{
+			clab.WithTimeout(timeout),
+			clab.WithTopoFile(topo, varsFile),
+			clab.WithRuntime(rt,
+				&runtime.RuntimeConfig{
+					Debug:            debug,
+					Timeout:          timeout,
+					GracefulShutdown: graceful,
+				},
+			),
+		}
+		c, err := clab.NewContainerLab(opts...)
+		if err != nil {
+			return err
+		}
+
+		nodeNames := logsNodes
+		if logsAll {
+			nodeNames = c.ContainerNodes()
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		return c.NodeLogs(ctx, nodeNames, runtime.LogsOptions{Follow: logsFollow, Since: since}, os.Stdout, os.Stderr)
+	},
+}
+
+// parseSince parses the --since flag value, which is either a duration relative to now or an RFC3339 timestamp
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--since value %q is neither a duration nor an RFC3339 timestamp", s)
+	}
+	return t, nil
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().StringSliceVarP(&logsNodes, "node", "", nil, "names of the nodes to show the logs of")
+	logsCmd.Flags().BoolVarP(&logsAll, "all", "a", false, "show the logs of all lab nodes")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "follow the logs output")
+	logsCmd.Flags().StringVarP(&logsSince, "since", "", "",
+		"show the logs written since a timestamp (e.g. 2022-01-02T15:04:05Z) or relative to now (e.g. 30m)")
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := map[string]struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		"empty":     {in: "", want: time.Time{}},
+		"duration":  {in: "30m", want: now.Add(-30 * time.Minute)},
+		"timestamp": {in: "2022-01-01T10:00:00Z", want: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+		"invalid":   {in: "yesterday", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseSince(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
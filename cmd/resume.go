@@ -0,0 +1,115 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+// generate-systemd flag
+var resumeSystemd bool
+
+// resumeCmd represents the resume command
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "resume a lab after a host reboot",
+	Long: `resume brings a deployed lab back after a host reboot or a restart of its containers.
+The stopped lab containers are started, and the netns symlinks, the bridges created by containerlab,
+the missing links and the lab DNS server are re-created.
+Refer to the https://containerlab.srlinux.dev/cmd/resume/ documentation for the details`,
+	Aliases: []string{"redeploy-links"},
+	PreRunE: sudoCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if topo == "" {
+			return errors.New("provide topology file path with --topo flag")
+		}
+		opts := []clab.ClabOption{
+			clab.WithTimeout(timeout),
+			clab.WithTopoFile(topo, varsFile),
+			clab.WithRuntime(rt,
+				&runtime.RuntimeConfig{
+					Debug:   debug,
+					Timeout: timeout,
+				},
+			),
+		}
+		c, err := clab.NewContainerLab(opts...)
+		if err != nil {
+			return err
+		}
+
+		if resumeSystemd {
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			topoPath, err := filepath.Abs(topo)
+			if err != nil {
+				return err
+			}
+			fmt.Print(systemdUnit(c.Config.Name, exe, topoPath, c.GlobalRuntime().GetName()))
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// deliver the pending webhook events before exiting
+		defer c.Events.Close()
+		res, err := c.Resume(ctx, clab.ResumeOptions{})
+		if err != nil {
+			return err
+		}
+		if len(res.Started) > 0 {
+			log.Infof("Started containers of nodes: %s", strings.Join(res.Started, ", "))
+		}
+		log.Infof("Re-created %d links", len(res.Links))
+		if len(res.Errors) > 0 {
+			return fmt.Errorf("%d error(s) occurred while resuming the lab. Check log messages", len(res.Errors))
+		}
+		return nil
+	},
+}
+
+// systemdUnit returns the systemd unit resuming the lab at boot once the container runtime is started
+func systemdUnit(lab, exe, topoPath, runtimeName string) string {
+	after := "network-online.target"
+	switch runtimeName {
+	case runtime.DockerRuntime:
+		after += " docker.service"
+	case "containerd", runtime.IgniteRuntime:
+		after += " containerd.service"
+	}
+	return fmt.Sprintf(`[Unit]
+Description=Resume containerlab lab %s
+Wants=network-online.target
+After=%s
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+WorkingDirectory=%s
+ExecStart=%s resume --topo %s --runtime %s
+
+[Install]
+WantedBy=multi-user.target
+`, lab, after, filepath.Dir(topoPath), exe, topoPath, runtimeName)
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+	resumeCmd.Flags().BoolVarP(&resumeSystemd, "generate-systemd", "", false,
+		"print the systemd unit resuming the lab at boot instead of resuming it")
+}
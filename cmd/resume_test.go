@@ -0,0 +1,32 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	want := `[Unit]
+Description=Resume containerlab lab srl01
+Wants=network-online.target
+After=network-online.target docker.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+WorkingDirectory=/labs/srl01
+ExecStart=/usr/bin/containerlab resume --topo /labs/srl01/srl01.clab.yml --runtime docker
+
+[Install]
+WantedBy=multi-user.target
+`
+	got := systemdUnit("srl01", "/usr/bin/containerlab", "/labs/srl01/srl01.clab.yml", "docker")
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected unit (-want +got):\n%s", d)
+	}
+}
@@ -7,6 +7,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -46,19 +48,8 @@ Refer to the https://containerlab.srlinux.dev/cmd/save/ documentation to see the
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		var wg sync.WaitGroup
-		wg.Add(len(c.Nodes))
-		for _, node := range c.Nodes {
-			go func(node nodes.Node) {
-				defer wg.Done()
-
-				err := node.SaveConfig(ctx)
-				if err != nil {
-					log.Errorf("err: %v", err)
-				}
-			}(node)
-		}
-		wg.Wait()
+		// errors are logged per node and don't fail the save command
+		_ = saveConfigs(ctx, c)
 
 		return nil
 	},
@@ -67,3 +58,28 @@ Refer to the https://containerlab.srlinux.dev/cmd/save/ documentation to see the
 func init() {
 	rootCmd.AddCommand(saveCmd)
 }
+
+// saveConfigs saves the configuration of the lab nodes
+func saveConfigs(ctx context.Context, c *clab.CLab) error {
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	var failed []string
+	wg.Add(len(c.Nodes))
+	for _, node := range c.Nodes {
+		go func(node nodes.Node) {
+			defer wg.Done()
+			if err := node.SaveConfig(ctx); err != nil {
+				log.Errorf("err: %v", err)
+				m.Lock()
+				failed = append(failed, node.Config().ShortName)
+				m.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to save the configuration of the nodes: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
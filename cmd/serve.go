@@ -0,0 +1,639 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/shlex"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// serveTokenEnv is the env var the API token is read from when --token flag is not set
+	serveTokenEnv = "CLAB_API_TOKEN"
+	apiPrefix     = "/api/v1/"
+	// maximum size of the request bodies, topologies are sent inline
+	apiMaxBodySize = 4 << 20
+)
+
+var (
+	serveListen   string
+	serveToken    string
+	serveTLSCert  string
+	serveTLSKey   string
+	serveClientCA string
+	serveWorkDir  string
+)
+
+var (
+	errLabNotFound = errors.New("lab not found")
+	// lab names are used as the directory names in the work directory, so they can't start with a dot
+	labNameRe = regexp.MustCompile(`^[\w-][\w.-]*$`)
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve the REST API for lab lifecycle operations",
+	Long: `serve runs an HTTP server exposing deploy, destroy, inspect, exec, save and graph operations of the labs.
+Deploy, destroy and save operations run as asynchronous jobs.
+Requests are authenticated with a bearer token and/or client certificates signed by the client CA
+reference: https://containerlab.srlinux.dev/cmd/serve/`,
+	PreRunE: sudoCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := serveToken
+		if token == "" {
+			token = os.Getenv(serveTokenEnv)
+		}
+		if token == "" && serveClientCA == "" {
+			return fmt.Errorf("provide an API token with --token flag or %s env var, or a client CA with --client-ca flag", serveTokenEnv)
+		}
+		if (serveTLSCert == "") != (serveTLSKey == "") {
+			return errors.New("both --tls-cert and --tls-key flags must be set to serve over TLS")
+		}
+		if serveClientCA != "" && serveTLSCert == "" {
+			return errors.New("client certificates authentication requires --tls-cert and --tls-key flags")
+		}
+
+		workDir, err := filepath.Abs(serveWorkDir)
+		if err != nil {
+			return err
+		}
+		utils.CreateDirectory(workDir, 0755)
+
+		c, err := clab.NewContainerLab(
+			clab.WithTimeout(timeout),
+			clab.WithRuntime(rt,
+				&runtime.RuntimeConfig{
+					Debug:   debug,
+					Timeout: timeout,
+				},
+			),
+		)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		api := &apiServer{
+			c:       c,
+			token:   token,
+			workDir: workDir,
+			jobs:    newJobQueue(ctx),
+			newLab:  newServedLab,
+		}
+		srv := &http.Server{
+			Addr:    serveListen,
+			Handler: api.handler(),
+		}
+		if serveClientCA != "" {
+			pool, err := loadCertPool(serveClientCA)
+			if err != nil {
+				return err
+			}
+			srv.TLSConfig = &tls.Config{
+				ClientCAs: pool,
+				// clients without a certificate can still authenticate with the token
+				ClientAuth: tls.VerifyClientCertIfGiven,
+				MinVersion: tls.VersionTLS12,
+			}
+		}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+
+		log.Infof("Serving containerlab API on %s", serveListen)
+		if serveTLSCert != "" {
+			err = srv.ListenAndServeTLS(serveTLSCert, serveTLSKey)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l", "127.0.0.1:8080", "address to serve the API on")
+	serveCmd.Flags().StringVarP(&serveToken, "token", "", "", "bearer token authenticating the API requests, defaults to "+serveTokenEnv+" env var")
+	serveCmd.Flags().StringVarP(&serveTLSCert, "tls-cert", "", "", "path to the server TLS certificate")
+	serveCmd.Flags().StringVarP(&serveTLSKey, "tls-key", "", "", "path to the server TLS key")
+	serveCmd.Flags().StringVarP(&serveClientCA, "client-ca", "", "", "path to the CA certificate verifying the client certificates, e.g. a lab CA")
+	serveCmd.Flags().StringVarP(&serveWorkDir, "work-dir", "", "clab-serve",
+		"directory to store the topologies submitted inline and their lab directories in")
+}
+
+// apiServer implements the containerlab REST API
+type apiServer struct {
+	// c is a lab without a topology used to list the containers
+	c       *clab.CLab
+	token   string
+	workDir string
+	jobs    *jobQueue
+	// newLab returns a lab of the topology file with the relative paths resolved against the base directory
+	newLab func(topoFile, baseDir string) (*clab.CLab, error)
+	// labMu serializes the topology files parsing,
+	// since the rendered topologies of the labs sharing a directory are written to the same files
+	labMu sync.Mutex
+}
+
+// deployRequest is the body of the deploy request.
+// Either a path to the topology file on the server or an inline topology is set
+type deployRequest struct {
+	Topo        string `json:"topo,omitempty"`
+	Topology    string `json:"topology,omitempty"`
+	Reconfigure bool   `json:"reconfigure,omitempty"`
+	MaxWorkers  uint   `json:"max_workers,omitempty"`
+	Graph       bool   `json:"graph,omitempty"`
+}
+
+// execRequest is the body of the exec request
+type execRequest struct {
+	Cmd    string   `json:"cmd"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// labSummary is an element of the labs list response
+type labSummary struct {
+	Name  string             `json:"name"`
+	Topo  string             `json:"topo"`
+	Nodes []containerDetails `json:"nodes"`
+}
+
+func newServedLab(topoFile, baseDir string) (*clab.CLab, error) {
+	return clab.NewContainerLab(
+		clab.WithTimeout(timeout),
+		clab.WithBaseDir(baseDir),
+		clab.WithTopoFile(topoFile, ""),
+		clab.WithRuntime(rt,
+			&runtime.RuntimeConfig{
+				Debug:   debug,
+				Timeout: timeout,
+			},
+		),
+	)
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPrefix+"labs", s.handleLabs)
+	mux.HandleFunc(apiPrefix+"labs/", s.handleLab)
+	mux.HandleFunc(apiPrefix+"jobs", s.handleJobs)
+	mux.HandleFunc(apiPrefix+"jobs/", s.handleJobs)
+	return s.authenticate(mux)
+}
+
+// authenticate passes the requests with a verified client certificate or a valid bearer token
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.token != "" {
+			auth := r.Header.Get("Authorization")
+			if strings.HasPrefix(auth, "Bearer ") &&
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(s.token)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="containerlab"`)
+		writeAPIError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+	})
+}
+
+// handleLabs serves the labs collection: GET lists the labs, POST deploys a lab
+func (s *apiServer) handleLabs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listLabs(w, r)
+	case http.MethodPost:
+		s.deployLab(w, r)
+	default:
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleLab serves the /labs/<name>[/<operation>] paths
+func (s *apiServer) handleLab(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, apiPrefix+"labs/"), "/")
+	if len(parts) > 2 || !labNameRe.MatchString(parts[0]) {
+		writeAPIError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	lab := parts[0]
+	op := ""
+	if len(parts) == 2 {
+		op = parts[1]
+	}
+
+	switch {
+	case op == "" && r.Method == http.MethodGet:
+		s.inspectLab(w, r, lab)
+	case op == "" && r.Method == http.MethodDelete:
+		s.destroyLab(w, r, lab)
+	case op == "":
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodDelete)
+	case op == "exec" && r.Method == http.MethodPost:
+		s.execLab(w, r, lab)
+	case op == "save" && r.Method == http.MethodPost:
+		s.saveLab(w, r, lab)
+	case op == "exec" || op == "save":
+		writeMethodNotAllowed(w, http.MethodPost)
+	case op == "graph" && r.Method == http.MethodGet:
+		s.labGraph(w, r, lab)
+	case op == "graph":
+		writeMethodNotAllowed(w, http.MethodGet)
+	default:
+		writeAPIError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// handleJobs serves the job list and the job status requests
+func (s *apiServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix+"jobs"), "/")
+	if id == "" {
+		writeAPIResponse(w, http.StatusOK, s.jobs.list())
+		return
+	}
+	j, ok := s.jobs.get(id)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("job %q not found", id))
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, j)
+}
+
+func (s *apiServer) listLabs(w http.ResponseWriter, r *http.Request) {
+	filter := []*types.GenericFilter{{FilterType: "label", Field: "containerlab", Operator: "exists"}}
+	containers, err := s.c.ListContainers(r.Context(), filter)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	labs := map[string]*labSummary{}
+	for _, cont := range containers {
+		name := cont.Labels["containerlab"]
+		if labs[name] == nil {
+			labs[name] = &labSummary{Name: name, Topo: cont.Labels[clab.TopoFileLabel]}
+		}
+	}
+	for _, d := range toContainerDetails(containers) {
+		labs[d.LabName].Nodes = append(labs[d.LabName].Nodes, d)
+	}
+	res := make([]*labSummary, 0, len(labs))
+	for _, l := range labs {
+		res = append(res, l)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	writeAPIResponse(w, http.StatusOK, res)
+}
+
+func (s *apiServer) inspectLab(w http.ResponseWriter, r *http.Request, lab string) {
+	containers, err := s.labContainers(r.Context(), lab)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, toContainerDetails(containers))
+}
+
+func (s *apiServer) deployLab(w http.ResponseWriter, r *http.Request) {
+	req := deployRequest{}
+	if err := decodeAPIRequest(w, r, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	topoFile, lab, err := s.deployTopology(&req)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	// topology errors are reported in the response rather than in the job
+	c, err := s.parseLab(topoFile)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	o := clab.DeployOptions{
+		Reconfigure: req.Reconfigure,
+		MaxWorkers:  req.MaxWorkers,
+		Graph:       req.Graph,
+	}
+	s.submitJob(w, "deploy", lab, func(ctx context.Context) (interface{}, error) {
+		defer c.Events.Close()
+		res, err := c.Deploy(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		return toContainerDetails(res.Containers), nil
+	})
+}
+
+// deployTopology returns the path to the topology file of the deploy request and the lab name.
+// Inline topologies are stored in the work directory under the lab name
+func (s *apiServer) deployTopology(req *deployRequest) (string, string, error) {
+	switch {
+	case req.Topo != "" && req.Topology != "":
+		return "", "", errors.New("either topo or topology must be set, not both")
+	case req.Topo != "":
+		if !filepath.IsAbs(req.Topo) {
+			return "", "", fmt.Errorf("topology file path %q must be absolute", req.Topo)
+		}
+		b, err := ioutil.ReadFile(req.Topo)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read topology file: %v", err)
+		}
+		// topology templates might not be valid yaml, the lab name is informational here
+		lab, _ := topologyLabName(b)
+		return req.Topo, lab, nil
+	case req.Topology != "":
+		lab, err := topologyLabName([]byte(req.Topology))
+		if err != nil {
+			return "", "", err
+		}
+		dir := filepath.Join(s.workDir, lab)
+		utils.CreateDirectory(dir, 0755)
+		topoFile := filepath.Join(dir, lab+".clab.yml")
+		if err := ioutil.WriteFile(topoFile, []byte(req.Topology), 0644); err != nil {
+			return "", "", err
+		}
+		return topoFile, lab, nil
+	}
+	return "", "", errors.New("either topo or topology must be set")
+}
+
+// topologyLabName returns the lab name set in the topology
+func topologyLabName(b []byte) (string, error) {
+	t := struct {
+		Name string `yaml:"name"`
+	}{}
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return "", fmt.Errorf("failed to parse topology: %v", err)
+	}
+	if !labNameRe.MatchString(t.Name) {
+		return "", fmt.Errorf("topology must set a lab name consisting of letters, digits, '_', '-' and '.' and not starting with '.', got %q", t.Name)
+	}
+	return t.Name, nil
+}
+
+func (s *apiServer) destroyLab(w http.ResponseWriter, r *http.Request, lab string) {
+	o := clab.DestroyOptions{}
+	q := r.URL.Query()
+	if v := q.Get("cleanup"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid cleanup value %q", v))
+			return
+		}
+		o.Cleanup = b
+	}
+	if v := q.Get("max_workers"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid max_workers value %q", v))
+			return
+		}
+		o.MaxWorkers = uint(n)
+	}
+	topoFile, err := s.labTopoFile(r.Context(), lab)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	s.submitJob(w, "destroy", lab, func(ctx context.Context) (interface{}, error) {
+		return nil, s.withLab(topoFile, func(c *clab.CLab) error {
+			defer c.Events.Close()
+			return c.Destroy(ctx, o)
+		})
+	})
+}
+
+func (s *apiServer) saveLab(w http.ResponseWriter, r *http.Request, lab string) {
+	topoFile, err := s.labTopoFile(r.Context(), lab)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	s.submitJob(w, "save", lab, func(ctx context.Context) (interface{}, error) {
+		return nil, s.withLab(topoFile, func(c *clab.CLab) error {
+			return saveConfigs(ctx, c)
+		})
+	})
+}
+
+func (s *apiServer) execLab(w http.ResponseWriter, r *http.Request, lab string) {
+	req := execRequest{}
+	if err := decodeAPIRequest(w, r, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Cmd == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("cmd must be set"))
+		return
+	}
+	if _, err := shlex.Split(req.Cmd); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid cmd: %v", err))
+		return
+	}
+	containers, err := s.labContainers(r.Context(), lab, types.FilterFromLabelStrings(req.Labels)...)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	// the nodes of the lab might use different runtimes
+	topoFile, err := s.labTopoFile(r.Context(), lab)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	c, err := s.parseLab(topoFile)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make(map[string]map[string]map[string]interface{})
+	for _, cont := range containers {
+		if cont.State != "running" || len(cont.Names) == 0 {
+			continue
+		}
+		nodeRuntime, err := c.GetNodeRuntime(strings.TrimLeft(cont.Names[0], "/"))
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		cmds := []string{req.Cmd}
+		out, err := clab.ExecCmds(r.Context(), cont, nodeRuntime, cmds)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		res[strings.TrimLeft(cont.Names[0], "/")] = formatExecResults(cont, cmds, out, "json")
+	}
+	writeAPIResponse(w, http.StatusOK, res)
+}
+
+func (s *apiServer) labGraph(w http.ResponseWriter, r *http.Request, lab string) {
+	topoFile, err := s.labTopoFile(r.Context(), lab)
+	if err != nil {
+		writeAPIError(w, errorStatus(err), err)
+		return
+	}
+	var g *clab.GraphData
+	err = s.withLab(topoFile, func(c *clab.CLab) error {
+		g = c.GraphData()
+		return nil
+	})
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, g)
+}
+
+// submitJob queues the lab operation and responds with the job status
+func (s *apiServer) submitJob(w http.ResponseWriter, op, lab string, run jobFunc) {
+	j, err := s.jobs.submit(op, lab, run)
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	w.Header().Set("Location", apiPrefix+"jobs/"+j.ID)
+	writeAPIResponse(w, http.StatusAccepted, j)
+}
+
+// withLab runs the function with the lab of the topology file
+func (s *apiServer) withLab(topoFile string, fn func(c *clab.CLab) error) error {
+	c, err := s.parseLab(topoFile)
+	if err != nil {
+		return err
+	}
+	return fn(c)
+}
+
+// parseLab returns the lab of the topology file. The relative paths of the topology are resolved against
+// the directory of the topology file, where the lab directory is created, as with the deploy command
+// run from the topology file directory
+func (s *apiServer) parseLab(topoFile string) (*clab.CLab, error) {
+	s.labMu.Lock()
+	defer s.labMu.Unlock()
+	return s.newLab(topoFile, filepath.Dir(topoFile))
+}
+
+// labContainers returns the containers of the lab matching the filters
+func (s *apiServer) labContainers(ctx context.Context, lab string, filters ...*types.GenericFilter) ([]types.GenericContainer, error) {
+	filter := append([]*types.GenericFilter{{FilterType: "label", Match: lab, Field: "containerlab", Operator: "="}}, filters...)
+	containers, err := s.c.ListContainers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("%w: %s", errLabNotFound, lab)
+	}
+	return containers, nil
+}
+
+// labTopoFile returns the path to the topology file of the deployed lab
+func (s *apiServer) labTopoFile(ctx context.Context, lab string) (string, error) {
+	containers, err := s.labContainers(ctx, lab)
+	if err != nil {
+		return "", err
+	}
+	topoFile := containers[0].Labels[clab.TopoFileLabel]
+	if topoFile == "" {
+		return "", fmt.Errorf("topology file of the lab %s is unknown", lab)
+	}
+	return topoFile, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return pool, nil
+}
+
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return fmt.Errorf("content type must be application/json, got %q", ct)
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode request: %v", err)
+	}
+	return nil
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, errLabNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Debugf("failed to write API response: %v", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIResponse(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeAPIError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
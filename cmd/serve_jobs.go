@@ -0,0 +1,181 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type jobState string
+
+const (
+	jobPending   jobState = "pending"
+	jobRunning   jobState = "running"
+	jobSucceeded jobState = "succeeded"
+	jobFailed    jobState = "failed"
+
+	// number of jobs waiting to be run
+	jobQueueSize = 64
+	// number of finished jobs kept for the status requests
+	jobsRetained = 512
+)
+
+var errJobQueueFull = errors.New("too many pending jobs, try again later")
+
+// jobFunc is the work of a job, the returned value is set as the job result
+type jobFunc func(ctx context.Context) (interface{}, error)
+
+// job is an asynchronous lab operation
+type job struct {
+	ID        string      `json:"id"`
+	Operation string      `json:"operation"`
+	Lab       string      `json:"lab,omitempty"`
+	State     jobState    `json:"state"`
+	Error     string      `json:"error,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Created   time.Time   `json:"created"`
+	Started   *time.Time  `json:"started,omitempty"`
+	Finished  *time.Time  `json:"finished,omitempty"`
+
+	run jobFunc
+}
+
+// jobQueue runs the submitted jobs one at a time in the order of submission.
+// Lab operations share the lab directories and the state of the container runtime,
+// such as the management network and the host netns symlinks,
+// so running them sequentially keeps them from interfering with each other.
+type jobQueue struct {
+	m     sync.RWMutex
+	jobs  map[string]*job
+	order []string
+	queue chan *job
+}
+
+// newJobQueue returns a job queue with a worker running the jobs until the context is canceled
+func newJobQueue(ctx context.Context) *jobQueue {
+	q := &jobQueue{
+		jobs:  map[string]*job{},
+		queue: make(chan *job, jobQueueSize),
+	}
+	go q.worker(ctx)
+	return q
+}
+
+// submit queues the job and returns its snapshot
+func (q *jobQueue) submit(op, lab string, run jobFunc) (job, error) {
+	j := &job{
+		ID:        uuid.New().String(),
+		Operation: op,
+		Lab:       lab,
+		State:     jobPending,
+		Created:   time.Now(),
+		run:       run,
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+	select {
+	case q.queue <- j:
+	default:
+		return job{}, errJobQueueFull
+	}
+	q.jobs[j.ID] = j
+	q.order = append(q.order, j.ID)
+	q.prune()
+	log.Infof("job %s: %s of the lab %q queued", j.ID, op, lab)
+	return *j, nil
+}
+
+// get returns the snapshot of the job
+func (q *jobQueue) get(id string) (job, bool) {
+	q.m.RLock()
+	defer q.m.RUnlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return job{}, false
+	}
+	return *j, true
+}
+
+// list returns the snapshots of the jobs in the order of submission
+func (q *jobQueue) list() []job {
+	q.m.RLock()
+	defer q.m.RUnlock()
+	jobs := make([]job, 0, len(q.order))
+	for _, id := range q.order {
+		jobs = append(jobs, *q.jobs[id])
+	}
+	return jobs
+}
+
+func (q *jobQueue) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-q.queue:
+			q.runJob(ctx, j)
+		}
+	}
+}
+
+func (q *jobQueue) runJob(ctx context.Context, j *job) {
+	q.m.Lock()
+	now := time.Now()
+	j.State = jobRunning
+	j.Started = &now
+	q.m.Unlock()
+	log.Infof("job %s: %s of the lab %q started", j.ID, j.Operation, j.Lab)
+
+	res, err := func() (res interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		return j.run(ctx)
+	}()
+
+	q.m.Lock()
+	defer q.m.Unlock()
+	now = time.Now()
+	j.Finished = &now
+	j.Result = res
+	j.State = jobSucceeded
+	if err != nil {
+		j.State = jobFailed
+		j.Error = err.Error()
+		log.Errorf("job %s: %s of the lab %q failed: %v", j.ID, j.Operation, j.Lab, err)
+		return
+	}
+	log.Infof("job %s: %s of the lab %q finished", j.ID, j.Operation, j.Lab)
+}
+
+// prune removes the oldest finished jobs exceeding the retention limit.
+// Must be called with the lock held.
+func (q *jobQueue) prune() {
+	excess := len(q.order) - jobsRetained
+	if excess <= 0 {
+		return
+	}
+	kept := q.order[:0]
+	for _, id := range q.order {
+		j := q.jobs[id]
+		if excess > 0 && (j.State == jobSucceeded || j.State == jobFailed) {
+			delete(q.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	q.order = kept
+}
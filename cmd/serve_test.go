@@ -0,0 +1,322 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/runtime/fake"
+)
+
+func newTestAPIServer(t *testing.T) *apiServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &apiServer{
+		token:   "secret",
+		workDir: t.TempDir(),
+		jobs:    newJobQueue(ctx),
+	}
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	s := newTestAPIServer(t)
+	h := s.handler()
+
+	tests := map[string]struct {
+		auth string
+		want int
+	}{
+		"no_token":    {auth: "", want: http.StatusUnauthorized},
+		"wrong_token": {auth: "Bearer wrong", want: http.StatusUnauthorized},
+		"basic_auth":  {auth: "Basic c2VjcmV0", want: http.StatusUnauthorized},
+		"valid_token": {auth: "Bearer secret", want: http.StatusOK},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, apiPrefix+"jobs", nil)
+			if tc.auth != "" {
+				r.Header.Set("Authorization", tc.auth)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.want {
+				t.Errorf("got status %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestAPIRouting(t *testing.T) {
+	s := newTestAPIServer(t)
+	h := s.handler()
+
+	tests := map[string]struct {
+		method string
+		path   string
+		want   int
+	}{
+		"unknown_lab_operation": {method: http.MethodPost, path: "labs/lab1/restart", want: http.StatusNotFound},
+		"nested_path":           {method: http.MethodGet, path: "labs/lab1/graph/x", want: http.StatusNotFound},
+		"invalid_lab_name":      {method: http.MethodGet, path: "labs/lab%201", want: http.StatusNotFound},
+		"hidden_lab_name":       {method: http.MethodGet, path: "labs/.lab1", want: http.StatusNotFound},
+		"labs_put":              {method: http.MethodPut, path: "labs", want: http.StatusMethodNotAllowed},
+		"exec_get":              {method: http.MethodGet, path: "labs/lab1/exec", want: http.StatusMethodNotAllowed},
+		"lab_post":              {method: http.MethodPost, path: "labs/lab1", want: http.StatusMethodNotAllowed},
+		"unknown_job":           {method: http.MethodGet, path: "jobs/1234", want: http.StatusNotFound},
+		"deploy_without_topo":   {method: http.MethodPost, path: "labs", want: http.StatusBadRequest},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, apiPrefix+tc.path, strings.NewReader("{}"))
+			r.Header.Set("Authorization", "Bearer secret")
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.want {
+				t.Errorf("got status %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeployTopology(t *testing.T) {
+	s := newTestAPIServer(t)
+
+	tests := map[string]struct {
+		req     deployRequest
+		wantLab string
+		wantErr bool
+	}{
+		"inline": {
+			req:     deployRequest{Topology: "name: lab1\ntopology:\n  nodes: {}\n"},
+			wantLab: "lab1",
+		},
+		"inline_without_name": {
+			req:     deployRequest{Topology: "topology:\n  nodes: {}\n"},
+			wantErr: true,
+		},
+		"inline_with_path_in_name": {
+			req:     deployRequest{Topology: "name: ../lab1\n"},
+			wantErr: true,
+		},
+		"inline_with_parent_dir_name": {
+			req:     deployRequest{Topology: "name: ..\n"},
+			wantErr: true,
+		},
+		"inline_with_hidden_name": {
+			req:     deployRequest{Topology: "name: .lab1\n"},
+			wantErr: true,
+		},
+		"relative_topo_path": {
+			req:     deployRequest{Topo: "lab1.clab.yml"},
+			wantErr: true,
+		},
+		"both_set": {
+			req:     deployRequest{Topo: "/lab1.clab.yml", Topology: "name: lab1\n"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			topoFile, lab, err := s.deployTopology(&tc.req)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lab != tc.wantLab {
+				t.Errorf("got lab %q, want %q", lab, tc.wantLab)
+			}
+			if want := filepath.Join(s.workDir, tc.wantLab, tc.wantLab+".clab.yml"); topoFile != want {
+				t.Errorf("got topology file %q, want %q", topoFile, want)
+			}
+			b, err := ioutil.ReadFile(topoFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.req.Topology {
+				t.Errorf("got topology file content %q, want %q", b, tc.req.Topology)
+			}
+		})
+	}
+}
+
+func TestDeployInvalidTopology(t *testing.T) {
+	s := newTestAPIServer(t)
+	s.newLab = func(topoFile, baseDir string) (*clab.CLab, error) {
+		return clab.NewContainerLab(
+			clab.WithBaseDir(baseDir),
+			clab.WithTopoFile(topoFile, ""),
+			clab.WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+		)
+	}
+	h := s.handler()
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(method, apiPrefix+path, bytes.NewReader(b))
+		r.Header.Set("Authorization", "Bearer secret")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	topo := "name: lab1\ntopology:\n  nodes:\n    n1: {kind: linux, image: alpine:3}\n" +
+		"  links:\n    - endpoints: [\"n1:eth1\", \"n2\"]\n"
+	w := do(http.MethodPost, "labs", deployRequest{Topology: topo})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "has wrong syntax") {
+		t.Fatalf("got status %d, want %d with the endpoint error: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	// the server keeps serving and no job is submitted
+	w = do(http.MethodGet, "jobs", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if jobs := s.jobs.list(); len(jobs) != 0 {
+		t.Errorf("unexpected jobs: %+v", jobs)
+	}
+}
+
+func TestExecLab(t *testing.T) {
+	s := newTestAPIServer(t)
+	topoFile := filepath.Join(s.workDir, "lab1.clab.yml")
+	topo := "name: lab1\ntopology:\n  nodes:\n    n1: {kind: linux, image: alpine:3}\n"
+	if err := ioutil.WriteFile(topoFile, []byte(topo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := clab.NewContainerLab(
+		clab.WithBaseDir(s.workDir),
+		clab.WithTopoFile(topoFile, ""),
+		clab.WithRuntime(fake.RuntimeName, &runtime.RuntimeConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	staticWg, dynWg := c.CreateNodes(ctx, 1, nil)
+	if staticWg != nil {
+		staticWg.Wait()
+	}
+	if dynWg != nil {
+		dynWg.Wait()
+	}
+	r := c.GlobalRuntime().(*fake.Runtime)
+	if len(r.ContainerNames()) != 1 {
+		t.Fatalf("failed to create the node: %v", r.ContainerNames())
+	}
+	// the containers are listed and the commands are executed with the same fake runtime
+	s.c = c
+	s.newLab = func(_, _ string) (*clab.CLab, error) { return c, nil }
+	h := s.handler()
+
+	exec := func() *httptest.ResponseRecorder {
+		b, err := json.Marshal(execRequest{Cmd: "ip a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, apiPrefix+"labs/lab1/exec", bytes.NewReader(b))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	r.ExecFn = func(_ string, _ []string) ([]byte, []byte, error) {
+		return []byte("lo"), nil, nil
+	}
+	w := exec()
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	res := map[string]map[string]map[string]interface{}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if out := res["clab-lab1-n1"]["ip a"]["stdout"]; out != "lo" {
+		t.Errorf("got stdout %v, want %q: %s", out, "lo", w.Body.String())
+	}
+
+	r.ExecFn = func(_ string, _ []string) ([]byte, []byte, error) {
+		return nil, nil, errors.New("exec failed")
+	}
+	w = exec()
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "exec failed") {
+		t.Errorf("got status %d, want %d with the exec error: %s", w.Code, http.StatusInternalServerError, w.Body.String())
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newJobQueue(ctx)
+
+	ok, err := q.submit("deploy", "lab1", func(ctx context.Context) (interface{}, error) {
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := q.submit("destroy", "lab1", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok.State != jobPending {
+		t.Errorf("got submitted job state %q, want %q", ok.State, jobPending)
+	}
+
+	wait := func(id string) job {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			j, _ := q.get(id)
+			if j.State == jobSucceeded || j.State == jobFailed {
+				return j
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("job %s did not finish", id)
+		return job{}
+	}
+
+	j := wait(ok.ID)
+	if j.State != jobSucceeded || j.Result != "done" || j.Started == nil || j.Finished == nil {
+		t.Errorf("unexpected succeeded job: %+v", j)
+	}
+	j = wait(failed.ID)
+	if j.State != jobFailed || j.Error != "boom" {
+		t.Errorf("unexpected failed job: %+v", j)
+	}
+
+	jobs := q.list()
+	if len(jobs) != 2 || jobs[0].ID != ok.ID || jobs[1].ID != failed.ID {
+		t.Errorf("unexpected jobs list: %+v", jobs)
+	}
+	if _, err := json.Marshal(jobs); err != nil {
+		t.Errorf("failed to marshal jobs: %v", err)
+	}
+}
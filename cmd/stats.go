@@ -0,0 +1,153 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+// clearScreen moves the cursor to the top left corner and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+var (
+	statsInterval time.Duration
+	statsNoStream bool
+	statsFormat   string
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show resource usage of the lab nodes",
+	Long: `stats shows the CPU, memory, network and processes usage of the lab nodes next to their limits set in the topology,
+along with the host resources summary. The usage is refreshed every interval until the command is interrupted.
+Refer to the https://containerlab.srlinux.dev/cmd/stats/ documentation for the details`,
+	PreRunE: sudoCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if topo == "" {
+			return errors.New("provide topology file path with --topo flag")
+		}
+		switch statsFormat {
+		case "table", "json":
+		default:
+			return fmt.Errorf("unknown format %q, use one of [table, json]", statsFormat)
+		}
+		if statsInterval <= 0 {
+			return errors.New("--interval must be positive")
+		}
+
+		opts := []clab.ClabOption{
+			clab.WithTimeout(timeout),
+			clab.WithTopoFile(topo, varsFile),
+			clab.WithRuntime(rt,
+				&runtime.RuntimeConfig{
+					Debug:            debug,
+					Timeout:          timeout,
+					GracefulShutdown: graceful,
+				},
+			),
+		}
+		c, err := clab.NewContainerLab(opts...)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		// the first collection is the baseline of the CPU usage
+		collector := c.NewStatsCollector()
+		collector.Collect(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(statsInterval):
+			}
+			stats := collector.Collect(ctx)
+			if statsFormat == "json" {
+				if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
+					return err
+				}
+			} else {
+				if !statsNoStream {
+					fmt.Print(clearScreen)
+				}
+				printStats(os.Stdout, stats)
+			}
+			if statsNoStream {
+				return nil
+			}
+		}
+	},
+}
+
+// printStats writes the host summary and the table of the nodes resource usage
+func printStats(w io.Writer, stats *clab.LabStats) {
+	h := stats.Host
+	fmt.Fprintf(w, "Host: %d vCPU, %s of %s memory free\n", h.VCPUs,
+		humanize.IBytes(h.FreeMemory), humanize.IBytes(h.TotalMemory))
+	fmt.Fprintf(w, "Lab:  CPU %.1f%% (%s of host), memory %s (%s of host)\n",
+		h.CPUPercent, percentOf(h.CPUPercent/100, float64(h.VCPUs)),
+		humanize.IBytes(h.MemoryUsage), percentOf(float64(h.MemoryUsage), float64(h.TotalMemory)))
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Node", "Kind", "CPU %", "CPU Limit", "Mem Usage / Limit", "Net RX / TX", "PIDs"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	for _, ns := range stats.Nodes {
+		if ns.Error != "" {
+			table.Append([]string{ns.Name, ns.Kind, "-", "-", "-", "-", ns.Error})
+			continue
+		}
+		cpuLimit, memLimit := "-", "-"
+		if ns.CPULimit != 0 {
+			cpuLimit = strconv.FormatFloat(ns.CPULimit, 'f', -1, 64)
+		}
+		if ns.MemoryLimit != 0 {
+			memLimit = humanize.IBytes(ns.MemoryLimit)
+		}
+		table.Append([]string{
+			ns.Name,
+			ns.Kind,
+			fmt.Sprintf("%.1f%%", ns.CPUPercent),
+			cpuLimit,
+			humanize.IBytes(ns.MemoryUsage) + " / " + memLimit,
+			humanize.Bytes(ns.NetRxBytes) + " / " + humanize.Bytes(ns.NetTxBytes),
+			strconv.FormatUint(ns.PIDs, 10),
+		})
+	}
+	table.Render()
+}
+
+// percentOf formats the share of the total as a percentage
+func percentOf(v, total float64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", v/total*100)
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().DurationVarP(&statsInterval, "interval", "i", 2*time.Second, "interval between the usage refreshes")
+	statsCmd.Flags().BoolVarP(&statsNoStream, "no-stream", "", false, "show the usage once and exit")
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "output format. One of [table, json]")
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/srl-labs/containerlab/clab"
+	"github.com/srl-labs/containerlab/runtime"
+)
+
+var dnsListen string
+
+func init() {
+	toolsCmd.AddCommand(dnsCmd)
+	dnsCmd.AddCommand(dnsServeCmd)
+	dnsServeCmd.Flags().StringVarP(&dnsListen, "listen", "l", "", "address to serve DNS on, e.g. 172.20.20.1:53")
+}
+
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "lab DNS server operations",
+}
+
+var dnsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve DNS records of the lab nodes",
+	Long: `serve runs a DNS server answering queries for <node>.<lab>.io names and long names of the lab containers,
+other queries are forwarded to the resolvers of the host.
+The server is started in the background by the deploy command when mgmt.dns is set in the topology file`,
+	PreRunE: sudoCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if name == "" {
+			return errors.New("provide lab name with --name flag")
+		}
+		if dnsListen == "" {
+			return errors.New("provide address to listen on with --listen flag")
+		}
+		opts := []clab.ClabOption{
+			clab.WithTimeout(timeout),
+			clab.WithRuntime(rt,
+				&runtime.RuntimeConfig{
+					Debug:   debug,
+					Timeout: timeout,
+				},
+			),
+		}
+		c, err := clab.NewContainerLab(opts...)
+		if err != nil {
+			return err
+		}
+		c.Config.Name = name
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		return c.ServeDNS(ctx, dnsListen)
+	},
+}
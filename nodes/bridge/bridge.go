@@ -7,9 +7,11 @@ package bridge
 import (
 	"context"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/srl-labs/containerlab/nodes"
 	"github.com/srl-labs/containerlab/runtime"
 	"github.com/srl-labs/containerlab/types"
+	"github.com/srl-labs/containerlab/utils"
 )
 
 func init() {
@@ -28,12 +30,23 @@ func (s *bridge) Init(cfg *types.NodeConfig, opts ...nodes.NodeOption) error {
 	for _, o := range opts {
 		o(s)
 	}
-	s.cfg.DeploymentStatus = "created" // since we do not create bridges with clab, the status is implied here
+	// unless the bridge is created by clab, the status is implied here
+	if !s.managed() {
+		s.cfg.DeploymentStatus = "created"
+	}
 	return nil
 }
 func (s *bridge) Config() *types.NodeConfig    { return s.cfg }
 func (*bridge) PreDeploy(_, _, _ string) error { return nil }
-func (*bridge) Deploy(_ context.Context) error { return nil }
+
+func (s *bridge) Deploy(_ context.Context) error {
+	if !s.managed() {
+		return nil
+	}
+	log.Infof("Creating bridge %q", s.cfg.ShortName)
+	return utils.CreateBridge(s.cfg.ShortName, s.cfg.Extras.VlanFiltering, s.cfg.Extras.STP)
+}
+
 func (*bridge) PostDeploy(_ context.Context, _ map[string]nodes.Node) error {
 	return nil
 }
@@ -49,6 +62,15 @@ func (*bridge) SaveConfig(_ context.Context) error { return nil }
 
 func (*bridge) GetImages() map[string]string { return map[string]string{} }
 
-func (*bridge) Delete(_ context.Context) error {
-	return nil
+func (s *bridge) Delete(_ context.Context) error {
+	if !s.managed() {
+		return nil
+	}
+	log.Infof("Deleting bridge %q", s.cfg.ShortName)
+	return utils.DeleteLinkByName(s.cfg.ShortName)
+}
+
+// managed returns true if the bridge lifecycle is managed by containerlab
+func (s *bridge) managed() bool {
+	return s.cfg.Extras != nil && s.cfg.Extras.CreateBridge
 }
@@ -31,8 +31,9 @@ func (l *linux) Init(cfg *types.NodeConfig, opts ...nodes.NodeOption) error {
 	}
 
 	// make ipv6 enabled on all linux node interfaces
-	// but not for the nodes with host network mode, as this is not supported on gh action runners
-	if l.Config().NetworkMode != "host" {
+	// but not for the nodes with host network mode, as this is not supported on gh action runners,
+	// and not for the nodes sharing the netns of another node, as the runtimes don't allow net sysctls for them
+	if l.Config().NetworkMode != "host" && l.Config().NetworkModeContainer() == "" {
 		cfg.Sysctls["net.ipv6.conf.all.disable_ipv6"] = "0"
 	}
 
@@ -108,3 +108,14 @@ var DefaultCredentials = map[string][]string{
 	"vr-xrv9k": {"clab", "clab@123"},
 	"vr-csr":   {"admin", "admin"},
 }
+
+// DefaultSecurity holds the security settings per each kind that doesn't need to run privileged,
+// the settings defined in the topology take precedence over these
+var DefaultSecurity = map[string]*types.SecurityConfig{
+	NodeKindLinux: {
+		Privileged: boolPtr(false),
+		CapAdd:     []string{"NET_ADMIN", "NET_RAW"},
+	},
+}
+
+func boolPtr(b bool) *bool { return &b }
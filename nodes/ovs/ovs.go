@@ -6,7 +6,11 @@ package ovs
 
 import (
 	"context"
+	"fmt"
+	"os/exec"
 
+	goOvs "github.com/digitalocean/go-openvswitch/ovs"
+	log "github.com/sirupsen/logrus"
 	"github.com/srl-labs/containerlab/nodes"
 	"github.com/srl-labs/containerlab/runtime"
 	"github.com/srl-labs/containerlab/types"
@@ -35,7 +39,40 @@ func (s *ovs) Config() *types.NodeConfig { return s.cfg }
 
 func (*ovs) PreDeploy(_, _, _ string) error { return nil }
 
-func (*ovs) Deploy(_ context.Context) error { return nil }
+func (s *ovs) Deploy(_ context.Context) error {
+	if !s.managed() {
+		return nil
+	}
+	log.Infof("Creating ovs bridge %q", s.cfg.ShortName)
+	c := goOvs.New(
+		// Prepend "sudo" to all commands.
+		goOvs.Sudo(),
+	)
+	if err := c.VSwitch.AddBridge(s.cfg.ShortName); err != nil {
+		return fmt.Errorf("failed to create ovs bridge %q: %v", s.cfg.ShortName, err)
+	}
+	if s.cfg.Extras.STP {
+		if err := enableSTP(s.cfg.ShortName); err != nil {
+			return fmt.Errorf("failed to enable stp on ovs bridge %q: %v", s.cfg.ShortName, err)
+		}
+	}
+	return nil
+}
+
+// enableSTP enables stp on the ovs bridge. go-openvswitch BridgeOptions only cover the OpenFlow protocols,
+// thus the stp_enable column of the bridge is set with ovs-vsctl directly
+func enableSTP(bridge string) error {
+	cmd := stpCmd(bridge)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+// stpCmd returns the command enabling stp on the ovs bridge
+func stpCmd(bridge string) *exec.Cmd {
+	return exec.Command("sudo", "ovs-vsctl", "set", "bridge", bridge, "stp_enable=true")
+}
 
 func (*ovs) PostDeploy(_ context.Context, _ map[string]nodes.Node) error {
 	return nil
@@ -49,8 +86,21 @@ func (*ovs) GetContainer(_ context.Context) (*types.GenericContainer, error) {
 	return nil, nil
 }
 
-func (*ovs) Delete(_ context.Context) error {
-	return nil
+func (s *ovs) Delete(_ context.Context) error {
+	if !s.managed() {
+		return nil
+	}
+	log.Infof("Deleting ovs bridge %q", s.cfg.ShortName)
+	c := goOvs.New(
+		// Prepend "sudo" to all commands.
+		goOvs.Sudo(),
+	)
+	return c.VSwitch.DeleteBridge(s.cfg.ShortName)
+}
+
+// managed returns true if the ovs bridge lifecycle is managed by containerlab
+func (s *ovs) managed() bool {
+	return s.cfg.Extras != nil && s.cfg.Extras.CreateBridge
 }
 
 func (*ovs) GetImages() map[string]string { return map[string]string{} }
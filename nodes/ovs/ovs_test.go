@@ -0,0 +1,18 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ovs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSTPCmd(t *testing.T) {
+	want := []string{"sudo", "ovs-vsctl", "set", "bridge", "ovs-br1", "stp_enable=true"}
+	if d := cmp.Diff(want, stpCmd("ovs-br1").Args); d != "" {
+		t.Errorf("unexpected stp command (-want +got):\n%s", d)
+	}
+}
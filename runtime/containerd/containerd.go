@@ -3,7 +3,11 @@ package containerd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,14 +18,20 @@ import (
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
 	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/contrib/apparmor"
+	"github.com/containerd/containerd/contrib/seccomp"
 	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/containernetworking/cni/libcni"
 	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/docker/go-units"
 	"github.com/dustin/go-humanize"
 	"github.com/google/shlex"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -33,8 +43,14 @@ import (
 const (
 	containerdNamespace = "clab"
 	cniCache            = "/opt/cni/cache"
-	runtimeName         = "containerd"
-	defaultTimeout      = 30 * time.Second
+	// extraNetsLabel lists the additional networks of the container,
+	// their cni resources are released when the container is deleted
+	extraNetsLabel = "clab.extra-networks"
+	runtimeName    = "containerd"
+	defaultTimeout = 30 * time.Second
+	// directory of the container task logs
+	logDir          = "/tmp/clab"
+	logPollInterval = 500 * time.Millisecond
 )
 
 func init() {
@@ -102,8 +118,39 @@ func (*ContainerdRuntime) CreateNet(_ context.Context) error {
 	return nil
 }
 func (c *ContainerdRuntime) DeleteNet(context.Context) error {
+	return deleteBridge(c.Mgmt.Bridge, c.config.KeepMgmtNet)
+}
+
+// CreateExtraNet sets the bridge of the additional network,
+// the bridge itself is created by cni when the first container attaches to the network
+func (*ContainerdRuntime) CreateExtraNet(_ context.Context, n *types.MgmtNet) error {
+	if n.IPv4Subnet == "" && n.IPv6Subnet == "" {
+		return fmt.Errorf("network %q requires an ipv4 or ipv6 subnet with the containerd runtime", n.Network)
+	}
+	if n.Bridge == "" {
+		n.Bridge = extraNetBridge(n.Network)
+	}
+	return nil
+}
+
+// DeleteExtraNet deletes the bridge of the additional network unless it is still in use
+func (*ContainerdRuntime) DeleteExtraNet(_ context.Context, n *types.MgmtNet) error {
+	bridge := n.Bridge
+	if bridge == "" {
+		bridge = extraNetBridge(n.Network)
+	}
+	return deleteBridge(bridge, false)
+}
+
+// extraNetBridge returns the name of the bridge backing the additional network,
+// which is derived from the network name as the latter may be too long for an interface name
+func extraNetBridge(network string) string {
+	return fmt.Sprintf("br-%x", sha256.Sum256([]byte(network)))[:15]
+}
+
+// deleteBridge deletes the bridge once the veths of the deleted containers are gone
+func deleteBridge(bridgename string, keep bool) error {
 	var err error
-	bridgename := c.Mgmt.Bridge
 	brInUse := true
 	for i := 0; i < 10; i++ {
 		brInUse, err = utils.CheckBrInUse(bridgename)
@@ -119,30 +166,67 @@ func (c *ContainerdRuntime) DeleteNet(context.Context) error {
 			break
 		}
 	}
-	if c.config.KeepMgmtNet || brInUse {
+	if keep || brInUse {
 		log.Infof("Skipping deletion of bridge '%s'", bridgename)
 		return nil
 	}
 	return utils.DeleteLinkByName(bridgename)
 }
 
-func (c *ContainerdRuntime) PullImageIfRequired(ctx context.Context, imagename string) error {
+// PullImage pulls the image according to the pull policy and returns its digest
+func (c *ContainerdRuntime) PullImage(ctx context.Context, imagename string, opts runtime.PullOptions) (string, error) {
 	log.Debugf("Looking up %s container image", imagename)
 	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
 	if !strings.Contains(imagename, ":") {
 		imagename = imagename + ":latest"
 	}
-	_, err := c.client.GetImage(ctx, imagename)
-	if err == nil {
+	img, err := c.client.GetImage(ctx, imagename)
+	switch {
+	case err != nil && opts.Policy == runtime.PullPolicyNever:
+		return "", fmt.Errorf("image %s is not present and the image pull policy is %q", imagename, opts.Policy)
+	case err != nil || opts.Policy == runtime.PullPolicyAlways:
+		n := utils.GetCanonicalImageName(imagename)
+		log.Infof("Pulling %s container image", n)
+		img, err = c.client.Pull(ctx, n, containerd.WithPullUnpack,
+			containerd.WithResolver(newResolver(opts.Auth)),
+			containerd.WithImageHandler(pullProgressHandler(opts.Progress)))
+		if err != nil {
+			return "", err
+		}
+		log.Infof("Done pulling %s", n)
+	default:
 		log.Debugf("Image %s present, skip pulling", imagename)
-		return nil
 	}
-	n := utils.GetCanonicalImageName(imagename)
-	_, err = c.client.Pull(ctx, n, containerd.WithPullUnpack)
-	if err != nil {
-		return err
+	return img.Target().Digest.String(), nil
+}
+
+// newResolver returns the registry resolver using the credentials, if any
+func newResolver(auth *types.RegistryAuth) remotes.Resolver {
+	var authOpts []docker.AuthorizerOpt
+	if auth != nil {
+		authOpts = append(authOpts, docker.WithAuthCreds(func(string) (string, string, error) {
+			// an identity token is passed as a secret with an empty username
+			if auth.IdentityToken != "" {
+				return "", auth.IdentityToken, nil
+			}
+			return auth.Username, auth.Password, nil
+		}))
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(
+			docker.WithAuthorizer(docker.NewDockerAuthorizer(authOpts...)),
+		),
+	})
+}
+
+// pullProgressHandler returns the image handler writing the fetched image layers to w
+func pullProgressHandler(w io.Writer) images.HandlerFunc {
+	return func(_ context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if w != nil && images.IsLayerType(desc.MediaType) {
+			fmt.Fprintf(w, "Pulling layer %s (%s)\n", desc.Digest.Encoded()[:12], humanize.Bytes(uint64(desc.Size)))
+		}
+		return nil, nil
 	}
-	return nil
 }
 
 func (c *ContainerdRuntime) CreateContainer(ctx context.Context, node *types.NodeConfig) (interface{}, error) {
@@ -189,13 +273,14 @@ func (c *ContainerdRuntime) CreateContainer(ctx context.Context, node *types.Nod
 		oci.WithHostname(node.ShortName),
 		WithSysctls(node.Sysctls),
 		oci.WithoutRunMount,
-		oci.WithPrivileged,
 		oci.WithHostLocaltime,
 		oci.WithNamespacedCgroup(),
-		oci.WithAllDevicesAllowed,
-		oci.WithDefaultUnixDevices,
-		oci.WithNewPrivileges,
 	}
+	secOpts, err := securityOpts(node.Security)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, secOpts...)
 	if len(cmd) > 0 {
 		opts = append(opts, oci.WithProcessArgs(cmd...))
 	}
@@ -232,6 +317,18 @@ func (c *ContainerdRuntime) CreateContainer(ctx context.Context, node *types.Nod
 	case "none":
 		// Done!
 	default:
+		if target := node.NetworkModeContainer(); target != "" {
+			// the container joins the netns of the target one, which is already set up by cni
+			nsPath, err := c.GetNSPath(ctx, target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the network namespace of container %q: %v", target, err)
+			}
+			opts = append(opts, oci.WithLinuxNamespace(specs.LinuxNamespace{
+				Type: specs.NetworkNamespace,
+				Path: nsPath,
+			}))
+			break
+		}
 		cnic, cncl, cnirc, err = cniInit(node.LongName, "eth0", c.Mgmt)
 		if err != nil {
 			return nil, err
@@ -332,7 +429,68 @@ func (c *ContainerdRuntime) CreateContainer(ctx context.Context, node *types.Nod
 			return nil, err
 		}
 	}
-	return nil, nil
+	return nil, c.attachExtraNets(ctx, newContainer, node)
+}
+
+// attachExtraNets attaches the container to the additional networks of the node with cni
+// and records the networks in the container labels to detach it when the container is deleted
+func (c *ContainerdRuntime) attachExtraNets(ctx context.Context, cont containerd.Container, node *types.NodeConfig) error {
+	if len(node.Networks) == 0 {
+		return nil
+	}
+	for _, a := range node.Networks {
+		cnic, cncl, cnirc, err := extraNetCNIInit(node.LongName, a)
+		if err != nil {
+			return err
+		}
+		cnirc.NetNS = node.NSPath
+		var ips []string
+		for _, ip := range []struct{ addr, subnet string }{{a.IPv4, a.Net.IPv4Subnet}, {a.IPv6, a.Net.IPv6Subnet}} {
+			if ip.addr == "" {
+				continue
+			}
+			_, subnet, _ := net.ParseCIDR(ip.subnet)
+			ones, _ := subnet.Mask.Size()
+			ips = append(ips, fmt.Sprintf("%s/%d", ip.addr, ones))
+		}
+		if len(ips) > 0 {
+			cnirc.CapabilityArgs["ips"] = ips
+		}
+		if _, err := cnic.AddNetworkList(ctx, cncl, cnirc); err != nil {
+			return fmt.Errorf("failed to attach container %q to network %q: %v", node.LongName, a.Net.Network, err)
+		}
+	}
+	b, err := json.Marshal(node.Networks)
+	if err != nil {
+		return err
+	}
+	_, err = cont.SetLabels(ctx, map[string]string{extraNetsLabel: string(b)})
+	return err
+}
+
+// detachExtraNets releases the cni resources of the additional networks the container is attached to
+func detachExtraNets(ctx context.Context, cont containerd.Container) error {
+	labels, err := cont.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	if labels[extraNetsLabel] == "" {
+		return nil
+	}
+	var attachments []*types.NetworkAttachment
+	if err := json.Unmarshal([]byte(labels[extraNetsLabel]), &attachments); err != nil {
+		return fmt.Errorf("failed to decode the %s label: %v", extraNetsLabel, err)
+	}
+	for _, a := range attachments {
+		cnic, cncl, cnirc, err := extraNetCNIInit(cont.ID(), a)
+		if err != nil {
+			return err
+		}
+		if err := cnic.DelNetworkList(ctx, cncl, cnirc); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func cniInit(cId, ifName string, mgmtNet *types.MgmtNet) (*libcni.CNIConfig, *libcni.NetworkConfigList, *libcni.RuntimeConf, error) {
@@ -400,6 +558,53 @@ func cniInit(cId, ifName string, mgmtNet *types.MgmtNet) (*libcni.CNIConfig, *li
 	return cnic, cncl, cnirc, nil
 }
 
+// extraNetCNIInit returns the cni config attaching the container to the additional network
+// with the interface named as set in the attachment
+func extraNetCNIInit(cId string, a *types.NetworkAttachment) (*libcni.CNIConfig, *libcni.NetworkConfigList, *libcni.RuntimeConf, error) {
+	cnic := libcni.NewCNIConfigWithCacheDir([]string{utils.GetCNIBinaryPath()}, cniCache, nil)
+
+	var ranges [][]map[string]string
+	for _, subnet := range []string{a.Net.IPv4Subnet, a.Net.IPv6Subnet} {
+		if subnet != "" {
+			ranges = append(ranges, []map[string]string{{"subnet": subnet}})
+		}
+	}
+	bridge := map[string]interface{}{
+		"type":        "bridge",
+		"bridge":      a.Net.Bridge,
+		"isGateway":   true,
+		"ipMasq":      false,
+		"hairpinMode": true,
+		"ipam": map[string]interface{}{
+			"type":   "host-local",
+			"ranges": ranges,
+		},
+		"capabilities": map[string]bool{"ips": true},
+	}
+	if mtu, err := strconv.Atoi(a.Net.MTU); err == nil {
+		bridge["mtu"] = mtu
+	}
+	cniConfig, err := json.Marshal(map[string]interface{}{
+		"cniVersion": "0.4.0",
+		"name":       a.Net.Network,
+		"plugins":    []interface{}{bridge},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cncl, err := libcni.ConfListFromBytes(cniConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cnirc := &libcni.RuntimeConf{
+		ContainerID:    cId,
+		IfName:         a.Interface,
+		CapabilityArgs: make(map[string]interface{}),
+	}
+	return cnic, cncl, cnirc, nil
+}
+
 type portMapping struct {
 	HostPort      int    `json:"hostPort"`
 	HostIP        string `json:"hostIP,omitempty"`
@@ -407,6 +612,66 @@ type portMapping struct {
 	Protocol      string `json:"protocol"`
 }
 
+// securityOpts returns the spec options applying the node security settings
+func securityOpts(s *types.SecurityConfig) ([]oci.SpecOpts, error) {
+	if s.IsPrivileged() {
+		return []oci.SpecOpts{
+			oci.WithPrivileged,
+			oci.WithAllDevicesAllowed,
+			oci.WithDefaultUnixDevices,
+			oci.WithNewPrivileges,
+		}, nil
+	}
+	opts := []oci.SpecOpts{
+		oci.WithDefaultUnixDevices,
+		oci.WithAddedCapabilities(capNames(s.CapAdd)),
+		oci.WithDroppedCapabilities(capNames(s.CapDrop)),
+	}
+	for _, d := range s.Devices {
+		dev, err := types.ParseDevice(d)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDevice(dev))
+	}
+	// seccomp profile must follow the capabilities, as the default profile depends on them
+	switch s.SeccompProfile {
+	case "":
+		opts = append(opts, seccomp.WithDefaultProfile())
+	case "unconfined":
+	default:
+		opts = append(opts, seccomp.WithProfile(s.SeccompProfile))
+	}
+	if s.ApparmorProfile != "" {
+		opts = append(opts, apparmor.WithProfile(s.ApparmorProfile))
+	}
+	return opts, nil
+}
+
+// capNames returns capabilities names in the CAP_ prefixed form expected by the OCI spec
+func capNames(caps []string) []string {
+	r := make([]string, 0, len(caps))
+	for _, c := range caps {
+		c = strings.ToUpper(c)
+		if !strings.HasPrefix(c, "CAP_") {
+			c = "CAP_" + c
+		}
+		r = append(r, c)
+	}
+	return r
+}
+
+// WithDevice passes the host device through to the container
+func WithDevice(dev types.DeviceMapping) oci.SpecOpts {
+	return func(ctx context.Context, client oci.Client, c *containers.Container, s *specs.Spec) error {
+		if err := oci.WithLinuxDevice(dev.PathOnHost, dev.CgroupPermissions)(ctx, client, c, s); err != nil {
+			return err
+		}
+		s.Linux.Devices[len(s.Linux.Devices)-1].Path = dev.PathInContainer
+		return nil
+	}
+}
+
 func WithSysctls(sysctls map[string]string) oci.SpecOpts {
 	return func(ctx context.Context, client oci.Client, c *containers.Container, s *specs.Spec) error {
 		if s.Linux == nil {
@@ -427,7 +692,7 @@ func (c *ContainerdRuntime) StartContainer(ctx context.Context, containername st
 	if err != nil {
 		return err
 	}
-	task, err := container.NewTask(ctx, cio.LogFile("/tmp/clab/"+containername+".log"))
+	task, err := container.NewTask(ctx, cio.LogFile(logFile(containername)))
 	if err != nil {
 		return err
 	}
@@ -437,6 +702,43 @@ func (c *ContainerdRuntime) StartContainer(ctx context.Context, containername st
 	}
 	return nil
 }
+
+// logFile returns the path to the file the task IO of the container is written to
+func logFile(containername string) string {
+	return filepath.Join(logDir, containername+".log")
+}
+
+// ContainerLogs writes the task IO of the named container to stdout,
+// as the task stdout and stderr share the log file
+func (c *ContainerdRuntime) ContainerLogs(ctx context.Context, name string, opts runtime.LogsOptions, stdout, _ io.Writer) error {
+	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
+	if _, err := c.client.LoadContainer(ctx, name); err != nil {
+		return err
+	}
+	if !opts.Since.IsZero() {
+		log.Warnf("containerd runtime doesn't record the time of the logs, showing all logs of %q", name)
+	}
+	f, err := os.Open(logFile(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for {
+		if _, err := io.Copy(stdout, f); err != nil {
+			return err
+		}
+		if !opts.Follow {
+			return nil
+		}
+		// wait for the task to write more logs
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
 func (c *ContainerdRuntime) StopContainer(ctx context.Context, containername string) error {
 	ctask, err := c.getContainerTask(ctx, containername)
 	if err != nil {
@@ -509,6 +811,30 @@ func (c *ContainerdRuntime) getContainerTask(ctx context.Context, containername
 	return cont.Task(ctx, nil)
 }
 
+// ContainerStats returns the resource usage of the named container read from the cgroup of its task
+func (c *ContainerdRuntime) ContainerStats(ctx context.Context, name string) (*runtime.ContainerStats, error) {
+	task, err := c.getContainerTask(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	pid := int(task.Pid())
+	cg, err := utils.ReadCgroupStats(pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup stats of container %q: %v", name, err)
+	}
+	s := &runtime.ContainerStats{
+		Time:        time.Now(),
+		CPUUsage:    cg.CPUUsage,
+		MemoryUsage: cg.MemoryUsage,
+		PIDs:        cg.PIDs,
+	}
+	s.NetRxBytes, s.NetTxBytes, err = utils.ReadNetDevStats(pid)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 func (c *ContainerdRuntime) ListContainers(ctx context.Context, filter []*types.GenericFilter) ([]types.GenericContainer, error) {
 	log.Debug("listing containers")
 	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
@@ -591,6 +917,10 @@ func (*ContainerdRuntime) produceGenericContainerList(ctx context.Context, input
 		ctr.Image = info.Image
 		ctr.Labels = info.Labels
 
+		if img, err := i.Image(ctx); err == nil {
+			ctr.ImageID = img.Target().Digest.String()
+		}
+
 		ctr.NetworkSettings, err = extractIPInfoFromLabels(ctr.Labels)
 		if err != nil {
 			return nil, err
@@ -778,6 +1108,9 @@ func (c *ContainerdRuntime) DeleteContainer(ctx context.Context, containerID str
 	if err != nil {
 		return err
 	}
+	if err := detachExtraNets(ctx, cont); err != nil {
+		return err
+	}
 	var delOpts []containerd.DeleteOpts
 	delOpts = append(delOpts, containerd.WithSnapshotCleanup)
 
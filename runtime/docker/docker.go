@@ -7,11 +7,14 @@ package docker
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +24,8 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	dockerC "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/dustin/go-humanize"
 	"github.com/google/shlex"
@@ -85,27 +90,38 @@ func (c *DockerRuntime) WithMgmtNet(n *types.MgmtNet) {
 
 // CreateDockerNet creates a docker network or reusing if it exists
 func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
+	return c.createNet(ctx, c.Mgmt)
+}
+
+// CreateExtraNet creates the docker network the nodes attach to besides the management one
+func (c *DockerRuntime) CreateExtraNet(ctx context.Context, n *types.MgmtNet) error {
+	return c.createNet(ctx, n)
+}
+
+// createNet creates the docker bridge network or reuses the existing one,
+// the name of the linux bridge backing the network is set to n.Bridge
+func (c *DockerRuntime) createNet(ctx context.Context, n *types.MgmtNet) (err error) {
 	nctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
 	defer cancel()
 
 	// linux bridge name that is used by docker network
-	bridgeName := c.Mgmt.Bridge
+	bridgeName := n.Bridge
 
-	log.Debugf("Checking if docker network '%s' exists", c.Mgmt.Network)
-	netResource, err := c.Client.NetworkInspect(nctx, c.Mgmt.Network, dockerTypes.NetworkInspectOptions{})
+	log.Debugf("Checking if docker network '%s' exists", n.Network)
+	netResource, err := c.Client.NetworkInspect(nctx, n.Network, dockerTypes.NetworkInspectOptions{})
 	switch {
 	case dockerC.IsErrNotFound(err):
-		log.Debugf("Network '%s' does not exist", c.Mgmt.Network)
+		log.Debugf("Network '%s' does not exist", n.Network)
 		log.Infof("Creating docker network: Name='%s', IPv4Subnet='%s', IPv6Subnet='%s', MTU='%s'",
-			c.Mgmt.Network, c.Mgmt.IPv4Subnet, c.Mgmt.IPv6Subnet, c.Mgmt.MTU)
+			n.Network, n.IPv4Subnet, n.IPv6Subnet, n.MTU)
 
 		enableIPv6 := false
 		var ipamConfig []network.IPAMConfig
 
 		// check if IPv4/6 addr are assigned to a mgmt bridge
 		var v4gw, v6gw string
-		if c.Mgmt.Bridge != "" {
-			v4gw, v6gw, err = utils.FirstLinkIPs(c.Mgmt.Bridge)
+		if n.Bridge != "" {
+			v4gw, v6gw, err = utils.FirstLinkIPs(n.Bridge)
 			if err != nil {
 				// only return error if the error is not about link not found
 				// we will create the bridge if it doesn't exist
@@ -113,25 +129,25 @@ func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
 					return err
 				}
 			}
-			log.Debugf("bridge %q has ipv4 adrr of %q and ipv6 addr of %q", c.Mgmt.Bridge, v4gw, v6gw)
+			log.Debugf("bridge %q has ipv4 adrr of %q and ipv6 addr of %q", n.Bridge, v4gw, v6gw)
 		}
 
-		if c.Mgmt.IPv4Subnet != "" {
-			if c.Mgmt.IPv4Gw != "" {
-				v4gw = c.Mgmt.IPv4Gw
+		if n.IPv4Subnet != "" {
+			if n.IPv4Gw != "" {
+				v4gw = n.IPv4Gw
 			}
 			ipamConfig = append(ipamConfig, network.IPAMConfig{
-				Subnet:  c.Mgmt.IPv4Subnet,
+				Subnet:  n.IPv4Subnet,
 				Gateway: v4gw,
 			})
 		}
 
-		if c.Mgmt.IPv6Subnet != "" {
-			if c.Mgmt.IPv6Gw != "" {
-				v6gw = c.Mgmt.IPv6Gw
+		if n.IPv6Subnet != "" {
+			if n.IPv6Gw != "" {
+				v6gw = n.IPv6Gw
 			}
 			ipamConfig = append(ipamConfig, network.IPAMConfig{
-				Subnet:  c.Mgmt.IPv6Subnet,
+				Subnet:  n.IPv6Subnet,
 				Gateway: v6gw,
 			})
 			enableIPv6 = true
@@ -143,7 +159,7 @@ func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
 		}
 
 		netwOpts := map[string]string{
-			"com.docker.network.driver.mtu": c.Mgmt.MTU,
+			"com.docker.network.driver.mtu": n.MTU,
 		}
 
 		if bridgeName != "" {
@@ -163,7 +179,7 @@ func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
 			Options: netwOpts,
 		}
 
-		netCreateResponse, err := c.Client.NetworkCreate(nctx, c.Mgmt.Network, opts)
+		netCreateResponse, err := c.Client.NetworkCreate(nctx, n.Network, opts)
 		if err != nil {
 			return err
 		}
@@ -178,11 +194,11 @@ func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
 		}
 
 	case err == nil:
-		log.Debugf("network '%s' was found. Reusing it...", c.Mgmt.Network)
+		log.Debugf("network '%s' was found. Reusing it...", n.Network)
 		if len(netResource.ID) < 12 {
 			return fmt.Errorf("could not get bridge ID")
 		}
-		switch c.Mgmt.Network {
+		switch n.Network {
 		case "bridge":
 			bridgeName = "docker0"
 		default:
@@ -197,11 +213,11 @@ func (c *DockerRuntime) CreateNet(ctx context.Context) (err error) {
 		return err
 	}
 
-	if c.Mgmt.Bridge == "" {
-		c.Mgmt.Bridge = bridgeName
+	if n.Bridge == "" {
+		n.Bridge = bridgeName
 	}
 
-	log.Debugf("Docker network '%s', bridge name '%s'", c.Mgmt.Network, bridgeName)
+	log.Debugf("Docker network '%s', bridge name '%s'", n.Network, bridgeName)
 
 	log.Debug("Disable RPF check on the docker host")
 	err = setSysctl("net/ipv4/conf/all/rp_filter", 0)
@@ -236,6 +252,16 @@ func (c *DockerRuntime) DeleteNet(ctx context.Context) (err error) {
 		log.Debugf("Skipping deletion of '%s' network", network)
 		return nil
 	}
+	return c.deleteNet(ctx, network)
+}
+
+// DeleteExtraNet deletes the docker network the nodes attached to besides the management one
+func (c *DockerRuntime) DeleteExtraNet(ctx context.Context, n *types.MgmtNet) error {
+	return c.deleteNet(ctx, n.Network)
+}
+
+// deleteNet deletes the docker network unless containers are connected to it
+func (c *DockerRuntime) deleteNet(ctx context.Context, network string) (err error) {
 	nctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
 	defer cancel()
 
@@ -246,7 +272,7 @@ func (c *DockerRuntime) DeleteNet(ctx context.Context) (err error) {
 	numEndpoints := len(nres.Containers)
 	if numEndpoints > 0 {
 		if c.config.Debug {
-			log.Debugf("network '%s' has %d active endpoints, deletion skipped", c.Mgmt.Network, numEndpoints)
+			log.Debugf("network '%s' has %d active endpoints, deletion skipped", network, numEndpoints)
 			for _, endp := range nres.Containers {
 				log.Debugf("'%s' is connected to %s", endp.Name, network)
 			}
@@ -299,9 +325,9 @@ func (c *DockerRuntime) CreateContainer(ctx context.Context, node *types.NodeCon
 		Binds:        node.Binds,
 		PortBindings: node.PortBindings,
 		Sysctls:      node.Sysctls,
-		Privileged:   true,
 		NetworkMode:  container.NetworkMode(c.Mgmt.Network),
 		ExtraHosts:   node.ExtraHosts, // add static /etc/hosts entries
+		DNS:          node.DNSServers,
 	}
 	var resources container.Resources
 	if node.Memory != "" {
@@ -319,11 +345,23 @@ func (c *DockerRuntime) CreateContainer(ctx context.Context, node *types.NodeCon
 		resources.CpusetCpus = node.CPUSet
 	}
 	containerHostConfig.Resources = resources
+	if err := setSecurityOpts(containerHostConfig, node.Security); err != nil {
+		return nil, err
+	}
 	containerNetworkingConfig := &network.NetworkingConfig{}
 
-	switch node.NetworkMode {
-	case "host":
+	switch {
+	case node.NetworkMode == "host":
 		containerHostConfig.NetworkMode = container.NetworkMode("host")
+	case node.NetworkModeContainer() != "":
+		// the hostname, dns and published ports belong to the container owning the netns
+		containerHostConfig.NetworkMode = container.NetworkMode(node.NetworkMode)
+		containerConfig.Hostname = ""
+		containerConfig.ExposedPorts = nil
+		containerConfig.MacAddress = ""
+		containerHostConfig.PortBindings = nil
+		containerHostConfig.ExtraHosts = nil
+		containerHostConfig.DNS = nil
 	default:
 		containerHostConfig.NetworkMode = container.NetworkMode(c.Mgmt.Network)
 
@@ -362,10 +400,118 @@ func (c *DockerRuntime) CreateContainer(ctx context.Context, node *types.NodeCon
 		return nil, err
 	}
 
+	// the additional networks are connected to the running container,
+	// so that the management network keeps the eth0 interface
+	if err := c.connectExtraNets(ctx, cont.ID, node); err != nil {
+		return nil, err
+	}
+
 	return nil, utils.LinkContainerNS(node.NSPath, node.LongName)
 
 }
 
+// connectExtraNets connects the container to the additional networks of the node
+// and renames the interfaces docker created for them to the names set in the node config
+func (c *DockerRuntime) connectExtraNets(ctx context.Context, id string, node *types.NodeConfig) error {
+	nctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	for _, a := range node.Networks {
+		err := c.Client.NetworkConnect(nctx, a.Net.Network, id, &network.EndpointSettings{
+			IPAMConfig: &network.EndpointIPAMConfig{
+				IPv4Address: a.IPv4,
+				IPv6Address: a.IPv6,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect container %q to network %q: %v", node.LongName, a.Net.Network, err)
+		}
+		cJSON, err := c.Client.ContainerInspect(nctx, id)
+		if err != nil {
+			return err
+		}
+		if err := renameExtraNetIface(cJSON, a, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameExtraNetIfaces renames the interfaces of the additional networks of the node container
+// to the names set in the node config. Docker re-creates the interfaces with the default names
+// when the container is restarted
+func (c *DockerRuntime) RenameExtraNetIfaces(ctx context.Context, node *types.NodeConfig) error {
+	if len(node.Networks) == 0 {
+		return nil
+	}
+	nctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	cJSON, err := c.Client.ContainerInspect(nctx, node.LongName)
+	if err != nil {
+		return err
+	}
+	for _, a := range node.Networks {
+		if err := renameExtraNetIface(cJSON, a, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameExtraNetIface renames the interface of the container attached to the additional network
+// found by the MAC address docker assigned to it
+func renameExtraNetIface(cJSON dockerTypes.ContainerJSON, a *types.NetworkAttachment, node *types.NodeConfig) error {
+	ep, ok := cJSON.NetworkSettings.Networks[a.Net.Network]
+	if !ok || ep.MacAddress == "" {
+		return fmt.Errorf("container %q has no MAC address in network %q", node.LongName, a.Net.Network)
+	}
+	if err := utils.RenameLinkByMAC(node.NSPath, ep.MacAddress, a.Interface); err != nil {
+		return fmt.Errorf("container %q network %q: %v", node.LongName, a.Net.Network, err)
+	}
+	return nil
+}
+
+// setSecurityOpts applies the node security settings to the container host config
+func setSecurityOpts(hc *container.HostConfig, s *types.SecurityConfig) error {
+	hc.Privileged = s.IsPrivileged()
+	// privileged containers get all capabilities and devices and are not confined
+	if hc.Privileged {
+		return nil
+	}
+	hc.CapAdd = s.CapAdd
+	hc.CapDrop = s.CapDrop
+	for _, d := range s.Devices {
+		dev, err := types.ParseDevice(d)
+		if err != nil {
+			return err
+		}
+		hc.Devices = append(hc.Devices, container.DeviceMapping{
+			PathOnHost:        dev.PathOnHost,
+			PathInContainer:   dev.PathInContainer,
+			CgroupPermissions: dev.CgroupPermissions,
+		})
+	}
+	switch s.SeccompProfile {
+	case "":
+	case "unconfined":
+		hc.SecurityOpt = append(hc.SecurityOpt, "seccomp=unconfined")
+	default:
+		// docker daemon expects the profile itself, not the path to it
+		b, err := ioutil.ReadFile(s.SeccompProfile)
+		if err != nil {
+			return fmt.Errorf("failed to read seccomp profile: %v", err)
+		}
+		profile := new(bytes.Buffer)
+		if err := json.Compact(profile, b); err != nil {
+			return fmt.Errorf("failed to parse seccomp profile %q: %v", s.SeccompProfile, err)
+		}
+		hc.SecurityOpt = append(hc.SecurityOpt, "seccomp="+profile.String())
+	}
+	if s.ApparmorProfile != "" {
+		hc.SecurityOpt = append(hc.SecurityOpt, "apparmor="+s.ApparmorProfile)
+	}
+	return nil
+}
+
 // GetNSPath inspects a container by its name/id and returns an netns path using the pid of a container
 func (c *DockerRuntime) GetNSPath(ctx context.Context, containerId string) (string, error) {
 	nctx, cancelFn := context.WithTimeout(ctx, c.config.Timeout)
@@ -377,7 +523,8 @@ func (c *DockerRuntime) GetNSPath(ctx context.Context, containerId string) (stri
 	return "/proc/" + strconv.Itoa(cJSON.State.Pid) + "/ns/net", nil
 }
 
-func (c *DockerRuntime) PullImageIfRequired(ctx context.Context, imageName string) error {
+// PullImage pulls the image according to the pull policy and returns its repository digest
+func (c *DockerRuntime) PullImage(ctx context.Context, imageName string, opts runtime.PullOptions) (string, error) {
 	filter := filters.NewArgs()
 	filter.Add("reference", imageName)
 
@@ -390,29 +537,112 @@ func (c *DockerRuntime) PullImageIfRequired(ctx context.Context, imageName strin
 
 	images, err := c.Client.ImageList(ctx, ilo)
 	if err != nil {
-		return err
+		return "", err
 	}
+	present := len(images) > 0
 
-	// If Image doesn't exist, we need to pull it
-	if len(images) > 0 {
+	switch {
+	case !present && opts.Policy == runtime.PullPolicyNever:
+		return "", fmt.Errorf("image %s is not present and the image pull policy is %q", imageName, opts.Policy)
+	case !present || opts.Policy == runtime.PullPolicyAlways:
+		if err := c.pullImage(ctx, imageName, opts); err != nil {
+			return "", err
+		}
+	default:
 		log.Debugf("Image %s present, skip pulling", imageName)
-		return nil
+	}
+
+	img, _, err := c.Client.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+	return utils.ImageRepoDigest(imageName, img.RepoDigests), nil
+}
+
+func (c *DockerRuntime) pullImage(ctx context.Context, imageName string, opts runtime.PullOptions) error {
+	var pullOpts dockerTypes.ImagePullOptions
+	if opts.Auth != nil {
+		buf, err := json.Marshal(dockerTypes.AuthConfig{
+			Username:      opts.Auth.Username,
+			Password:      opts.Auth.Password,
+			IdentityToken: opts.Auth.IdentityToken,
+		})
+		if err != nil {
+			return err
+		}
+		pullOpts.RegistryAuth = base64.URLEncoding.EncodeToString(buf)
 	}
 
 	canonicalImageName := utils.GetCanonicalImageName(imageName)
 	log.Infof("Pulling %s Docker image", canonicalImageName)
-	reader, err := c.Client.ImagePull(ctx, canonicalImageName, dockerTypes.ImagePullOptions{})
+	reader, err := c.Client.ImagePull(ctx, canonicalImageName, pullOpts)
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
-	// must read from reader, otherwise image is not properly pulled
-	_, _ = io.Copy(ioutil.Discard, reader)
+
+	// must read from reader, otherwise image is not properly pulled.
+	// the stream carries the pull progress and the pull errors
+	out := opts.Progress
+	if out == nil {
+		out = ioutil.Discard
+	}
+	fd, isTerm := utils.TerminalFd(out)
+	if err := jsonmessage.DisplayJSONMessagesStream(reader, out, fd, isTerm, nil); err != nil {
+		return fmt.Errorf("failed to pull %s: %v", canonicalImageName, err)
+	}
 	log.Infof("Done pulling %s", canonicalImageName)
 
 	return nil
 }
 
+// BuildImage builds the image from the Dockerfile in the build context,
+// the files excluded by the .dockerignore file are not sent to the docker daemon
+func (c *DockerRuntime) BuildImage(ctx context.Context, opts runtime.BuildOptions) error {
+	excludes, err := utils.BuildContextExcludes(opts.Context, opts.Dockerfile)
+	if err != nil {
+		return err
+	}
+	buildCtx, err := archive.TarWithOptions(opts.Context, &archive.TarOptions{ExcludePatterns: excludes})
+	if err != nil {
+		return err
+	}
+	defer buildCtx.Close()
+
+	args := make(map[string]*string, len(opts.Args))
+	for k, v := range opts.Args {
+		v := v
+		args[k] = &v
+	}
+
+	log.Infof("Building %s Docker image", opts.Tag)
+	resp, err := c.Client.ImageBuild(ctx, buildCtx, dockerTypes.ImageBuildOptions{
+		Tags:        []string{opts.Tag},
+		Dockerfile:  filepath.ToSlash(opts.Dockerfile),
+		BuildArgs:   args,
+		Labels:      opts.Labels,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the stream carries the build output and the build errors
+	out := opts.Progress
+	if out == nil {
+		out = ioutil.Discard
+	}
+	fd, isTerm := utils.TerminalFd(out)
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, out, fd, isTerm, nil); err != nil {
+		return fmt.Errorf("failed to build %s: %v", opts.Tag, err)
+	}
+	log.Infof("Done building %s", opts.Tag)
+
+	return nil
+}
+
 // StartContainer starts a docker container
 func (c *DockerRuntime) StartContainer(ctx context.Context, id string) error {
 	nctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
@@ -511,11 +741,20 @@ func (c *DockerRuntime) produceGenericContainerList(inputContainers []dockerType
 			ID:              i.ID,
 			ShortID:         i.ID[:12],
 			Image:           i.Image,
+			ImageID:         i.ImageID,
 			State:           i.State,
 			Status:          i.Status,
 			Labels:          i.Labels,
 			NetworkSettings: types.GenericMgmtIPs{},
 		}
+		for _, p := range i.Ports {
+			ctr.Ports = append(ctr.Ports, types.GenericPortBinding{
+				HostIP:        p.IP,
+				HostPort:      int(p.PublicPort),
+				ContainerPort: int(p.PrivatePort),
+				Protocol:      p.Type,
+			})
+		}
 		bridgeName := c.Mgmt.Network
 		// if bridgeName is "", try to find a network created by clab that the container is connected to
 		if bridgeName == "" && inputNetworkRessources != nil {
@@ -531,6 +770,7 @@ func (c *DockerRuntime) produceGenericContainerList(inputContainers []dockerType
 			ctr.NetworkSettings.IPv4pLen = ifcfg.IPPrefixLen
 			ctr.NetworkSettings.IPv6addr = ifcfg.GlobalIPv6Address
 			ctr.NetworkSettings.IPv6pLen = ifcfg.GlobalIPv6PrefixLen
+			ctr.NetworkSettings.MAC = ifcfg.MacAddress
 		}
 		result = append(result, ctr)
 	}
@@ -599,6 +839,73 @@ func (c *DockerRuntime) ExecNotWait(_ context.Context, id string, cmd []string)
 	return nil
 }
 
+// ContainerLogs writes the logs of the named container to the writers
+func (c *DockerRuntime) ContainerLogs(ctx context.Context, name string, opts runtime.LogsOptions, stdout, stderr io.Writer) error {
+	cont, err := c.Client.ContainerInspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	logOpts := dockerTypes.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+	}
+	if !opts.Since.IsZero() {
+		logOpts.Since = strconv.FormatInt(opts.Since.Unix(), 10)
+	}
+	rc, err := c.Client.ContainerLogs(ctx, cont.ID, logOpts)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// logs of the containers with a tty are not multiplexed and are all written to stdout
+	if cont.Config.Tty {
+		_, err = io.Copy(stdout, rc)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, rc)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// ContainerStats returns the resource usage of the named container
+func (c *DockerRuntime) ContainerStats(ctx context.Context, name string) (*runtime.ContainerStats, error) {
+	cont, err := c.Client.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.Client.ContainerStatsOneShot(ctx, cont.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	var st dockerTypes.StatsJSON
+	if err := json.NewDecoder(rsp.Body).Decode(&st); err != nil {
+		return nil, fmt.Errorf("failed to decode stats of container %q: %v", name, err)
+	}
+	s := &runtime.ContainerStats{
+		Time:        st.Read,
+		CPUUsage:    time.Duration(st.CPUStats.CPUUsage.TotalUsage),
+		MemoryUsage: st.MemoryStats.Usage,
+		PIDs:        st.PidsStats.Current,
+	}
+	// the page cache is not accounted, as docker stats does
+	for _, k := range []string{"total_inactive_file", "inactive_file"} {
+		if v, ok := st.MemoryStats.Stats[k]; ok && v < s.MemoryUsage {
+			s.MemoryUsage -= v
+			break
+		}
+	}
+	// docker reports the traffic of the interfaces it manages only, so all interfaces are read from the container netns
+	s.NetRxBytes, s.NetTxBytes, err = utils.ReadNetDevStats(cont.State.Pid)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 // DeleteContainer tries to stop a container then remove it
 func (c *DockerRuntime) DeleteContainer(ctx context.Context, containerID string) error {
 	var err error
@@ -0,0 +1,677 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package fake implements an in-memory container runtime for testing.
+// The runtime registers itself under the "fake" name when the package is imported,
+// it is not a part of the runtimes available to the containerlab binary.
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	"github.com/srl-labs/containerlab/runtime"
+	"github.com/srl-labs/containerlab/types"
+	"golang.org/x/sys/unix"
+)
+
+const RuntimeName = "fake"
+
+// fake runtime method names used in the recorded calls and the injected faults
+const (
+	MethodCreateNet            = "CreateNet"
+	MethodDeleteNet            = "DeleteNet"
+	MethodPullImage            = "PullImage"
+	MethodCreateContainer      = "CreateContainer"
+	MethodStartContainer       = "StartContainer"
+	MethodStopContainer        = "StopContainer"
+	MethodListContainers       = "ListContainers"
+	MethodGetNSPath            = "GetNSPath"
+	MethodExec                 = "Exec"
+	MethodExecNotWait          = "ExecNotWait"
+	MethodDeleteContainer      = "DeleteContainer"
+	MethodContainerLogs        = "ContainerLogs"
+	MethodContainerStats       = "ContainerStats"
+	MethodCreateExtraNet       = "CreateExtraNet"
+	MethodDeleteExtraNet       = "DeleteExtraNet"
+	MethodRenameExtraNetIfaces = "RenameExtraNetIfaces"
+	MethodBuildImage           = "BuildImage"
+)
+
+// stubNSDir is the directory of the netns paths returned when the real namespaces are not used
+const stubNSDir = "/run/clab-fake/netns"
+
+func init() {
+	runtime.Register(RuntimeName, func() runtime.ContainerRuntime {
+		return New()
+	})
+}
+
+// Call is a recorded call of the runtime method
+type Call struct {
+	Method string
+	// Arg is the container name or ID, the image or the network name the method was called with
+	Arg string
+}
+
+// ExecFunc simulates the execution of cmd in the named container
+type ExecFunc func(name string, cmd []string) (stdout, stderr []byte, err error)
+
+type fault struct {
+	method string
+	arg    string
+	err    error
+}
+
+type container struct {
+	types.GenericContainer
+	name     string
+	nsPath   string
+	netns    ns.NetNS
+	security *types.SecurityConfig
+	stdout   string
+	stderr   string
+	stats    runtime.ContainerStats
+	networks []*types.NetworkAttachment
+}
+
+// Runtime is an in-memory container runtime recording the calls of its methods.
+// Containers are simulated with stub network namespace paths, unless NetNS is set
+type Runtime struct {
+	config runtime.RuntimeConfig
+	Mgmt   *types.MgmtNet
+	// NetNS makes the runtime create a real network namespace for every container,
+	// so that the links between the containers can be created. Requires root privileges
+	NetNS bool
+	// ExecFn simulates the commands executed in the containers, the commands succeed with no output if nil
+	ExecFn ExecFunc
+
+	m          sync.Mutex
+	calls      []Call
+	faults     []fault
+	containers map[string]*container
+	netCreated bool
+	extraNets  map[string]*types.MgmtNet
+	images     map[string]string
+	pulls      map[string]runtime.PullOptions
+	builds     map[string]runtime.BuildOptions
+	lastID     int
+	lastIP     int
+}
+
+// New returns a fake runtime without containers
+func New() *Runtime {
+	return &Runtime{
+		Mgmt:       new(types.MgmtNet),
+		containers: map[string]*container{},
+		extraNets:  map[string]*types.MgmtNet{},
+		images:     map[string]string{},
+		pulls:      map[string]runtime.PullOptions{},
+		builds:     map[string]runtime.BuildOptions{},
+	}
+}
+
+func (*Runtime) GetName() string                 { return RuntimeName }
+func (r *Runtime) Config() runtime.RuntimeConfig { return r.config }
+
+func (r *Runtime) Init(opts ...runtime.RuntimeOption) error {
+	for _, o := range opts {
+		o(r)
+	}
+	return nil
+}
+
+func (r *Runtime) WithConfig(cfg *runtime.RuntimeConfig) {
+	r.config = *cfg
+}
+
+func (r *Runtime) WithMgmtNet(n *types.MgmtNet) {
+	r.Mgmt = n
+}
+
+func (r *Runtime) WithKeepMgmtNet() {
+	r.config.KeepMgmtNet = true
+}
+
+// FailOn makes the method fail with err when it is called with arg,
+// which is the container name, the image or the network name. An empty arg matches any call of the method
+func (r *Runtime) FailOn(method, arg string, err error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.faults = append(r.faults, fault{method: method, arg: arg, err: err})
+}
+
+// Calls returns the recorded calls of the runtime methods in the order they were made
+func (r *Runtime) Calls() []Call {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallArgs returns the sorted args of the recorded method calls
+func (r *Runtime) CallArgs(method string) []string {
+	var args []string
+	for _, c := range r.Calls() {
+		if c.Method == method {
+			args = append(args, c.Arg)
+		}
+	}
+	sort.Strings(args)
+	return args
+}
+
+// ContainerNames returns the sorted names of the runtime containers
+func (r *Runtime) ContainerNames() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	names := make([]string, 0, len(r.containers))
+	for n := range r.containers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NetworkCreated reports whether the management network exists
+func (r *Runtime) NetworkCreated() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.netCreated
+}
+
+// call records the call and returns the error injected for it, if any.
+// The runtime lock must be held
+func (r *Runtime) call(method, arg string) error {
+	r.calls = append(r.calls, Call{Method: method, Arg: arg})
+	for _, f := range r.faults {
+		if f.method == method && (f.arg == "" || f.arg == arg) {
+			return f.err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) CreateNet(_ context.Context) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodCreateNet, r.Mgmt.Network); err != nil {
+		return err
+	}
+	r.netCreated = true
+	return nil
+}
+
+func (r *Runtime) DeleteNet(_ context.Context) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodDeleteNet, r.Mgmt.Network); err != nil {
+		return err
+	}
+	if r.config.KeepMgmtNet {
+		return nil
+	}
+	r.netCreated = false
+	return nil
+}
+
+// CreateExtraNet creates the additional network unless it exists
+func (r *Runtime) CreateExtraNet(_ context.Context, n *types.MgmtNet) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodCreateExtraNet, n.Network); err != nil {
+		return err
+	}
+	if _, ok := r.extraNets[n.Network]; !ok {
+		r.extraNets[n.Network] = n
+	}
+	return nil
+}
+
+// DeleteExtraNet deletes the additional network unless a container is attached to it
+func (r *Runtime) DeleteExtraNet(_ context.Context, n *types.MgmtNet) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodDeleteExtraNet, n.Network); err != nil {
+		return err
+	}
+	for _, c := range r.containers {
+		for _, a := range c.networks {
+			if a.Net.Network == n.Network {
+				return nil
+			}
+		}
+	}
+	delete(r.extraNets, n.Network)
+	return nil
+}
+
+// RenameExtraNetIfaces records the call for the container attached to the additional networks,
+// the fake runtime creates no interfaces for the networks
+func (r *Runtime) RenameExtraNetIfaces(_ context.Context, node *types.NodeConfig) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodRenameExtraNetIfaces, node.LongName)
+	if err != nil {
+		return err
+	}
+	if len(c.networks) == 0 {
+		return fmt.Errorf("container %q is not attached to additional networks", node.LongName)
+	}
+	return nil
+}
+
+// ExtraNets returns the sorted names of the existing additional networks
+func (r *Runtime) ExtraNets() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	names := make([]string, 0, len(r.extraNets))
+	for name := range r.extraNets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Networks returns the additional networks the named container is attached to
+func (r *Runtime) Networks(name string) ([]*types.NetworkAttachment, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	cont, ok := r.containers[name]
+	if !ok {
+		return nil, false
+	}
+	return cont.networks, true
+}
+
+// PullImage records the pull options of the image and "pulls" the image with the digest derived from its name,
+// when required by the pull policy. The pulled digest is written to the progress writer
+func (r *Runtime) PullImage(_ context.Context, image string, opts runtime.PullOptions) (string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodPullImage, image); err != nil {
+		return "", err
+	}
+	r.pulls[image] = opts
+	digest, present := r.images[image]
+	switch {
+	case !present && opts.Policy == runtime.PullPolicyNever:
+		return "", fmt.Errorf("image %s is not present and the image pull policy is %q", image, opts.Policy)
+	case !present || opts.Policy == runtime.PullPolicyAlways:
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(image)))
+		r.images[image] = digest
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "Pulled %s\n", digest)
+		}
+	}
+	return digest, nil
+}
+
+// BuildImage adds the image tagged by the build to the runtime, the built images have no digest
+func (r *Runtime) BuildImage(_ context.Context, opts runtime.BuildOptions) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodBuildImage, opts.Tag); err != nil {
+		return err
+	}
+	r.builds[opts.Tag] = opts
+	r.images[opts.Tag] = ""
+	if opts.Progress != nil {
+		fmt.Fprintf(opts.Progress, "Built %s\n", opts.Tag)
+	}
+	return nil
+}
+
+// BuildOptions returns the options of the last build of the image
+func (r *Runtime) BuildOptions(image string) (runtime.BuildOptions, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	opts, ok := r.builds[image]
+	return opts, ok
+}
+
+// AddImage adds the image with the digest to the runtime, as if it was pulled before
+func (r *Runtime) AddImage(image, digest string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.images[image] = digest
+}
+
+// PullOptions returns the options of the last pull of the image
+func (r *Runtime) PullOptions(image string) (runtime.PullOptions, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	opts, ok := r.pulls[image]
+	return opts, ok
+}
+
+// Security returns the security settings the named container was created with
+func (r *Runtime) Security(name string) (*types.SecurityConfig, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	cont, ok := r.containers[name]
+	if !ok {
+		return nil, false
+	}
+	return cont.security, true
+}
+
+// CreateContainer creates and starts the container of the node
+// and sets the node netns path
+func (r *Runtime) CreateContainer(_ context.Context, node *types.NodeConfig) (interface{}, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodCreateContainer, node.LongName); err != nil {
+		return nil, err
+	}
+	if _, ok := r.containers[node.LongName]; ok {
+		return nil, fmt.Errorf("container %q already exists", node.LongName)
+	}
+
+	r.lastID++
+	id := fmt.Sprintf("%064x", r.lastID)
+	labels := make(map[string]string, len(node.Labels))
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	cont := &container{
+		GenericContainer: types.GenericContainer{
+			Names:   []string{"/" + node.LongName},
+			ID:      id,
+			ShortID: id[:12],
+			Image:   node.Image,
+			State:   "running",
+			Status:  "Up",
+			Labels:  labels,
+		},
+		name:     node.LongName,
+		nsPath:   filepath.Join(stubNSDir, node.LongName),
+		security: node.Security,
+	}
+	for _, a := range node.Networks {
+		if _, ok := r.extraNets[a.Net.Network]; !ok {
+			return nil, fmt.Errorf("network %q is not found", a.Net.Network)
+		}
+	}
+	cont.networks = node.Networks
+
+	// the container sharing the netns of another one neither gets addresses nor owns the netns
+	if target := node.NetworkModeContainer(); target != "" {
+		tc, ok := r.containers[target]
+		if !ok {
+			return nil, fmt.Errorf("container %q whose network namespace is shared is not found", target)
+		}
+		cont.nsPath = tc.nsPath
+	} else if strings.ToLower(node.NetworkMode) != "host" {
+		cont.NetworkSettings = r.mgmtIPs(node)
+	}
+
+	if r.NetNS && node.NetworkModeContainer() == "" {
+		netns, err := testutils.NewNS()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create netns of container %q: %v", node.LongName, err)
+		}
+		cont.netns = netns
+		cont.nsPath = netns.Path()
+	}
+
+	r.containers[node.LongName] = cont
+	node.NSPath = cont.nsPath
+	return nil, nil
+}
+
+// mgmtIPs returns the static management addresses of the node
+// or allocates the IPv4 address from the management network subnet
+func (r *Runtime) mgmtIPs(node *types.NodeConfig) types.GenericMgmtIPs {
+	ips := types.GenericMgmtIPs{
+		IPv4addr: node.MgmtIPv4Address,
+		IPv6addr: node.MgmtIPv6Address,
+	}
+	if ips.IPv4addr != "" {
+		ips.IPv4pLen = node.MgmtIPv4PrefixLength
+	}
+	if ips.IPv6addr != "" {
+		ips.IPv6pLen = node.MgmtIPv6PrefixLength
+	}
+	if ips.IPv4addr != "" || r.Mgmt.IPv4Subnet == "" {
+		return ips
+	}
+	_, subnet, err := net.ParseCIDR(r.Mgmt.IPv4Subnet)
+	if err != nil || subnet.IP.To4() == nil {
+		return ips
+	}
+	// the first address of the subnet is taken by the gateway
+	r.lastIP++
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(subnet.IP.To4())+uint32(r.lastIP)+1)
+	ips.IPv4addr = ip.String()
+	ips.IPv4pLen, _ = subnet.Mask.Size()
+	return ips
+}
+
+// lookup returns the container by its name or ID. The runtime lock must be held
+func (r *Runtime) lookup(nameOrID string) (*container, error) {
+	nameOrID = strings.TrimLeft(nameOrID, "/")
+	if c, ok := r.containers[nameOrID]; ok {
+		return c, nil
+	}
+	for _, c := range r.containers {
+		if c.ID == nameOrID || c.ShortID == nameOrID {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no such container: %s", nameOrID)
+}
+
+// containerCall records the call of the container method and returns the container.
+// The injected faults are matched against the container name. The runtime lock must be held
+func (r *Runtime) containerCall(method, nameOrID string) (*container, error) {
+	c, err := r.lookup(nameOrID)
+	if err != nil {
+		r.calls = append(r.calls, Call{Method: method, Arg: nameOrID})
+		return nil, err
+	}
+	if err := r.call(method, c.name); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *Runtime) StartContainer(_ context.Context, nameOrID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodStartContainer, nameOrID)
+	if err != nil {
+		return err
+	}
+	c.State, c.Status = "running", "Up"
+	return nil
+}
+
+func (r *Runtime) StopContainer(_ context.Context, nameOrID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodStopContainer, nameOrID)
+	if err != nil {
+		return err
+	}
+	c.State, c.Status = "exited", "Exited"
+	return nil
+}
+
+// ListContainers returns the containers matching the label and name filters
+func (r *Runtime) ListContainers(_ context.Context, filters []*types.GenericFilter) ([]types.GenericContainer, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.call(MethodListContainers, ""); err != nil {
+		return nil, err
+	}
+	var res []types.GenericContainer
+	for _, name := range sortedKeys(r.containers) {
+		c := r.containers[name]
+		if matchFilters(c, filters) {
+			res = append(res, c.GenericContainer)
+		}
+	}
+	return res, nil
+}
+
+func matchFilters(c *container, filters []*types.GenericFilter) bool {
+	for _, f := range filters {
+		switch f.FilterType {
+		case "label":
+			v, ok := c.Labels[f.Field]
+			if !ok || (f.Operator != "exists" && v != f.Match) {
+				return false
+			}
+		case "name":
+			if !strings.Contains(c.name, f.Match) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]*container) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Runtime) GetNSPath(_ context.Context, nameOrID string) (string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodGetNSPath, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	return c.nsPath, nil
+}
+
+// Exec runs the ExecFn function of the runtime for the running container
+func (r *Runtime) Exec(_ context.Context, nameOrID string, cmd []string) ([]byte, []byte, error) {
+	r.m.Lock()
+	c, err := r.containerCall(MethodExec, nameOrID)
+	exec := r.ExecFn
+	r.m.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.State != "running" {
+		return nil, nil, fmt.Errorf("container %s is not running", c.name)
+	}
+	if exec == nil {
+		return nil, nil, nil
+	}
+	return exec(c.name, cmd)
+}
+
+// SetLogs sets the stdout and stderr logs of the named container
+func (r *Runtime) SetLogs(name, stdout, stderr string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, ok := r.containers[name]
+	if !ok {
+		return fmt.Errorf("container %q not found", name)
+	}
+	c.stdout, c.stderr = stdout, stderr
+	return nil
+}
+
+// ContainerLogs writes the logs set with SetLogs,
+// when following the logs it blocks until the context is canceled
+func (r *Runtime) ContainerLogs(ctx context.Context, nameOrID string, opts runtime.LogsOptions, stdout, stderr io.Writer) error {
+	r.m.Lock()
+	c, err := r.containerCall(MethodContainerLogs, nameOrID)
+	var out, errOut string
+	if err == nil {
+		out, errOut = c.stdout, c.stderr
+	}
+	r.m.Unlock()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stdout, out); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stderr, errOut); err != nil {
+		return err
+	}
+	if opts.Follow {
+		<-ctx.Done()
+	}
+	return nil
+}
+
+// SetStats sets the resource usage of the named container
+func (r *Runtime) SetStats(name string, s runtime.ContainerStats) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, ok := r.containers[name]
+	if !ok {
+		return fmt.Errorf("container %q not found", name)
+	}
+	c.stats = s
+	return nil
+}
+
+// ContainerStats returns the resource usage set with SetStats
+func (r *Runtime) ContainerStats(_ context.Context, nameOrID string) (*runtime.ContainerStats, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodContainerStats, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	s := c.stats
+	return &s, nil
+}
+
+func (r *Runtime) ExecNotWait(ctx context.Context, nameOrID string, cmd []string) error {
+	r.m.Lock()
+	c, err := r.containerCall(MethodExecNotWait, nameOrID)
+	exec := r.ExecFn
+	r.m.Unlock()
+	if err != nil {
+		return err
+	}
+	if exec != nil {
+		_, _, err = exec(c.name, cmd)
+	}
+	return err
+}
+
+// DeleteContainer removes the container along with its network namespace
+func (r *Runtime) DeleteContainer(_ context.Context, nameOrID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	c, err := r.containerCall(MethodDeleteContainer, nameOrID)
+	if err != nil {
+		return err
+	}
+	delete(r.containers, c.name)
+	if c.netns == nil {
+		return nil
+	}
+	_ = c.netns.Close()
+	// lazy unmount, since the namespace mount may still be busy
+	if err := unix.Unmount(c.nsPath, unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to unmount netns of container %q: %v", c.name, err)
+	}
+	return os.Remove(c.nsPath)
+}
@@ -3,6 +3,7 @@ package ignite
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -137,17 +138,23 @@ func (c *IgniteRuntime) DeleteNet(ctx context.Context) error {
 	return c.ctrRuntime.DeleteNet(ctx)
 }
 
-func (*IgniteRuntime) PullImageIfRequired(_ context.Context, imageName string) error {
+// PullImage pulls the image with the underlying container runtime
+// and imports it to ignite, unless it is already imported
+func (c *IgniteRuntime) PullImage(ctx context.Context, imageName string, opts runtime.PullOptions) (string, error) {
 	ociRef, err := meta.NewOCIImageRef(imageName)
 	if err != nil {
-		return fmt.Errorf("failed to parse OCI image ref %q: %s", imageName, err)
+		return "", fmt.Errorf("failed to parse OCI image ref %q: %s", imageName, err)
+	}
+	digest, err := c.ctrRuntime.PullImage(ctx, imageName, opts)
+	if err != nil {
+		return "", err
 	}
 	_, err = operations.FindOrImportImage(providers.Client, ociRef)
 	if err != nil {
-		return fmt.Errorf("failed to find OCI image ref %q: %s", ociRef, err)
+		return "", fmt.Errorf("failed to find OCI image ref %q: %s", ociRef, err)
 	}
 
-	return nil
+	return digest, nil
 }
 
 func (c *IgniteRuntime) CreateContainer(ctx context.Context, node *types.NodeConfig) (interface{}, error) {
@@ -373,6 +380,32 @@ func (c *IgniteRuntime) GetNSPath(ctx context.Context, ctrId string) (string, er
 	return result, nil
 }
 
+// ContainerLogs writes the console output of the named VM, which is the log of its sandbox container
+func (c *IgniteRuntime) ContainerLogs(ctx context.Context, name string, opts runtime.LogsOptions, stdout, stderr io.Writer) error {
+	ls, ok := c.ctrRuntime.(runtime.LogStreamer)
+	if !ok {
+		return fmt.Errorf("runtime %q doesn't support container logs", c.ctrRuntime.GetName())
+	}
+	vm, err := providers.Client.VMs().Find(filter.NewVMFilter(name))
+	if err != nil {
+		return err
+	}
+	return ls.ContainerLogs(ctx, vm.PrefixedID(), opts, stdout, stderr)
+}
+
+// ContainerStats returns the resource usage of the named VM, which is the usage of its sandbox container
+func (c *IgniteRuntime) ContainerStats(ctx context.Context, name string) (*runtime.ContainerStats, error) {
+	sp, ok := c.ctrRuntime.(runtime.StatsProvider)
+	if !ok {
+		return nil, fmt.Errorf("runtime %q doesn't support container stats", c.ctrRuntime.GetName())
+	}
+	vm, err := providers.Client.VMs().Find(filter.NewVMFilter(name))
+	if err != nil {
+		return nil, err
+	}
+	return sp.ContainerStats(ctx, vm.PrefixedID())
+}
+
 func (*IgniteRuntime) Exec(context.Context, string, []string) ([]byte, []byte, error) {
 	log.Infof("Exec is not yet implemented for Ignite runtime")
 	return []byte{}, []byte{}, nil
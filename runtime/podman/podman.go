@@ -6,12 +6,17 @@ package podman
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
 	"time"
 
+	"github.com/containers/buildah/imagebuildah"
 	"github.com/containers/podman/v3/pkg/api/handlers"
 	"github.com/containers/podman/v3/pkg/bindings/containers"
 	"github.com/containers/podman/v3/pkg/bindings/images"
 	"github.com/containers/podman/v3/pkg/bindings/network"
+	"github.com/containers/podman/v3/pkg/domain/entities"
 	dockerTypes "github.com/docker/docker/api/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/srl-labs/containerlab/runtime"
@@ -127,21 +132,38 @@ func (r *PodmanRuntime) DeleteNet(ctx context.Context) error {
 	return nil
 }
 
-func (r *PodmanRuntime) PullImageIfRequired(ctx context.Context, image string) error {
+// PullImage pulls the image according to the pull policy and returns its digest
+func (r *PodmanRuntime) PullImage(ctx context.Context, image string, opts runtime.PullOptions) (string, error) {
 	ctx, err := r.connect(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 	// Check the existence
 	ex, err := images.Exists(ctx, image, &images.ExistsOptions{})
 	if err != nil {
-		return err
+		return "", err
 	}
-	// Pull the image if it doesn't exist
-	if !ex {
-		_, err = images.Pull(ctx, image, &images.PullOptions{})
+	switch {
+	case !ex && opts.Policy == runtime.PullPolicyNever:
+		return "", fmt.Errorf("image %s is not present and the image pull policy is %q", image, opts.Policy)
+	case !ex || opts.Policy == runtime.PullPolicyAlways:
+		pullOpts := new(images.PullOptions).WithQuiet(opts.Progress == nil)
+		if opts.Progress != nil {
+			pullOpts = pullOpts.WithProgressWriter(opts.Progress)
+		}
+		if opts.Auth != nil {
+			pullOpts = pullOpts.WithUsername(opts.Auth.Username).WithPassword(opts.Auth.Password)
+		}
+		log.Infof("Pulling %s container image", image)
+		if _, err = images.Pull(ctx, image, pullOpts); err != nil {
+			return "", err
+		}
 	}
-	return err
+	img, err := images.GetImage(ctx, image, &images.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return img.Digest.String(), nil
 }
 
 // CreateContainer creates a container based on the given NodeConfig and starts it as well
@@ -273,6 +295,102 @@ func (r *PodmanRuntime) ExecNotWait(ctx context.Context, cID string, cmd []strin
 	return nil
 }
 
+// BuildImage builds the image from the Dockerfile in the build context
+func (r *PodmanRuntime) BuildImage(ctx context.Context, opts runtime.BuildOptions) error {
+	ctx, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	labels := make([]string, 0, len(opts.Labels))
+	for k, v := range opts.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	out := opts.Progress
+	if out == nil {
+		out = ioutil.Discard
+	}
+	log.Infof("Building %s container image", opts.Tag)
+	_, err = images.Build(ctx, []string{filepath.Join(opts.Context, opts.Dockerfile)}, entities.BuildOptions{
+		BuildOptions: imagebuildah.BuildOptions{
+			ContextDirectory:       opts.Context,
+			Args:                   opts.Args,
+			Labels:                 labels,
+			Output:                 opts.Tag,
+			Out:                    out,
+			Err:                    out,
+			ReportWriter:           out,
+			RemoveIntermediateCtrs: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build %s: %v", opts.Tag, err)
+	}
+	log.Infof("Done building %s", opts.Tag)
+	return nil
+}
+
+// ContainerLogs writes the logs of the named container to the writers
+func (r *PodmanRuntime) ContainerLogs(ctx context.Context, name string, opts runtime.LogsOptions, stdout, stderr io.Writer) error {
+	ctx, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	logOpts := new(containers.LogOptions).WithFollow(opts.Follow).WithStdout(true).WithStderr(true)
+	if !opts.Since.IsZero() {
+		logOpts = logOpts.WithSince(opts.Since.Format(time.RFC3339))
+	}
+	// the log lines are received over the channels until the log stream ends
+	outCh, errCh := make(chan string), make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- containers.Logs(ctx, name, logOpts, outCh, errCh)
+	}()
+	for {
+		select {
+		case line := <-outCh:
+			fmt.Fprintln(stdout, line)
+		case line := <-errCh:
+			fmt.Fprintln(stderr, line)
+		case err := <-done:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ContainerStats returns the resource usage of the named container
+func (r *PodmanRuntime) ContainerStats(ctx context.Context, name string) (*runtime.ContainerStats, error) {
+	ctx, err := r.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := containers.Stats(ctx, []string{name}, new(containers.StatsOptions).WithStream(false))
+	if err != nil {
+		return nil, err
+	}
+	report, ok := <-reports
+	if !ok {
+		return nil, fmt.Errorf("no stats received for container %q", name)
+	}
+	if report.Error != nil {
+		return nil, report.Error
+	}
+	if len(report.Stats) != 1 {
+		return nil, fmt.Errorf("unexpected number of stats %d for container %q", len(report.Stats), name)
+	}
+	st := report.Stats[0]
+	return &runtime.ContainerStats{
+		Time:        time.Now(),
+		CPUUsage:    time.Duration(st.CPUNano),
+		MemoryUsage: st.MemUsage,
+		NetRxBytes:  st.NetInput,
+		NetTxBytes:  st.NetOutput,
+		PIDs:        st.PIDs,
+	}, nil
+}
+
 // DeleteContainer removes a given container from the system (if it exists)
 func (r *PodmanRuntime) DeleteContainer(ctx context.Context, contName string) error {
 	force := !r.config.GracefulShutdown
@@ -84,6 +84,20 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 		log.Errorf("Cannot convert mounts %v: %v", cfg.Binds, err)
 		mounts = nil
 	}
+	// Devices passed through to unprivileged containers
+	var devices []specs.LinuxDevice
+	if !cfg.Security.IsPrivileged() {
+		for _, d := range cfg.Security.Devices {
+			dev, err := types.ParseDevice(d)
+			if err != nil {
+				return sg, err
+			}
+			// podman expects the device in the source:destination:permissions form
+			devices = append(devices, specs.LinuxDevice{
+				Path: strings.Join([]string{dev.PathOnHost, dev.PathInContainer, dev.CgroupPermissions}, ":"),
+			})
+		}
+	}
 	specStorageConfig := specgen.ContainerStorageConfig{
 		Image: cfg.Image,
 		// Rootfs:            "",
@@ -95,7 +109,7 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 		// Volumes:           nil,
 		// OverlayVolumes:    nil,
 		// ImageVolumes:      nil,
-		// Devices:           nil,
+		Devices: devices,
 		// DeviceCGroupRule:  nil,
 		// IpcNS:             specgen.Namespace{},
 		// ShmSize:           nil,
@@ -106,9 +120,15 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 	}
 	// Security
 	specSecurityConfig := specgen.ContainerSecurityConfig{
-		Privileged: true,
+		Privileged: cfg.Security.IsPrivileged(),
 		User:       cfg.User,
 	}
+	if !specSecurityConfig.Privileged {
+		specSecurityConfig.CapAdd = cfg.Security.CapAdd
+		specSecurityConfig.CapDrop = cfg.Security.CapDrop
+		specSecurityConfig.SeccompProfilePath = cfg.Security.SeccompProfile
+		specSecurityConfig.ApparmorProfile = cfg.Security.ApparmorProfile
+	}
 	// Going with the defaults for cgroups
 	specCgroupConfig := specgen.ContainerCgroupConfig{
 		CgroupNS: specgen.Namespace{},
@@ -159,7 +179,15 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 	// Everything below is related to network spec of a container
 	specNetConfig := specgen.ContainerNetworkConfig{}
 	netns := cfg.NetworkMode
+	if cfg.NetworkModeContainer() != "" {
+		netns = "container"
+	}
 	switch netns {
+	case "container":
+		// addresses, ports and dns belong to the container owning the netns
+		specNetConfig = specgen.ContainerNetworkConfig{
+			NetNS: specgen.Namespace{NSMode: specgen.FromContainer, Value: cfg.NetworkModeContainer()},
+		}
 	case "host":
 		specNetConfig = specgen.ContainerNetworkConfig{
 			NetNS: specgen.Namespace{NSMode: "host"},
@@ -188,6 +216,14 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 		if err != nil {
 			return sg, err
 		}
+		dnsServers := make([]net.IP, 0, len(cfg.DNSServers))
+		for _, srv := range cfg.DNSServers {
+			ip := net.ParseIP(srv)
+			if ip == nil {
+				return sg, fmt.Errorf("invalid DNS server address %q", srv)
+			}
+			dnsServers = append(dnsServers, ip)
+		}
 		specNetConfig = specgen.ContainerNetworkConfig{
 			// Aliases:             nil,
 			NetNS:               specgen.Namespace{NSMode: "bridge"},
@@ -199,7 +235,7 @@ func (r *PodmanRuntime) createContainerSpec(ctx context.Context, cfg *types.Node
 			Expose:              expose,
 			CNINetworks:         nets,
 			// UseImageResolvConf:  false,
-			// DNSServers:          nil,
+			DNSServers: dnsServers,
 			// DNSSearch:           nil,
 			// DNSOptions:          nil,
 			UseImageHosts: false,
@@ -266,12 +302,21 @@ func (r *PodmanRuntime) produceGenericContainerList(ctx context.Context, cList [
 			ID:              v.ID,
 			ShortID:         v.ID[:12],
 			Image:           v.Image,
+			ImageID:         v.ImageID,
 			State:           v.State,
 			Status:          v.Status,
 			Labels:          v.Labels,
 			Pid:             v.Pid,
 			NetworkSettings: netSettings,
 		}
+		for _, p := range v.Ports {
+			genericList[i].Ports = append(genericList[i].Ports, types.GenericPortBinding{
+				HostIP:        p.HostIP,
+				HostPort:      int(p.HostPort),
+				ContainerPort: int(p.ContainerPort),
+				Protocol:      p.Protocol,
+			})
+		}
 	}
 	log.Debugf("Method produceGenericContainerList returns %+v", genericList)
 	return genericList, nil
@@ -306,6 +351,7 @@ func (*PodmanRuntime) extractMgmtIP(ctx context.Context, cID string) (types.Gene
 		IPv4pLen: v4pLen,
 		IPv6addr: v6addr,
 		IPv6pLen: v6pLen,
+		MAC:      mgmtData.MacAddress,
 	}
 	return toReturn, nil
 }
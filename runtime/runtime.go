@@ -6,6 +6,8 @@ package runtime
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/srl-labs/containerlab/types"
@@ -29,8 +31,9 @@ type ContainerRuntime interface {
 	CreateNet(context.Context) error
 	// Delete container (bridge) network
 	DeleteNet(context.Context) error
-	// Pull container image if not present
-	PullImageIfRequired(context.Context, string) error
+	// Pull container image according to the pull policy and return its digest,
+	// the digest is empty when the runtime can't resolve it
+	PullImage(context.Context, string, PullOptions) (string, error)
 	// Create container returns an extra interface that can be used to receive signals
 	// about the container life-cycle after it was created, e.g. for post-deploy tasks
 	CreateContainer(context.Context, *types.NodeConfig) (interface{}, error)
@@ -53,6 +56,114 @@ type ContainerRuntime interface {
 	GetName() string
 }
 
+// PullPolicy defines when the container images are pulled
+type PullPolicy string
+
+const (
+	// PullPolicyAlways pulls the image even if it is present
+	PullPolicyAlways PullPolicy = "always"
+	// PullPolicyIfNotPresent pulls the image only if it is missing
+	PullPolicyIfNotPresent PullPolicy = "if-not-present"
+	// PullPolicyNever never pulls the image and fails if it is missing
+	PullPolicyNever PullPolicy = "never"
+)
+
+// ParsePullPolicy parses the image pull policy, an empty policy defaults to if-not-present
+func ParsePullPolicy(s string) (PullPolicy, error) {
+	switch p := PullPolicy(s); p {
+	case "":
+		return PullPolicyIfNotPresent, nil
+	case PullPolicyAlways, PullPolicyIfNotPresent, PullPolicyNever:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown image pull policy %q, use one of %q", s,
+		[]PullPolicy{PullPolicyAlways, PullPolicyIfNotPresent, PullPolicyNever})
+}
+
+// PullOptions are the options of an image pull
+type PullOptions struct {
+	Policy PullPolicy
+	// Auth holds the registry credentials, nil for anonymous pulls
+	Auth *types.RegistryAuth
+	// Progress receives the human readable pull progress, the progress is discarded if nil
+	Progress io.Writer
+}
+
+// LogsOptions are the options of the container logs stream
+type LogsOptions struct {
+	// Follow keeps streaming the new logs until the context is canceled
+	Follow bool
+	// Since limits the logs to the ones written after the given time, all logs are streamed if zero
+	Since time.Time
+}
+
+// LogStreamer is implemented by the runtimes able to stream the container logs,
+// which is the console output for the VM based nodes
+type LogStreamer interface {
+	// ContainerLogs writes the stdout and stderr logs of the named container to the writers
+	ContainerLogs(ctx context.Context, name string, opts LogsOptions, stdout, stderr io.Writer) error
+}
+
+// ContainerStats is a snapshot of the resource usage of a container, the counters are cumulative
+type ContainerStats struct {
+	// Time the snapshot is taken at
+	Time time.Time
+	// CPUUsage is the CPU time consumed by the container
+	CPUUsage time.Duration
+	// MemoryUsage is the memory used by the container in bytes
+	MemoryUsage uint64
+	// bytes received and transmitted over all container interfaces
+	NetRxBytes uint64
+	NetTxBytes uint64
+	// number of processes and threads of the container
+	PIDs uint64
+}
+
+// StatsProvider is implemented by the runtimes able to report the resource usage of the containers
+type StatsProvider interface {
+	// ContainerStats returns the resource usage snapshot of the named container
+	ContainerStats(ctx context.Context, name string) (*ContainerStats, error)
+}
+
+// ExtraNetworker is implemented by the runtimes able to attach the nodes to additional networks
+// besides the management one. The nodes are attached to the networks listed in their config
+// when their containers are created
+type ExtraNetworker interface {
+	// CreateExtraNet creates the additional network or reuses the existing one
+	CreateExtraNet(ctx context.Context, n *types.MgmtNet) error
+	// DeleteExtraNet deletes the additional network unless it is still in use
+	DeleteExtraNet(ctx context.Context, n *types.MgmtNet) error
+}
+
+// ExtraNetRenamer is implemented by the runtimes which name the interfaces of the additional networks
+// on their own, so that the interfaces are renamed to the names set in the node config
+type ExtraNetRenamer interface {
+	// RenameExtraNetIfaces renames the interfaces of the additional networks of the node container
+	RenameExtraNetIfaces(ctx context.Context, node *types.NodeConfig) error
+}
+
+// BuildOptions are the options of an image build
+type BuildOptions struct {
+	// Context is the absolute path to the build context directory
+	Context string
+	// Dockerfile is the path to the Dockerfile relative to the build context
+	Dockerfile string
+	// Args are the build-time variables
+	Args map[string]string
+	// Tag is the name the built image is tagged with
+	Tag string
+	// Labels are added to the built image
+	Labels map[string]string
+	// Progress receives the human readable build output, the output is discarded if nil
+	Progress io.Writer
+}
+
+// ImageBuilder is implemented by the runtimes able to build the node images from Dockerfiles
+type ImageBuilder interface {
+	// BuildImage builds the image from the Dockerfile and tags it
+	BuildImage(ctx context.Context, opts BuildOptions) error
+}
+
 type Initializer func() ContainerRuntime
 
 type RuntimeOption func(ContainerRuntime)
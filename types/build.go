@@ -0,0 +1,15 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package types
+
+// BuildConfig describes how the node image is built from a Dockerfile
+type BuildConfig struct {
+	// path to the build context directory, relative paths are resolved against the current directory
+	Context string `yaml:"context,omitempty"`
+	// path to the Dockerfile relative to the build context, defaults to Dockerfile
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+	// build-time variables passed to the Dockerfile ARG instructions
+	Args map[string]string `yaml:"args,omitempty"`
+}
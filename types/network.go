@@ -0,0 +1,60 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package types
+
+import (
+	"fmt"
+	"net"
+)
+
+// maxIfaceNameLen is the longest linux interface name
+const maxIfaceNameLen = 15
+
+// NetworkAttachment attaches a node to an additional network defined in the topology networks section
+type NetworkAttachment struct {
+	// name of the network in the topology networks section
+	Name string `yaml:"name"`
+	// name of the node interface connected to the network, defaults to the network name
+	Interface string `yaml:"interface,omitempty"`
+	// optional static addresses of the node in the network
+	IPv4 string `yaml:"ipv4,omitempty"`
+	IPv6 string `yaml:"ipv6,omitempty"`
+	// Net is the network the node is attached to, set when the topology is parsed
+	Net *MgmtNet `yaml:"-"`
+}
+
+// Validate checks the interface name and the static addresses of the attachment
+// against the network it attaches to
+func (a *NetworkAttachment) Validate() error {
+	if a.Interface == "" || len(a.Interface) > maxIfaceNameLen {
+		return fmt.Errorf("network %q: interface name %q must be 1 to %d characters long", a.Name, a.Interface, maxIfaceNameLen)
+	}
+	if a.Interface == "eth0" {
+		return fmt.Errorf("network %q: interface eth0 is reserved for the management network", a.Name)
+	}
+	for _, addr := range []struct{ ip, subnet, family string }{
+		{a.IPv4, a.Net.IPv4Subnet, "ipv4"},
+		{a.IPv6, a.Net.IPv6Subnet, "ipv6"},
+	} {
+		if addr.ip == "" {
+			continue
+		}
+		ip := net.ParseIP(addr.ip)
+		if ip == nil || (ip.To4() != nil) != (addr.family == "ipv4") {
+			return fmt.Errorf("network %q: invalid %s address %q", a.Name, addr.family, addr.ip)
+		}
+		if addr.subnet == "" {
+			return fmt.Errorf("network %q: static %s address requires the network %s subnet", a.Name, addr.family, addr.family)
+		}
+		_, subnet, err := net.ParseCIDR(addr.subnet)
+		if err != nil {
+			return fmt.Errorf("network %q: invalid %s subnet %q: %v", a.Name, addr.family, addr.subnet, err)
+		}
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("network %q: %s address %s is not in the network subnet %s", a.Name, addr.family, addr.ip, addr.subnet)
+		}
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ type NodeDefinition struct {
 	EnforceStartupConfig bool              `yaml:"enforce-startup-config,omitempty"`
 	Config               *ConfigDispatcher `yaml:"config,omitempty"`
 	Image                string            `yaml:"image,omitempty"`
+	ImagePullPolicy      string            `yaml:"image-pull-policy,omitempty"`
 	License              string            `yaml:"license,omitempty"`
 	Position             string            `yaml:"position,omitempty"`
 	Entrypoint           string            `yaml:"entrypoint,omitempty"`
@@ -54,6 +55,12 @@ type NodeDefinition struct {
 	CPUSet string `yaml:"cpu-set,omitempty"`
 	// Set node Memory (cgroup or hypervisor)
 	Memory string `yaml:"memory,omitempty"`
+	// Container security settings
+	Security *SecurityConfig `yaml:"security,omitempty"`
+	// Additional networks the node attaches to
+	Networks []*NetworkAttachment `yaml:"networks,omitempty"`
+	// Node image built from a Dockerfile instead of a prebuilt image
+	Build *BuildConfig `yaml:"build,omitempty"`
 
 	// Extra options, may be kind specific
 	Extras *Extras `yaml:"extras,omitempty"`
@@ -116,6 +123,13 @@ func (n *NodeDefinition) GetImage() string {
 	return n.Image
 }
 
+func (n *NodeDefinition) GetImagePullPolicy() string {
+	if n == nil {
+		return ""
+	}
+	return n.ImagePullPolicy
+}
+
 func (n *NodeDefinition) GetLicense() string {
 	if n == nil {
 		return ""
@@ -256,6 +270,27 @@ func (n *NodeDefinition) GetExec() []string {
 	return n.Exec
 }
 
+func (n *NodeDefinition) GetSecurity() *SecurityConfig {
+	if n == nil {
+		return nil
+	}
+	return n.Security
+}
+
+func (n *NodeDefinition) GetNetworks() []*NetworkAttachment {
+	if n == nil {
+		return nil
+	}
+	return n.Networks
+}
+
+func (n *NodeDefinition) GetBuild() *BuildConfig {
+	if n == nil {
+		return nil
+	}
+	return n.Build
+}
+
 func (n *NodeDefinition) GetExtras() *Extras {
 	if n == nil {
 		return nil
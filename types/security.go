@@ -0,0 +1,117 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecurityConfig holds the security settings of a node container
+type SecurityConfig struct {
+	// runs the container in privileged mode. When unset, the default of the node kind applies,
+	// the nodes of the kinds without a default are privileged
+	Privileged *bool `yaml:"privileged,omitempty"`
+	// linux capabilities added to and dropped from the default set of the runtime
+	CapAdd  []string `yaml:"cap-add,omitempty"`
+	CapDrop []string `yaml:"cap-drop,omitempty"`
+	// host devices passed through to an unprivileged container in the `host[:container][:permissions]` form
+	Devices []string `yaml:"devices,omitempty"`
+	// path to a seccomp profile, or `unconfined`
+	SeccompProfile string `yaml:"seccomp-profile,omitempty"`
+	// name of a loaded apparmor profile, or `unconfined`
+	ApparmorProfile string `yaml:"apparmor-profile,omitempty"`
+}
+
+// IsPrivileged returns true if the container needs to run in privileged mode
+func (s *SecurityConfig) IsPrivileged() bool {
+	return s == nil || s.Privileged == nil || *s.Privileged
+}
+
+// WithDefaults returns a copy of the security config with the fields that are not set in s taken from d
+func (s *SecurityConfig) WithDefaults(d *SecurityConfig) *SecurityConfig {
+	if s == nil && d == nil {
+		return nil
+	}
+	r := &SecurityConfig{}
+	if s != nil {
+		*r = *s
+	}
+	if d == nil {
+		return r
+	}
+	// the default is copied, so that changing the node config doesn't change the default
+	if r.Privileged == nil && d.Privileged != nil {
+		p := *d.Privileged
+		r.Privileged = &p
+	}
+	if len(r.CapAdd) == 0 {
+		r.CapAdd = d.CapAdd
+	}
+	if len(r.CapDrop) == 0 {
+		r.CapDrop = d.CapDrop
+	}
+	if len(r.Devices) == 0 {
+		r.Devices = d.Devices
+	}
+	if r.SeccompProfile == "" {
+		r.SeccompProfile = d.SeccompProfile
+	}
+	if r.ApparmorProfile == "" {
+		r.ApparmorProfile = d.ApparmorProfile
+	}
+	return r
+}
+
+// DeviceMapping is a host device passed through to a container
+type DeviceMapping struct {
+	PathOnHost        string
+	PathInContainer   string
+	CgroupPermissions string
+}
+
+// ParseDevice parses a device string in the `host[:container][:permissions]` form,
+// the device is mapped to the same path in the container with `rwm` permissions by default
+func ParseDevice(d string) (DeviceMapping, error) {
+	dev := DeviceMapping{CgroupPermissions: "rwm"}
+	parts := strings.Split(d, ":")
+	switch len(parts) {
+	case 3:
+		dev.CgroupPermissions = parts[2]
+		dev.PathInContainer = parts[1]
+	case 2:
+		if validDevicePermissions(parts[1]) {
+			dev.CgroupPermissions = parts[1]
+		} else {
+			dev.PathInContainer = parts[1]
+		}
+	case 1:
+	default:
+		return dev, fmt.Errorf("invalid device specification %q", d)
+	}
+	dev.PathOnHost = parts[0]
+	if dev.PathInContainer == "" {
+		dev.PathInContainer = dev.PathOnHost
+	}
+	if !strings.HasPrefix(dev.PathOnHost, "/") || !strings.HasPrefix(dev.PathInContainer, "/") {
+		return dev, fmt.Errorf("invalid device specification %q: device paths must be absolute", d)
+	}
+	if !validDevicePermissions(dev.CgroupPermissions) {
+		return dev, fmt.Errorf("invalid device specification %q: permissions must be a combination of r, w and m", d)
+	}
+	return dev, nil
+}
+
+func validDevicePermissions(p string) bool {
+	if p == "" {
+		return false
+	}
+	for _, c := range p {
+		if !strings.ContainsRune("rwm", c) {
+			return false
+		}
+	}
+	return true
+}
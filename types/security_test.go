@@ -0,0 +1,86 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package types
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDevice(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		want    DeviceMapping
+		wantErr bool
+	}{
+		"host_only": {
+			in:   "/dev/kvm",
+			want: DeviceMapping{PathOnHost: "/dev/kvm", PathInContainer: "/dev/kvm", CgroupPermissions: "rwm"},
+		},
+		"container_path": {
+			in:   "/dev/net/tun:/dev/tun0",
+			want: DeviceMapping{PathOnHost: "/dev/net/tun", PathInContainer: "/dev/tun0", CgroupPermissions: "rwm"},
+		},
+		"permissions": {
+			in:   "/dev/kvm:rw",
+			want: DeviceMapping{PathOnHost: "/dev/kvm", PathInContainer: "/dev/kvm", CgroupPermissions: "rw"},
+		},
+		"full": {
+			in:   "/dev/net/tun:/dev/net/tun:r",
+			want: DeviceMapping{PathOnHost: "/dev/net/tun", PathInContainer: "/dev/net/tun", CgroupPermissions: "r"},
+		},
+		"relative_path": {
+			in:      "dev/kvm",
+			wantErr: true,
+		},
+		"bad_permissions": {
+			in:      "/dev/kvm:/dev/kvm:rx",
+			wantErr: true,
+		},
+		"too_many_fields": {
+			in:      "/dev/kvm:/dev/kvm:rw:m",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDevice(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unexpected device mapping (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestSecurityWithDefaults(t *testing.T) {
+	unprivileged := false
+	d := &SecurityConfig{Privileged: &unprivileged, CapAdd: []string{"NET_ADMIN"}}
+
+	r := (*SecurityConfig)(nil).WithDefaults(d)
+	if r.IsPrivileged() {
+		t.Error("expected the default unprivileged mode")
+	}
+	// changing the resolved config keeps the default intact
+	*r.Privileged = true
+	if *d.Privileged {
+		t.Error("default privileged mode is changed through the resolved config")
+	}
+
+	privileged := true
+	r = (&SecurityConfig{Privileged: &privileged}).WithDefaults(d)
+	if !r.IsPrivileged() {
+		t.Error("expected the privileged mode set in the node config")
+	}
+	if d := cmp.Diff([]string{"NET_ADMIN"}, r.CapAdd); d != "" {
+		t.Errorf("unexpected capabilities (-want +got):\n%s", d)
+	}
+}
@@ -15,6 +15,8 @@ type Topology struct {
 	Kinds    map[string]*NodeDefinition `yaml:"kinds,omitempty"`
 	Nodes    map[string]*NodeDefinition `yaml:"nodes,omitempty"`
 	Links    []*LinkConfig              `yaml:"links,omitempty"`
+	// additional networks the nodes attach to besides the management network, keyed by name
+	Networks map[string]*MgmtNet `yaml:"networks,omitempty"`
 }
 
 func NewTopology() *Topology {
@@ -30,6 +32,15 @@ type LinkConfig struct {
 	Endpoints []string
 	Labels    map[string]string      `yaml:"labels,omitempty"`
 	Vars      map[string]interface{} `yaml:"vars,omitempty"`
+	// veth link mtu, defaults to 9500
+	MTU int `yaml:"mtu,omitempty"`
+	// MAC addresses of the link endpoints, listed in the order of the endpoints.
+	// An empty string makes containerlab generate a MAC address for an endpoint
+	MACs []string `yaml:"macs,omitempty"`
+	// vlan settings applied to the port of a linux bridge this link is connected to
+	Vlan  int   `yaml:"vlan,omitempty"`  // access vlan
+	Vlans []int `yaml:"vlans,omitempty"` // tagged vlans of a trunk port
+	PVID  int   `yaml:"pvid,omitempty"`  // untagged (native) vlan of a trunk port
 }
 
 func (t *Topology) GetDefaults() *NodeDefinition {
@@ -148,7 +159,9 @@ func (t *Topology) GetNodeConfigDispatcher(name string) *ConfigDispatcher {
 	return nil
 }
 
-func (t *Topology) GetNodeStartupConfig(name string) (string, error) {
+// GetNodeStartupConfig returns the path to the node startup config,
+// a relative path is resolved against baseDir or the working directory when baseDir is empty
+func (t *Topology) GetNodeStartupConfig(name, baseDir string) (string, error) {
 	var cfg string
 	if ndef, ok := t.Nodes[name]; ok {
 		var err error
@@ -160,7 +173,7 @@ func (t *Topology) GetNodeStartupConfig(name string) (string, error) {
 			cfg = t.GetDefaults().GetStartupConfig()
 		}
 		if cfg != "" {
-			cfg, err = resolvePath(cfg)
+			cfg, err = resolvePath(cfg, baseDir)
 			if err != nil {
 				return "", err
 			}
@@ -197,7 +210,9 @@ func (t *Topology) GetNodeEnforceStartupConfig(name string) bool {
 	return false
 }
 
-func (t *Topology) GetNodeLicense(name string) (string, error) {
+// GetNodeLicense returns the path to the node license file,
+// a relative path is resolved against baseDir or the working directory when baseDir is empty
+func (t *Topology) GetNodeLicense(name, baseDir string) (string, error) {
 	var license string
 	if ndef, ok := t.Nodes[name]; ok {
 		var err error
@@ -209,7 +224,7 @@ func (t *Topology) GetNodeLicense(name string) (string, error) {
 			license = t.GetDefaults().GetLicense()
 		}
 		if license != "" {
-			license, err = resolvePath(license)
+			license, err = resolvePath(license, baseDir)
 			if err != nil {
 				return "", err
 			}
@@ -233,6 +248,19 @@ func (t *Topology) GetNodeImage(name string) string {
 	return ""
 }
 
+func (t *Topology) GetNodeImagePullPolicy(name string) string {
+	if ndef, ok := t.Nodes[name]; ok {
+		if ndef.GetImagePullPolicy() != "" {
+			return ndef.GetImagePullPolicy()
+		}
+		if t.GetKind(t.GetNodeKind(name)).GetImagePullPolicy() != "" {
+			return t.GetKind(t.GetNodeKind(name)).GetImagePullPolicy()
+		}
+		return t.GetDefaults().GetImagePullPolicy()
+	}
+	return ""
+}
+
 func (t *Topology) GetNodeGroup(name string) string {
 	if ndef, ok := t.Nodes[name]; ok {
 		if ndef.GetGroup() != "" {
@@ -413,6 +441,47 @@ func (t *Topology) GetNodeMemory(name string) string {
 	return ""
 }
 
+// GetNodeSecurity returns the security settings of the given node,
+// every setting not defined for a node is taken from its kind and then from the defaults
+func (t *Topology) GetNodeSecurity(name string) *SecurityConfig {
+	if ndef, ok := t.Nodes[name]; ok {
+		return ndef.GetSecurity().
+			WithDefaults(t.GetKind(t.GetNodeKind(name)).GetSecurity()).
+			WithDefaults(t.GetDefaults().GetSecurity())
+	}
+	return nil
+}
+
+// GetNodeNetworks returns the additional networks the given node attaches to,
+// the node attachments replace those of its kind, which replace the defaults
+func (t *Topology) GetNodeNetworks(name string) []*NetworkAttachment {
+	if ndef, ok := t.Nodes[name]; ok {
+		if ndef.GetNetworks() != nil {
+			return ndef.GetNetworks()
+		}
+		if t.GetKind(t.GetNodeKind(name)).GetNetworks() != nil {
+			return t.GetKind(t.GetNodeKind(name)).GetNetworks()
+		}
+		return t.GetDefaults().GetNetworks()
+	}
+	return nil
+}
+
+// GetNodeBuild returns the image build of the given node,
+// the node build replaces the one of its kind, which replaces the defaults
+func (t *Topology) GetNodeBuild(name string) *BuildConfig {
+	if ndef, ok := t.Nodes[name]; ok {
+		if ndef.GetBuild() != nil {
+			return ndef.GetBuild()
+		}
+		if t.GetKind(t.GetNodeKind(name)).GetBuild() != nil {
+			return t.GetKind(t.GetNodeKind(name)).GetBuild()
+		}
+		return t.GetDefaults().GetBuild()
+	}
+	return nil
+}
+
 // Returns the 'extras' section for the given node
 func (t *Topology) GetNodeExtras(name string) *Extras {
 	if ndef, ok := t.Nodes[name]; ok {
@@ -443,8 +512,9 @@ func (t *Topology) ImportEnvs() {
 	}
 }
 
-//resolvePath resolves a string path by expanding `~` to home dir or getting Abs path for the given path
-func resolvePath(p string) (string, error) {
+//resolvePath resolves a string path by expanding `~` to home dir or getting Abs path for the given path,
+// relative paths are resolved against base directory, if it is set
+func resolvePath(p, base string) (string, error) {
 	if p == "" {
 		return "", nil
 	}
@@ -457,6 +527,9 @@ func resolvePath(p string) (string, error) {
 			return "", err
 		}
 	default:
+		if base != "" && !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
 		p, err = filepath.Abs(p)
 		if err != nil {
 			return "", err
@@ -306,11 +306,11 @@ func TestGetNodeType(t *testing.T) {
 func TestGetNodeConfig(t *testing.T) {
 	for name, item := range topologyTestSet {
 		t.Logf("%q test item", name)
-		config, err := item.input.GetNodeStartupConfig("node1")
+		config, err := item.input.GetNodeStartupConfig("node1", "")
 		if err != nil {
 			t.Fatal(err)
 		}
-		wantedConfig, err := resolvePath(item.want["node1"].StartupConfig)
+		wantedConfig, err := resolvePath(item.want["node1"].StartupConfig, "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -341,11 +341,11 @@ func TestGetNodeImage(t *testing.T) {
 func TestGetNodeLicense(t *testing.T) {
 	for name, item := range topologyTestSet {
 		t.Logf("%q test item", name)
-		lic, err := item.input.GetNodeLicense("node1")
+		lic, err := item.input.GetNodeLicense("node1", "")
 		if err != nil {
 			t.Fatal(err)
 		}
-		wantedLicense, err := resolvePath(item.want["node1"].License)
+		wantedLicense, err := resolvePath(item.want["node1"].License, "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -456,3 +456,58 @@ func TestGetNodeLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNodeSecurity(t *testing.T) {
+	privileged, unprivileged := true, false
+	topo := &Topology{
+		Defaults: &NodeDefinition{
+			Security: &SecurityConfig{
+				Privileged:      &privileged,
+				ApparmorProfile: "clab-default",
+			},
+		},
+		Kinds: map[string]*NodeDefinition{
+			"linux": {
+				Security: &SecurityConfig{
+					Privileged: &unprivileged,
+					CapAdd:     []string{"NET_ADMIN"},
+					Devices:    []string{"/dev/net/tun"},
+				},
+			},
+		},
+		Nodes: map[string]*NodeDefinition{
+			"node1": {Kind: "linux", Security: &SecurityConfig{CapAdd: []string{"SYS_ADMIN"}}},
+			"node2": {Kind: "srl"},
+			"node3": {Kind: "linux"},
+		},
+	}
+	want := map[string]*SecurityConfig{
+		"node1": {
+			Privileged:      &unprivileged,
+			CapAdd:          []string{"SYS_ADMIN"},
+			Devices:         []string{"/dev/net/tun"},
+			ApparmorProfile: "clab-default",
+		},
+		"node2": {
+			Privileged:      &privileged,
+			ApparmorProfile: "clab-default",
+		},
+		"node3": {
+			Privileged:      &unprivileged,
+			CapAdd:          []string{"NET_ADMIN"},
+			Devices:         []string{"/dev/net/tun"},
+			ApparmorProfile: "clab-default",
+		},
+	}
+	for name, w := range want {
+		if d := cmp.Diff(w, topo.GetNodeSecurity(name)); d != "" {
+			t.Errorf("node %q: unexpected security config (-want +got):\n%s", name, d)
+		}
+	}
+	if topo.GetNodeSecurity("node2").IsPrivileged() != true || topo.GetNodeSecurity("node3").IsPrivileged() != false {
+		t.Error("unexpected privileged mode of the nodes")
+	}
+	if !(&Topology{Nodes: map[string]*NodeDefinition{"node1": {}}}).GetNodeSecurity("node1").IsPrivileged() {
+		t.Error("nodes without security settings must be privileged")
+	}
+}
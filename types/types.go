@@ -25,6 +25,10 @@ type Link struct {
 	MTU    int
 	Labels map[string]string
 	Vars   map[string]interface{}
+	// vlan settings of a bridge port
+	Vlan  int   // access vlan
+	Vlans []int // tagged vlans
+	PVID  int   // untagged (native) vlan of a trunk port
 }
 
 func (link *Link) String() string {
@@ -39,6 +43,17 @@ type Endpoint struct {
 	EndpointName string
 	// mac address
 	MAC string
+	// vlan id of a sub-interface when endpoint name follows <iface>.<vlan-id> pattern
+	VlanID int
+}
+
+// IfaceName returns the name of the interface backing the endpoint.
+// For vlan sub-interface endpoints this is the name of the parent interface
+func (e *Endpoint) IfaceName() string {
+	if e.VlanID == 0 {
+		return e.EndpointName
+	}
+	return strings.TrimSuffix(e.EndpointName, fmt.Sprintf(".%d", e.VlanID))
 }
 
 // mgmtNet struct defines the management network options
@@ -51,6 +66,8 @@ type MgmtNet struct {
 	IPv6Subnet string `yaml:"ipv6_subnet,omitempty"`
 	IPv6Gw     string `yaml:"ipv6-gw,omitempty"`
 	MTU        string `yaml:"mtu,omitempty"`
+	// run the lab DNS server on the management bridge and use it as the nodes resolver
+	DNS bool `yaml:"dns,omitempty"`
 }
 
 // NodeConfig is a struct that contains the information of a container element
@@ -72,6 +89,8 @@ type NodeConfig struct {
 	Position             string
 	License              string
 	Image                string
+	ImagePullPolicy      string // image pull policy, one of always, if-not-present or never
+	ImageDigest          string // resolved digest of the node image
 	Sysctls              map[string]string
 	User                 string
 	Entrypoint           string
@@ -81,7 +100,8 @@ type NodeConfig struct {
 	Binds                []string    // Bind mounts strings (src:dest:options)
 	PortBindings         nat.PortMap // PortBindings define the bindings between the container ports and host ports
 	PortSet              nat.PortSet // PortSet define the ports that should be exposed on a container
-	// container networking mode. if set to `host` the host networking will be used for this node, else bridged network
+	// container networking mode. if set to `host` the host networking will be used for this node,
+	// `container:<container-name>` shares the network namespace of another container, else bridged network
 	NetworkMode          string
 	MgmtNet              string // name of the docker network this node is connected to with its first interface
 	MgmtIPv4Address      string
@@ -96,6 +116,7 @@ type NodeConfig struct {
 	NSPath               string   // network namespace path for this node
 	Publish              []string // list of ports to publish with mysocketctl
 	ExtraHosts           []string // Extra /etc/hosts entries for all nodes
+	DNSServers           []string // DNS servers used by the node container as resolvers
 	// container labels
 	Labels map[string]string
 	// Slice of pointers to local endpoints
@@ -108,6 +129,12 @@ type NodeConfig struct {
 	CPU    float64
 	CPUSet string
 	Memory string
+	// Container security settings
+	Security *SecurityConfig
+	// Additional networks the node attaches to besides the management network
+	Networks []*NetworkAttachment
+	// Build of the node image, the image is set to the built image tag when the images are verified
+	Build *BuildConfig
 
 	DeploymentStatus string // status that is set by containerlab to indicate deployment stage
 
@@ -148,9 +175,21 @@ func (node *NodeConfig) GenerateConfig(dst, templ string) error {
 	return err
 }
 
+// NetworkModeContainerPrefix prefixes the name of the container whose network namespace is shared by a node
+const NetworkModeContainerPrefix = "container:"
+
+// NetworkModeContainer returns the name of the container whose network namespace the node shares
+// with the `container:<name>` network mode, and an empty string for the other network modes
+func (node *NodeConfig) NetworkModeContainer() string {
+	if strings.HasPrefix(strings.ToLower(node.NetworkMode), NetworkModeContainerPrefix) {
+		return node.NetworkMode[len(NetworkModeContainerPrefix):]
+	}
+	return ""
+}
+
 func DisableTxOffload(n *NodeConfig) error {
-	// skip this if node runs in host mode
-	if strings.ToLower(n.NetworkMode) == "host" {
+	// skip this if node runs in host mode or shares the netns of another node which manages it
+	if strings.ToLower(n.NetworkMode) == "host" || n.NetworkModeContainer() != "" {
 		return nil
 	}
 	// disable tx checksum offload for linux containers on eth0 interfaces
@@ -175,11 +214,14 @@ type GenericContainer struct {
 	ID              string
 	ShortID         string // trimmed ID for display purposes
 	Image           string
+	ImageID         string // image ID (digest) the container was created from
 	State           string
 	Status          string
 	Labels          map[string]string
 	Pid             int
 	NetworkSettings GenericMgmtIPs
+	Ports           []GenericPortBinding // ports exposed by the container
+	Runtime         string               // name of the runtime managing the container
 }
 
 type GenericMgmtIPs struct {
@@ -187,6 +229,22 @@ type GenericMgmtIPs struct {
 	IPv4pLen int
 	IPv6addr string
 	IPv6pLen int
+	MAC      string // MAC address of the management interface
+}
+
+// GenericPortBinding represents a container port and its binding to the host
+type GenericPortBinding struct {
+	HostIP        string
+	HostPort      int
+	ContainerPort int
+	Protocol      string
+}
+
+func (p GenericPortBinding) String() string {
+	if p.HostPort == 0 {
+		return fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol)
+	}
+	return fmt.Sprintf("%s:%d->%d/%s", p.HostIP, p.HostPort, p.ContainerPort, p.Protocol)
 }
 
 type GenericFilter struct {
@@ -240,4 +298,29 @@ func (cd *ConfigDispatcher) GetVars() map[string]interface{} {
 type Extras struct {
 	SRLAgents     []string `yaml:"srl-agents,omitempty"`     // Nokia SR Linux agents. As of now just the agents spec files can be provided here
 	MysocketProxy string   `yaml:"mysocket-proxy,omitempty"` // Proxy address that mysocketctl will use
+	// bridge and ovs-bridge kinds
+	CreateBridge  bool `yaml:"create,omitempty"`         // when set to true the bridge is created on deploy and removed on destroy
+	VlanFiltering bool `yaml:"vlan-filtering,omitempty"` // enable vlan filtering on a created linux bridge
+	STP           bool `yaml:"stp,omitempty"`            // enable spanning tree protocol on a created bridge
+}
+
+// InventoryConfig defines a user-defined inventory rendered from a Go template after the lab is deployed
+type InventoryConfig struct {
+	Template string `yaml:"template"` // path to the inventory template
+	Output   string `yaml:"output"`   // path to the rendered inventory, relative paths are resolved within the lab directory
+}
+
+// RegistryAuth holds the credentials of a container registry
+type RegistryAuth struct {
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// IdentityToken is used instead of the username and password by the registries supporting tokens
+	IdentityToken string `yaml:"identity-token,omitempty"`
+}
+
+// WebhookConfig defines an HTTP endpoint the lab lifecycle events are posted to
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`               // URL the events are posted to as JSON
+	Events  []string          `yaml:"events,omitempty"`  // event types to post, all events are posted when empty
+	Headers map[string]string `yaml:"headers,omitempty"` // extra HTTP headers, e.g. authorization
 }
@@ -0,0 +1,52 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildContextExcludes returns the patterns of the files excluded from the build context
+// by its .dockerignore file. The Dockerfile and the .dockerignore file are always part of the context
+func BuildContextExcludes(contextDir, dockerfile string) ([]string, error) {
+	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var excludes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		invert := line[0] == '!'
+		if invert {
+			line = strings.TrimSpace(line[1:])
+		}
+		if line != "" {
+			line = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(line)), "/")
+		}
+		if invert {
+			line = "!" + line
+		}
+		excludes = append(excludes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(excludes) == 0 {
+		return nil, nil
+	}
+	return append(excludes, "!"+filepath.ToSlash(filepath.Clean(dockerfile)), "!.dockerignore"), nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildContextExcludes(t *testing.T) {
+	dir := t.TempDir()
+	got, err := BuildContextExcludes(dir, "Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got excludes %q without .dockerignore, want none", got)
+	}
+
+	ignore := "# comment\n\n/build/\n*.log\n! build/keep.txt \nsub/../tmp\n"
+	if err := os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = BuildContextExcludes(dir, "./docker/Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"build", "*.log", "!build/keep.txt", "tmp", "!docker/Dockerfile", "!.dockerignore"}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected excludes (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procDir   = "/proc"
+	cgroupDir = "/sys/fs/cgroup"
+)
+
+// CgroupStats is the resource usage of the processes of a cgroup
+type CgroupStats struct {
+	CPUUsage    time.Duration
+	MemoryUsage uint64
+	PIDs        uint64
+}
+
+// ReadCgroupStats reads the resource usage of the cgroup the process with the given pid belongs to.
+// Both cgroup v1 and v2 hierarchies are supported
+func ReadCgroupStats(pid int) (*CgroupStats, error) {
+	return readCgroupStats(procDir, cgroupDir, pid)
+}
+
+func readCgroupStats(procDir, cgroupDir string, pid int) (*CgroupStats, error) {
+	paths, err := readProcCgroups(filepath.Join(procDir, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	s := &CgroupStats{}
+	// cgroup v2 unified hierarchy
+	if _, err := os.Stat(filepath.Join(cgroupDir, "cgroup.controllers")); err == nil {
+		dir := filepath.Join(cgroupDir, paths[""])
+		stat, err := readKeyValueFile(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			return nil, err
+		}
+		s.CPUUsage = time.Duration(stat["usage_usec"]) * time.Microsecond
+		if s.MemoryUsage, err = readUintFile(filepath.Join(dir, "memory.current")); err != nil {
+			return nil, err
+		}
+		if s.PIDs, err = readUintFile(filepath.Join(dir, "pids.current")); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	// cgroup v1 hierarchies per controller
+	usage, err := readUintFile(filepath.Join(cgroupDir, "cpuacct", paths["cpuacct"], "cpuacct.usage"))
+	if err != nil {
+		return nil, err
+	}
+	s.CPUUsage = time.Duration(usage)
+	if s.MemoryUsage, err = readUintFile(filepath.Join(cgroupDir, "memory", paths["memory"], "memory.usage_in_bytes")); err != nil {
+		return nil, err
+	}
+	if s.PIDs, err = readUintFile(filepath.Join(cgroupDir, "pids", paths["pids"], "pids.current")); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readProcCgroups returns the cgroup paths of a process keyed by the controller,
+// the path in the v2 unified hierarchy has an empty key
+func readProcCgroups(file string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("unexpected line %q in %s", line, file)
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			paths[c] = parts[2]
+		}
+	}
+	return paths, nil
+}
+
+// ReadNetDevStats returns the number of bytes received and transmitted over all interfaces
+// of the network namespace the process with the given pid runs in, the loopback interface excluded
+func ReadNetDevStats(pid int) (rx, tx uint64, err error) {
+	return readNetDevStats(filepath.Join(procDir, strconv.Itoa(pid), "net", "dev"))
+}
+
+func readNetDevStats(file string) (rx, tx uint64, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for i := 0; sc.Scan(); i++ {
+		// the first two lines are the table headers
+		if i < 2 {
+			continue
+		}
+		parts := strings.SplitN(sc.Text(), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "lo" {
+			continue
+		}
+		// the receive bytes are the first field and the transmit bytes are the ninth one
+		fields := strings.Fields(parts[1])
+		if len(fields) < 9 {
+			return 0, 0, fmt.Errorf("unexpected line %q in %s", sc.Text(), file)
+		}
+		r, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		t, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		rx += r
+		tx += t
+	}
+	return rx, tx, sc.Err()
+}
+
+func readUintFile(file string) (uint64, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// readKeyValueFile reads a file of `key value` lines with unsigned integer values
+func readKeyValueFile(file string) (map[string]uint64, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := map[string]uint64{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected line %q in %s: %v", line, file, err)
+		}
+		r[fields[0]] = v
+	}
+	return r, nil
+}
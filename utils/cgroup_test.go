@@ -0,0 +1,89 @@
+// Copyright 2020 Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeFiles creates the files with their contents under the dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadCgroupStats(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		want  *CgroupStats
+	}{
+		"v2": {
+			files: map[string]string{
+				"proc/42/cgroup":                                      "0::/system.slice/docker-abc.scope\n",
+				"cgroup/cgroup.controllers":                           "cpu memory pids\n",
+				"cgroup/system.slice/docker-abc.scope/cpu.stat":       "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\n",
+				"cgroup/system.slice/docker-abc.scope/memory.current": "1048576\n",
+				"cgroup/system.slice/docker-abc.scope/pids.current":   "12\n",
+			},
+			want: &CgroupStats{CPUUsage: 1500 * time.Millisecond, MemoryUsage: 1048576, PIDs: 12},
+		},
+		"v1": {
+			files: map[string]string{
+				"proc/42/cgroup": "12:pids:/clab/n1\n" +
+					"4:cpu,cpuacct:/clab/n1\n" +
+					"3:memory:/clab/n1\n" +
+					"0::/\n",
+				"cgroup/cpuacct/clab/n1/cpuacct.usage":        "2000000000\n",
+				"cgroup/memory/clab/n1/memory.usage_in_bytes": "2097152\n",
+				"cgroup/pids/clab/n1/pids.current":            "3\n",
+			},
+			want: &CgroupStats{CPUUsage: 2 * time.Second, MemoryUsage: 2097152, PIDs: 3},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			got, err := readCgroupStats(filepath.Join(dir, "proc"), filepath.Join(dir, "cgroup"), 42)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unexpected cgroup stats (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestReadNetDevStats(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"dev": `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    6000      60    0    0    0     0          0         0     6000      60    0    0    0     0       0          0
+  eth0:    1000      10    0    0    0     0          0         0      200       2    0    0    0     0       0          0
+ e1-1:     500       5    0    0    0     0          0         0     3000      30    0    0    0     0       0          0
+`})
+	rx, tx, err := readNetDevStats(filepath.Join(dir, "dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rx != 1500 || tx != 3200 {
+		t.Errorf("got rx %d and tx %d bytes, want 1500 and 3200", rx, tx)
+	}
+}
@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"io"
 	"os"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 const (
@@ -42,6 +45,44 @@ func GetCanonicalImageName(imageName string) string {
 	return canonicalImageName
 }
 
+// ImageRepoDigest returns the digest of the image from its repository digests,
+// preferring the digest of the image repository. An empty string is returned for images without repository digests
+func ImageRepoDigest(imageName string, repoDigests []string) string {
+	if i := strings.LastIndexByte(imageName, '@'); i >= 0 {
+		return imageName[i+1:]
+	}
+	repo := imageName
+	// strip the tag, taking care of the registry port
+	if i := strings.LastIndexByte(repo, ':'); i > strings.LastIndexByte(repo, '/') {
+		repo = repo[:i]
+	}
+	repo = GetCanonicalImageName(repo)
+
+	var digest string
+	for _, rd := range repoDigests {
+		i := strings.LastIndexByte(rd, '@')
+		if i < 0 {
+			continue
+		}
+		if GetCanonicalImageName(rd[:i]) == repo {
+			return rd[i+1:]
+		}
+		if digest == "" {
+			digest = rd[i+1:]
+		}
+	}
+	return digest
+}
+
+// TerminalFd returns the file descriptor of the writer and whether it is a terminal
+func TerminalFd(w io.Writer) (uintptr, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	return f.Fd(), term.IsTerminal(int(f.Fd()))
+}
+
 func GetCNIBinaryPath() string {
 	var cniPath string
 	var ok bool
@@ -0,0 +1,41 @@
+package utils
+
+import "testing"
+
+func TestImageRepoDigest(t *testing.T) {
+	tests := map[string]struct {
+		image       string
+		repoDigests []string
+		want        string
+	}{
+		"docker_hub": {
+			image:       "alpine:3",
+			repoDigests: []string{"alpine@sha256:aaa"},
+			want:        "sha256:aaa",
+		},
+		"matching_repo": {
+			image:       "registry.local:5000/nos/srl:21.6",
+			repoDigests: []string{"ghcr.io/nokia/srlinux@sha256:bbb", "registry.local:5000/nos/srl@sha256:ccc"},
+			want:        "sha256:ccc",
+		},
+		"retagged": {
+			image:       "srlinux:latest",
+			repoDigests: []string{"ghcr.io/nokia/srlinux@sha256:bbb"},
+			want:        "sha256:bbb",
+		},
+		"pinned": {
+			image:       "ghcr.io/nokia/srlinux@sha256:ddd",
+			repoDigests: []string{"ghcr.io/nokia/srlinux@sha256:bbb"},
+			want:        "sha256:ddd",
+		},
+		"local_build": {
+			image: "my-nos:dev",
+			want:  "",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert(t, ImageRepoDigest(tc.image, tc.repoDigests), tc.want)
+		})
+	}
+}
@@ -6,9 +6,13 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
+	"github.com/containernetworking/plugins/pkg/ns"
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 )
@@ -26,6 +30,33 @@ func BridgeByName(name string) (*netlink.Bridge, error) {
 	return br, nil
 }
 
+// CreateBridge creates a linux bridge with a given name in the current netns and sets it up.
+// vlanFiltering and stp control the respective bridge options
+func CreateBridge(name string, vlanFiltering, stp bool) error {
+	la := netlink.NewLinkAttrs()
+	la.Name = name
+	br := &netlink.Bridge{
+		LinkAttrs:     la,
+		VlanFiltering: &vlanFiltering,
+	}
+	if err := netlink.LinkAdd(br); err != nil {
+		return fmt.Errorf("failed to create bridge %q: %v", name, err)
+	}
+
+	if stp {
+		// netlink library doesn't expose stp_state, thus using sysfs
+		f := fmt.Sprintf("/sys/class/net/%s/bridge/stp_state", name)
+		if err := ioutil.WriteFile(f, []byte("1"), 0644); err != nil {
+			return fmt.Errorf("failed to enable stp on bridge %q: %v", name, err)
+		}
+	}
+
+	if err := netlink.LinkSetUp(br); err != nil {
+		return fmt.Errorf("failed to set bridge %q up: %v", name, err)
+	}
+	return nil
+}
+
 // linkContainerNS creates a symlink for containers network namespace
 // so that it can be managed by iproute2 utility
 func LinkContainerNS(nspath, containerName string) error {
@@ -41,6 +72,37 @@ func LinkContainerNS(nspath, containerName string) error {
 	return nil
 }
 
+// RenameLinkByMAC renames the interface with the given MAC address in the network namespace
+func RenameLinkByMAC(nspath, mac, name string) error {
+	netns, err := ns.GetNS(nspath)
+	if err != nil {
+		return err
+	}
+	defer netns.Close()
+	return netns.Do(func(_ ns.NetNS) error {
+		links, err := netlink.LinkList()
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			if !strings.EqualFold(l.Attrs().HardwareAddr.String(), mac) {
+				continue
+			}
+			if l.Attrs().Name == name {
+				return nil
+			}
+			if err := netlink.LinkSetDown(l); err != nil {
+				return err
+			}
+			if err := netlink.LinkSetName(l, name); err != nil {
+				return fmt.Errorf("failed to rename interface %s to %s: %v", l.Attrs().Name, name, err)
+			}
+			return netlink.LinkSetUp(l)
+		}
+		return fmt.Errorf("interface with MAC address %s is not found", mac)
+	})
+}
+
 // getDefaultDockerMTU gets the MTU of a docker0 bridge interface
 // if fails to get the MTU of docker0, returns "1500"
 func DefaultNetMTU() (string, error) {
@@ -86,6 +148,14 @@ func GenMac(oui string) string {
 	return fmt.Sprintf("%s:%02x:%02x:%02x", oui, buf[0], buf[1], buf[2])
 }
 
+// GenMacFromString generates a MAC address for a given OUI
+// with the last three octets derived from the hash of s.
+// The same input string always results in the same MAC address
+func GenMacFromString(oui, s string) string {
+	h := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%s:%02x:%02x:%02x", oui, h[0], h[1], h[2])
+}
+
 // deleteNetnsSymlink deletes a network namespace and removes the symlink created by linkContainerNS func
 func DeleteNetnsSymlink(n string) error {
 	log.Debug("Deleting netns symlink: ", n)